@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+	"github.com/pur1fying/GO_BAAS/internal/axisbundle"
+	"github.com/pur1fying/GO_BAAS/internal/catalog"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/global_info"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+var errUsage = errors.New("invalid arguments")
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"grant-role":     {usage: "grant-role <username|email> <role>", run: cmdGrantRole},
+	"import-catalog": {usage: "import-catalog [dir]", run: cmdImportCatalog},
+	"export-axes":    {usage: "export-axes <username|email> <file" + axisbundle.Extension + ">", run: cmdExportAxes},
+	"import-axes":    {usage: "import-axes <username|email> <file" + axisbundle.Extension + ">", run: cmdImportAxes},
+	"migrate":        {usage: "migrate [status | up [version] | down <version> | baseline <version>]", run: cmdMigrate},
+}
+
+func runCommand(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errors.New("Unknown command : " + args[0] + "\n" + commandUsage())
+	}
+	err := cmd.run(args[1:])
+	if errors.Is(err, errUsage) {
+		return errors.New("Usage : " + cmd.usage)
+	}
+	return err
+}
+
+func commandUsage() string {
+	lines := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		lines = append(lines, "  "+cmd.usage)
+	}
+	sort.Strings(lines)
+	return "Commands:\n" + strings.Join(lines, "\n")
+}
+
+func cmdGrantRole(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	u, err := findUser(args[0])
+	if err != nil {
+		return err
+	}
+	if err := rbac.AssignRole(u.ID, args[1], nil); err != nil {
+		return err
+	}
+	logger.BAASInfo("Granted role", args[1], "to", u.Username)
+	return nil
+}
+
+func cmdImportCatalog(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	dir := catalogDir()
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	return importCatalog(dir)
+}
+
+func importCatalog(dir string) error {
+	logger.BAASInfo("Catalog Dir :", dir)
+	results, err := catalog.Import(dir)
+	if err != nil {
+		return err
+	}
+	for _, each := range results {
+		if each.Skipped {
+			logger.BAASInfo("Catalog", each.Kind, "is up to date :", each.File)
+		}
+	}
+	return nil
+}
+
+// cmdExportAxes writes every axis of a user into a bundle, for backups and
+// moving to another instance.
+func cmdExportAxes(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	u, err := findUser(args[0])
+	if err != nil {
+		return err
+	}
+	axes, err := axis.ListOwned(u.ID)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	n, err := axis.ExportBundle(f, u.Username, axes)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	logger.BAASInfo("Exported", strconv.Itoa(n), "axes of", u.Username, "to", args[1])
+	return nil
+}
+
+func cmdImportAxes(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	u, err := findUser(args[0])
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	b, err := axisbundle.Read(file, info.Size())
+	if err != nil {
+		return err
+	}
+	results, err := axis.ImportBundle(context.Background(), b, u.ID)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, each := range results {
+		if each.AxisID == 0 {
+			failed++
+			logger.BAASError("Axis", strconv.Itoa(each.Index), each.Title, ":", each.Error)
+		}
+	}
+	logger.BAASInfo("Imported", strconv.Itoa(len(results)-failed), "axes for", u.Username+",", strconv.Itoa(failed), "failed")
+	return nil
+}
+
+// cmdMigrate applies migrations without starting the server. down reverts
+// until version is the latest applied, baseline marks a schema set up by
+// hand as migrated up to version.
+func cmdMigrate(args []string) error {
+	if len(args) == 0 {
+		return database.Migrate(migrations())
+	}
+	version := 0
+	switch {
+	case args[0] == "status" && len(args) == 1:
+		return printMigrationStatus()
+	case args[0] == "up" && len(args) <= 2:
+	case (args[0] == "down" || args[0] == "baseline") && len(args) == 2:
+	default:
+		return errUsage
+	}
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 0 {
+			return errUsage
+		}
+		version = v
+	}
+	switch args[0] {
+	case "down":
+		return database.Rollback(migrations(), version)
+	case "baseline":
+		return database.Baseline(migrations(), version)
+	}
+	return database.MigrateTo(migrations(), version)
+}
+
+func printMigrationStatus() error {
+	states, err := database.MigrationStatus(migrations())
+	for _, s := range states {
+		state := "pending"
+		if s.Applied != nil {
+			state = "applied " + s.Applied.TimeApply.Format("2006-01-02 15:04:05")
+		}
+		if s.Drift {
+			state += ", changed since"
+		}
+		if !s.HasDown {
+			state += ", no down script"
+		}
+		logger.BAASInfo(s.String(), ":", state)
+	}
+	return err
+}
+
+func catalogDir() string {
+	if config.Config.Catalog.DataDir != "" {
+		return config.Config.Catalog.DataDir
+	}
+	return global_info.GO_BAAS_CATALOG_DIR
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func seedInitialAdmin() error {
+	name := config.Config.RBAC.InitialAdmin
+	if name == "" {
+		return nil
+	}
+	u, err := findUser(name)
+	if err != nil {
+		return err
+	}
+	logger.BAASInfo("Initial Admin :", u.Username)
+	return rbac.AssignRole(u.ID, rbac.RoleAdmin, nil)
+}
+
+func findUser(usernameOrEmail string) (*user.User, error) {
+	u, err := user.GetByUsername(usernameOrEmail)
+	if errors.Is(err, user.ErrNotFound) {
+		u, err = user.GetByEmail(usernameOrEmail)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find user %q : %w", usernameOrEmail, err)
+	}
+	return u, nil
+}
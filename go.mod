@@ -9,6 +9,7 @@ require (
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/natefinch/lumberjack v2.0.0+incompatible
 	go.uber.org/zap v1.27.1
+	golang.org/x/crypto v0.43.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	tokenScopesKey
+	languagesKey
+)
+
+func WithUserID(ctx context.Context, userID uint64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+func withLanguages(ctx context.Context, langs []string) context.Context {
+	return context.WithValue(ctx, languagesKey, langs)
+}
+
+func CurrentUserID(r *http.Request) (uint64, bool) {
+	id, ok := r.Context().Value(userIDKey).(uint64)
+	return id, ok
+}
+
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := CurrentUserID(r); !ok {
+			ResponseWithError(w, http.StatusUnauthorized, CodeUnauthorized, "login required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,124 @@
+package api
+
+import "github.com/pur1fying/GO_BAAS/internal/i18n"
+
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeInternal          = "internal_error"
+	CodeAccountSuspended  = "account_suspended"
+	CodeAccountBanned     = "account_banned"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeValidationFailed  = "validation_failed"
+	CodeRateLimited       = "rate_limited"
+
+	CodeFileTooLarge         = "file_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeMediaTypeMismatch    = "media_type_mismatch"
+	CodeArchiveRejected      = "archive_rejected"
+	CodeImageRejected        = "image_rejected"
+	CodeFileRejected         = "file_rejected"
+)
+
+// errorMessages describes every code in each supported language. The
+// message of a response stays specific and in English, the localized one
+// is what clients show to players.
+var errorMessages = map[string]map[string]string{
+	CodeInvalidRequest: {
+		i18n.English:  "The request is invalid.",
+		i18n.Japanese: "リクエストが正しくありません。",
+		i18n.Chinese:  "请求无效。",
+	},
+	CodeUnauthorized: {
+		i18n.English:  "Please log in.",
+		i18n.Japanese: "ログインしてください。",
+		i18n.Chinese:  "请先登录。",
+	},
+	CodeForbidden: {
+		i18n.English:  "You are not allowed to do this.",
+		i18n.Japanese: "この操作を行う権限がありません。",
+		i18n.Chinese:  "你没有执行此操作的权限。",
+	},
+	CodeNotFound: {
+		i18n.English:  "The requested resource was not found.",
+		i18n.Japanese: "リソースが見つかりません。",
+		i18n.Chinese:  "请求的资源不存在。",
+	},
+	CodeConflict: {
+		i18n.English:  "The request conflicts with the current state.",
+		i18n.Japanese: "現在の状態と競合しています。",
+		i18n.Chinese:  "请求与当前状态冲突。",
+	},
+	CodeInternal: {
+		i18n.English:  "Something went wrong on our side. Please try again later.",
+		i18n.Japanese: "サーバーでエラーが発生しました。しばらくしてから再度お試しください。",
+		i18n.Chinese:  "服务器内部错误, 请稍后再试。",
+	},
+	CodeAccountSuspended: {
+		i18n.English:  "Your account is suspended.",
+		i18n.Japanese: "アカウントは一時停止されています。",
+		i18n.Chinese:  "你的账号已被暂停使用。",
+	},
+	CodeAccountBanned: {
+		i18n.English:  "Your account is banned.",
+		i18n.Japanese: "アカウントは停止されています。",
+		i18n.Chinese:  "你的账号已被封禁。",
+	},
+	CodeInsufficientScope: {
+		i18n.English:  "The access token lacks the required scope.",
+		i18n.Japanese: "アクセストークンに必要なスコープがありません。",
+		i18n.Chinese:  "访问令牌缺少所需的权限范围。",
+	},
+	CodeValidationFailed: {
+		i18n.English:  "The submitted data failed validation.",
+		i18n.Japanese: "送信されたデータの検証に失敗しました。",
+		i18n.Chinese:  "提交的数据未通过校验。",
+	},
+	CodeRateLimited: {
+		i18n.English:  "Too many requests. Please try again later.",
+		i18n.Japanese: "リクエストが多すぎます。しばらくしてから再度お試しください。",
+		i18n.Chinese:  "请求过于频繁, 请稍后再试。",
+	},
+	CodeFileTooLarge: {
+		i18n.English:  "The file is too large.",
+		i18n.Japanese: "ファイルが大きすぎます。",
+		i18n.Chinese:  "文件过大。",
+	},
+	CodeUnsupportedMediaType: {
+		i18n.English:  "This file type is not allowed here.",
+		i18n.Japanese: "このファイル形式はアップロードできません。",
+		i18n.Chinese:  "不支持此文件类型。",
+	},
+	CodeMediaTypeMismatch: {
+		i18n.English:  "The file content does not match its type.",
+		i18n.Japanese: "ファイルの内容が形式と一致しません。",
+		i18n.Chinese:  "文件内容与声明的类型不符。",
+	},
+	CodeArchiveRejected: {
+		i18n.English:  "The archive is damaged or unsafe to extract.",
+		i18n.Japanese: "アーカイブが破損しているか、安全に展開できません。",
+		i18n.Chinese:  "压缩包已损坏或无法安全解压。",
+	},
+	CodeImageRejected: {
+		i18n.English:  "The image could not be read or is too large.",
+		i18n.Japanese: "画像を読み込めないか、サイズが大きすぎます。",
+		i18n.Chinese:  "图片无法读取或尺寸过大。",
+	},
+	CodeFileRejected: {
+		i18n.English:  "The file was rejected by a security check.",
+		i18n.Japanese: "ファイルはセキュリティチェックで拒否されました。",
+		i18n.Chinese:  "文件未通过安全检查。",
+	},
+}
+
+// LocalizedMessage falls back to English, and to "" for unknown codes.
+func LocalizedMessage(code, lang string) string {
+	messages := errorMessages[code]
+	if msg, ok := messages[lang]; ok {
+		return msg
+	}
+	return messages[i18n.English]
+}
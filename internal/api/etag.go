@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CheckETag sets the ETag of the response and answers 304 when the client
+// already holds that version. It returns true if the caller is done.
+func CheckETag(w http.ResponseWriter, r *http.Request, etag string) bool {
+	etag = `"` + etag + `"`
+	w.Header().Set("ETag", etag)
+	for _, each := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		each = strings.TrimPrefix(strings.TrimSpace(each), "W/")
+		if each == etag || each == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
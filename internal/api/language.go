@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/pur1fying/GO_BAAS/internal/i18n"
+)
+
+// languageWriter carries the negotiated language to ResponseWithError, which
+// only sees the ResponseWriter.
+type languageWriter struct {
+	http.ResponseWriter
+	lang string
+}
+
+func (w *languageWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Localize negotiates the response language from Accept-Language. A lang
+// query parameter takes precedence for links that cannot set headers.
+func Localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		langs := i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+		if lang := i18n.Normalize(r.URL.Query().Get("lang")); lang != "" {
+			langs = append([]string{lang}, langs...)
+		}
+		langs = append(langs, i18n.Default())
+		r = r.WithContext(withLanguages(r.Context(), langs))
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", langs[0])
+		next.ServeHTTP(&languageWriter{ResponseWriter: w, lang: langs[0]}, r)
+	})
+}
+
+// Languages returns the languages the client accepts, most preferred first
+// and ending with the default language.
+func Languages(r *http.Request) []string {
+	if langs, ok := r.Context().Value(languagesKey).([]string); ok {
+		return langs
+	}
+	return []string{i18n.Default()}
+}
+
+// Language is the single language used where no per item choice is made.
+func Language(r *http.Request) string {
+	return Languages(r)[0]
+}
+
+func writerLanguage(w http.ResponseWriter) string {
+	for {
+		switch v := w.(type) {
+		case *languageWriter:
+			return v.lang
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return i18n.Default()
+		}
+	}
+}
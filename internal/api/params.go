@@ -0,0 +1,16 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func URLParamID(r *http.Request, key string) (uint64, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, key), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return id, true
+}
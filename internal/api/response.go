@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+const maxJsonBodySize = 1 << 20
+
+type ErrorResponse struct {
+	Code             string      `json:"code"`
+	Message          string      `json:"message"`
+	LocalizedMessage string      `json:"localized_message,omitempty"`
+	Details          interface{} `json:"details,omitempty"`
+}
+
+func ResponseWithJson(w http.ResponseWriter, statusCode int, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.BAASError(fmt.Sprintf("JSON 序列化失败: %v", err))
+		w.WriteHeader(500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(data)
+}
+
+func ResponseWithError(w http.ResponseWriter, statusCode int, code string, message string) {
+	ResponseWithErrorDetails(w, statusCode, code, message, nil)
+}
+
+func ResponseWithErrorDetails(w http.ResponseWriter, statusCode int, code string, message string, details interface{}) {
+	ResponseWithJson(w, statusCode, ErrorResponse{
+		Code:             code,
+		Message:          message,
+		LocalizedMessage: LocalizedMessage(code, writerLanguage(w)),
+		Details:          details,
+	})
+}
+
+func ResponseWithInternalError(w http.ResponseWriter, err error) {
+	logger.BAASError("Internal Error :", err.Error())
+	ResponseWithError(w, http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+func DecodeJson(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxJsonBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("empty request body")
+		}
+		return err
+	}
+	return nil
+}
+
+// DecodeOptionalJson is DecodeJson for endpoints whose body may be omitted.
+func DecodeOptionalJson(r *http.Request, v interface{}) error {
+	if r.ContentLength == 0 {
+		return nil
+	}
+	return DecodeJson(r, v)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Scopes a personal access token may carry. Session requests hold them all.
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeAxisRead     = "axis:read"
+	ScopeAxisWrite    = "axis:write"
+)
+
+var KnownScopes = []string{ScopeProfileRead, ScopeProfileWrite, ScopeAxisRead, ScopeAxisWrite}
+
+// WithTokenScopes marks the request as authenticated by a personal access
+// token restricted to scopes.
+func WithTokenScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, tokenScopesKey, scopes)
+}
+
+func IsTokenRequest(r *http.Request) bool {
+	_, ok := r.Context().Value(tokenScopesKey).([]string)
+	return ok
+}
+
+func HasScope(r *http.Request, scope string) bool {
+	scopes, ok := r.Context().Value(tokenScopesKey).([]string)
+	if !ok {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r, scope) {
+				ResponseWithError(w, http.StatusForbidden, CodeInsufficientScope, "token lacks scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects personal access tokens, for account management
+// endpoints that must only be reachable from an interactive login.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsTokenRequest(r) {
+			ResponseWithError(w, http.StatusForbidden, CodeInsufficientScope, "not allowed with an access token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
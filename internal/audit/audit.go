@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+// Entry is one privileged action, such as a moderator hiding a comment.
+// ActorID is nil for actions the system took by itself.
+type Entry struct {
+	ID         uint64             `db:"id" json:"id"`
+	ActorID    *uint64            `db:"actor_id" json:"actor_id"`
+	ActorName  *string            `db:"actor_name" json:"actor_name"`
+	Action     string             `db:"action" json:"action"`
+	TargetType string             `db:"target_type" json:"target_type"`
+	TargetID   uint64             `db:"target_id" json:"target_id"`
+	Reason     *string            `db:"reason" json:"reason"`
+	Details    types.NullJSONText `db:"details" json:"details"`
+	TimeCreate time.Time          `db:"time_create" json:"time_create"`
+}
+
+type Filter struct {
+	ActorID    uint64
+	TargetType string
+	TargetID   uint64
+	Action     string
+	Offset     int
+	Limit      int
+}
+
+// Record writes an entry, inside the transaction of the action when there
+// is one so the log cannot disagree with the data.
+func Record(db sqlx.Execer, e Entry) error {
+	_, err := db.Exec(
+		`INSERT INTO audit_log (actor_id, action, target_type, target_id, reason, details) VALUES (?, ?, ?, ?, ?, ?)`,
+		e.ActorID, e.Action, e.TargetType, e.TargetID, e.Reason, e.Details,
+	)
+	return err
+}
+
+// Actor returns the ActorID of an entry, 0 standing for the system.
+func Actor(userID uint64) *uint64 {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
+
+func List(f Filter) ([]Entry, error) {
+	where := []string{"1 = 1"}
+	var args []interface{}
+	if f.ActorID != 0 {
+		where = append(where, "l.actor_id = ?")
+		args = append(args, f.ActorID)
+	}
+	if f.TargetType != "" {
+		where = append(where, "l.target_type = ?")
+		args = append(args, f.TargetType)
+	}
+	if f.TargetID != 0 {
+		where = append(where, "l.target_id = ?")
+		args = append(args, f.TargetID)
+	}
+	if f.Action != "" {
+		where = append(where, "l.action = ?")
+		args = append(args, f.Action)
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	entries := []Entry{}
+	err := database.DB.Select(&entries,
+		`SELECT l.id, l.actor_id, u.username AS actor_name, l.action, l.target_type, l.target_id, l.reason, l.details, l.time_create
+		FROM audit_log l LEFT JOIN users u ON u.id = l.actor_id
+		WHERE `+strings.Join(where, " AND ")+` ORDER BY l.id DESC LIMIT ? OFFSET ?`,
+		args...,
+	)
+	return entries, err
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func handlerList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := Filter{
+		TargetType: q.Get("target_type"),
+		Action:     q.Get("action"),
+		Limit:      defaultPageSize,
+	}
+	for key, dst := range map[string]*uint64{"actor_id": &f.ActorID, "target_id": &f.TargetID} {
+		if v := q.Get(key); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid "+key)
+				return
+			}
+			*dst = id
+		}
+	}
+	page := 1
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page")
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page_size")
+			return
+		}
+		f.Limit = n
+	}
+	f.Offset = (page - 1) * f.Limit
+
+	entries, err := List(f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": f.Limit,
+		"items":     entries,
+	})
+}
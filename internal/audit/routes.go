@@ -0,0 +1,14 @@
+package audit
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(api.RequireSession, rbac.RequirePermission(rbac.PermAxisModerate))
+	r.Get("/", handlerList)
+	return r
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/token"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+// Authenticate resolves the session cookie or a personal access token sent
+// as "Authorization: Bearer", if any, and stores the user id in the request
+// context. Requests without credentials or with an expired session pass
+// through anonymously; invalid tokens and blocked accounts are rejected.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := bearerToken(r); ok {
+			authenticateToken(w, r, next, bearer)
+			return
+		}
+
+		cookie, err := r.Cookie(user.SessionCookieName)
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u, err := user.LookupSession(cookie.Value)
+		if errors.Is(err, user.ErrSessionInvalid) {
+			// An expired cookie must not lock the browser out of logging
+			// in again, so it is dropped and the request goes on anonymously.
+			http.SetCookie(w, user.SessionCookie("", -1))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+		if !checkStatus(w, u) {
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(api.WithUserID(r.Context(), u.ID)))
+	})
+}
+
+func authenticateToken(w http.ResponseWriter, r *http.Request, next http.Handler, bearer string) {
+	t, err := token.Lookup(bearer, api.ClientIP(r))
+	if errors.Is(err, token.ErrInvalid) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		api.ResponseWithError(w, http.StatusUnauthorized, api.CodeUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	scopes, err := t.ScopeList()
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+
+	u, err := user.GetByID(t.UserID)
+	if errors.Is(err, user.ErrNotFound) {
+		api.ResponseWithError(w, http.StatusUnauthorized, api.CodeUnauthorized, token.ErrInvalid.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	if !checkStatus(w, u) {
+		return
+	}
+	ctx := api.WithTokenScopes(api.WithUserID(r.Context(), u.ID), scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
+
+func checkStatus(w http.ResponseWriter, u *user.User) bool {
+	switch u.EffectiveStatus(time.Now()) {
+	case user.StatusSuspended:
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeAccountSuspended, "account suspended")
+		return false
+	case user.StatusBanned:
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeAccountBanned, "account banned")
+		return false
+	}
+	return true
+}
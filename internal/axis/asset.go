@@ -0,0 +1,69 @@
+package axis
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+var ErrAssetNotFound = errors.New("asset not found")
+
+// Asset is a file that travels with an axis in bundles, such as a
+// screenshot of the team or a map of the stage.
+type Asset struct {
+	Name        string    `db:"name" json:"name"`
+	ContentHash string    `db:"content_hash" json:"content_hash"`
+	Size        uint32    `db:"size" json:"size"`
+	TimeCreate  time.Time `db:"time_create" json:"time_create"`
+}
+
+func ListAssets(axisID uint64) ([]Asset, error) {
+	assets := []Asset{}
+	err := database.DB.Select(&assets,
+		`SELECT name, content_hash, size, time_create FROM axis_assets WHERE axis_id = ? ORDER BY name`, axisID,
+	)
+	return assets, err
+}
+
+func GetAssetContent(axisID uint64, name string) ([]byte, error) {
+	var content []byte
+	err := database.DB.Get(&content, `SELECT content FROM axis_assets WHERE axis_id = ? AND name = ?`, axisID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetNotFound
+	}
+	return content, err
+}
+
+// getAssetContents returns every asset of an axis keyed by name, the way
+// bundles carry them.
+func getAssetContents(axisID uint64) (map[string][]byte, error) {
+	var rows []struct {
+		Name    string `db:"name"`
+		Content []byte `db:"content"`
+	}
+	err := database.DB.Select(&rows, `SELECT name, content FROM axis_assets WHERE axis_id = ?`, axisID)
+	if err != nil {
+		return nil, err
+	}
+	assets := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		assets[row.Name] = row.Content
+	}
+	return assets, nil
+}
+
+func insertAssets(tx *sqlx.Tx, axisID uint64, assets map[string][]byte) error {
+	for name, content := range assets {
+		_, err := tx.Exec(
+			`INSERT INTO axis_assets (axis_id, name, content, content_hash, size) VALUES (?, ?, ?, ?, ?)`,
+			axisID, name, content, ContentHash(content), len(content),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func handlerListAssets(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	assets, err := ListAssets(a.ID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, assets)
+}
+
+func handlerGetAsset(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	content, err := GetAssetContent(a.ID, chi.URLParam(r, "name"))
+	if errors.Is(err, ErrAssetNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	// Assets come from bundles of other instances, so they are never
+	// rendered in place.
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
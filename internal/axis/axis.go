@@ -0,0 +1,267 @@
+package axis
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
+var Difficulties = []string{"normal", "hard", "veryhard", "hardcore", "extreme", "insane", "torment"}
+
+var Servers = []string{"JP", "Global", "CN"}
+
+var ErrNotFound = errors.New("axis not found")
+
+type Axis struct {
+	ID          uint64  `db:"id" json:"id"`
+	OwnerID     uint64  `db:"owner_id" json:"owner_id"`
+	OwnerName   string  `db:"owner_name" json:"owner_name"`
+	Title       string  `db:"title" json:"title"`
+	StageID     string  `db:"stage_id" json:"stage_id"`
+	Difficulty  string  `db:"difficulty" json:"difficulty"`
+	Server      string  `db:"server" json:"server"`
+	Description *string `db:"description" json:"description"`
+	Language    string  `db:"language" json:"language"`
+	// Translation is the language of the title and description when a
+	// translation was served instead of the original.
+	Translation          string     `db:"-" json:"translation,omitempty"`
+	Visibility           string     `db:"visibility" json:"visibility"`
+	IsHidden             bool       `db:"is_hidden" json:"is_hidden"`
+	HeadRevisionID       *uint64    `db:"head_revision_id" json:"-"`
+	HeadRevision         *uint32    `db:"head_revision" json:"head_revision"`
+	ForkedFromID         *uint64    `db:"forked_from_axis_id" json:"forked_from_axis_id"`
+	ForkedFromRevisionID *uint64    `db:"forked_from_revision_id" json:"-"`
+	ForkedFromRevision   *uint32    `db:"forked_from_revision" json:"forked_from_revision"`
+	MergeBaseRevisionID  *uint64    `db:"merge_base_revision_id" json:"-"`
+	RatingScore          float64    `db:"rating_score" json:"rating_score"`
+	RatingAverage        *float64   `db:"rating_average" json:"rating_average"`
+	RatingCount          uint32     `db:"rating_count" json:"rating_count"`
+	DownloadCount        uint32     `db:"download_count" json:"download_count"`
+	FavoriteCount        uint32     `db:"favorite_count" json:"favorite_count"`
+	VerifiedClearCount   uint32     `db:"verified_clear_count" json:"verified_clear_count"`
+	IsDeleted            bool       `db:"is_deleted" json:"-"`
+	TimeDeleted          *time.Time `db:"time_deleted" json:"-"`
+	TimeCreate           time.Time  `db:"time_create" json:"time_create"`
+	TimeLastUpdate       time.Time  `db:"time_last_update" json:"time_last_update"`
+}
+
+// Fields holds the user editable columns of an axis.
+type Fields struct {
+	Title       string
+	StageID     string
+	Difficulty  string
+	Server      string
+	Description *string
+	Language    string
+	Visibility  string
+}
+
+type ListFilter struct {
+	OwnerID    uint64
+	StageID    string
+	Difficulty string
+	// ViewerID sees their own unlisted and private axes in addition to
+	// public ones when listing by OwnerID.
+	ViewerID uint64
+	Offset   int
+	Limit    int
+}
+
+const axisColumns = `a.id, a.owner_id, u.username AS owner_name, a.title, a.stage_id, a.difficulty, a.server,
+	a.description, a.language, a.visibility, a.is_hidden, a.head_revision_id, hr.revision_no AS head_revision,
+	a.forked_from_axis_id, a.forked_from_revision_id, fr.revision_no AS forked_from_revision, a.merge_base_revision_id,
+	a.rating_score, a.rating_sum / NULLIF(a.rating_count, 0) AS rating_average, a.rating_count,
+	a.download_count, a.favorite_count, a.verified_clear_count,
+	a.is_deleted, a.time_deleted, a.time_create, a.time_last_update`
+
+const axisFrom = ` FROM axes a JOIN users u ON u.id = a.owner_id LEFT JOIN axis_revisions hr ON hr.id = a.head_revision_id
+	LEFT JOIN axis_revisions fr ON fr.id = a.forked_from_revision_id `
+
+func IsValidDifficulty(d string) bool {
+	for _, v := range Difficulties {
+		if v == d {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidServer(server string) bool {
+	for _, v := range Servers {
+		if v == server {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityUnlisted || v == VisibilityPrivate
+}
+
+func Get(id uint64) (*Axis, error) {
+	var a Axis
+	err := database.DB.Get(&a, `SELECT `+axisColumns+axisFrom+`WHERE a.id = ? AND a.is_deleted = FALSE AND u.is_deleted = FALSE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func Create(ownerID uint64, f Fields) (*Axis, error) {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO axes (owner_id, title, stage_id, difficulty, server, description, language, visibility) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		ownerID, f.Title, f.StageID, f.Difficulty, f.Server, f.Description, f.Language, f.Visibility,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	// Start unrated axes at the prior instead of below every rated one.
+	if err := refreshScores(tx, uint64(id)); err != nil {
+		return nil, err
+	}
+	if err := recordChange(tx, uint64(id), ChangeCreated); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return Get(uint64(id))
+}
+
+func Update(id uint64, f Fields) (*Axis, error) {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE axes SET title = ?, stage_id = ?, difficulty = ?, server = ?, description = ?, language = ?, visibility = ?
+		WHERE id = ? AND is_deleted = FALSE`,
+		f.Title, f.StageID, f.Difficulty, f.Server, f.Description, f.Language, f.Visibility, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAffected(res); err != nil {
+		return nil, err
+	}
+	if err := recordChange(tx, id, ChangeUpdated); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return Get(id)
+}
+
+func Delete(id uint64) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE axes SET is_deleted = TRUE, time_deleted = NOW() WHERE id = ? AND is_deleted = FALSE`, id,
+	)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+	if err := recordChange(tx, id, ChangeDeleted); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func List(f ListFilter) ([]Axis, error) {
+	var where []string
+	var args []interface{}
+	where = append(where, "a.is_deleted = FALSE", "u.is_deleted = FALSE")
+	if f.OwnerID != 0 {
+		where = append(where, "a.owner_id = ?")
+		args = append(args, f.OwnerID)
+	}
+	if f.OwnerID == 0 || f.OwnerID != f.ViewerID {
+		where = append(where, "a.visibility = ?", "a.is_hidden = FALSE")
+		args = append(args, VisibilityPublic)
+	}
+	if f.StageID != "" {
+		where = append(where, "a.stage_id = ?")
+		args = append(args, f.StageID)
+	}
+	if f.Difficulty != "" {
+		where = append(where, "a.difficulty = ?")
+		args = append(args, f.Difficulty)
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	axes := []Axis{}
+	err := database.DB.Select(&axes,
+		`SELECT `+axisColumns+axisFrom+`WHERE `+strings.Join(where, " AND ")+` ORDER BY a.id DESC LIMIT ? OFFSET ?`,
+		args...,
+	)
+	return axes, err
+}
+
+// ExportUser lists every axis the user authored, for the personal data export.
+func ExportUser(userID uint64) (interface{}, error) {
+	return ListOwned(userID)
+}
+
+// Viewer is who an axis is shown to: a user, 0 for anonymous, and whether
+// the moderator override applies to them.
+type Viewer struct {
+	UserID    uint64
+	Moderator bool
+}
+
+// CanView reports whether v may see an axis. Private and moderator hidden
+// axes are limited to their owner and moderators.
+func (v Viewer) CanView(a *Axis) bool {
+	if a.Visibility != VisibilityPrivate && !a.IsHidden {
+		return true
+	}
+	return v.CanEdit(a)
+}
+
+func (v Viewer) CanEdit(a *Axis) bool {
+	return v.Moderator || (v.UserID != 0 && v.UserID == a.OwnerID)
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
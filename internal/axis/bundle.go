@@ -0,0 +1,227 @@
+package axis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pur1fying/GO_BAAS/internal/axisbundle"
+	"github.com/pur1fying/GO_BAAS/internal/axisfile"
+	"github.com/pur1fying/GO_BAAS/internal/catalog"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/upload"
+)
+
+// ImportResult reports what became of one axis of an imported bundle. A
+// bad axis does not stop the others from being imported.
+type ImportResult struct {
+	Index   int                       `json:"index"`
+	Title   string                    `json:"title"`
+	AxisID  uint64                    `json:"axis_id,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+	Details axisfile.ValidationErrors `json:"details,omitempty"`
+}
+
+// ListOwned returns every axis of the user, including private ones.
+func ListOwned(ownerID uint64) ([]Axis, error) {
+	axes := []Axis{}
+	err := database.DB.Select(&axes,
+		`SELECT `+axisColumns+axisFrom+`WHERE a.owner_id = ? AND a.is_deleted = FALSE ORDER BY a.id`, ownerID,
+	)
+	return axes, err
+}
+
+// ExportBundle writes the head revision and the assets of each axis into a
+// bundle and returns how many were written. Axes with nothing published are skipped.
+func ExportBundle(w io.Writer, exporter string, axes []Axis) (int, error) {
+	bw := axisbundle.NewWriter(w, exporter)
+	n := 0
+	for _, a := range axes {
+		if a.HeadRevisionID == nil {
+			continue
+		}
+		head, err := getRevisionByID(*a.HeadRevisionID)
+		if err != nil {
+			return n, err
+		}
+		content, err := GetRevisionContent(a.ID, head.RevisionNo)
+		if err != nil {
+			return n, err
+		}
+		assets, err := getAssetContents(a.ID)
+		if err != nil {
+			return n, err
+		}
+		err = bw.AddAxis(axisbundle.Entry{
+			Title:       a.Title,
+			StageID:     a.StageID,
+			Difficulty:  a.Difficulty,
+			Server:      a.Server,
+			Description: a.Description,
+			Language:    a.Language,
+			Visibility:  a.Visibility,
+			Author:      a.OwnerName,
+			Revision:    head.RevisionNo,
+			GameVersion: head.GameVersion,
+			Changelog:   head.Changelog,
+		}, content, assets)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, bw.Close()
+}
+
+// ImportBundle creates a new axis owned by ownerID for every entry of b,
+// each with the bundled file as its first published revision and the
+// bundled assets.
+func ImportBundle(ctx context.Context, b *axisbundle.Bundle, ownerID uint64) ([]ImportResult, error) {
+	results := make([]ImportResult, len(b.Manifest.Axes))
+	for i, e := range b.Manifest.Axes {
+		results[i] = ImportResult{Index: i, Title: e.Title}
+		data, err := b.File(e.File)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := scanAxisContent(ctx, data); err != nil {
+			if !upload.Rejected(err) {
+				return results, err
+			}
+			results[i].Error = err.Error()
+			continue
+		}
+		assets, err := readAssets(b, e)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		f, nr, msg, errs, err := prepareImport(e, data)
+		if err != nil {
+			return results, err
+		}
+		if msg != "" || len(errs) > 0 {
+			if msg == "" {
+				msg = errs[0].Error()
+			}
+			results[i].Error, results[i].Details = msg, errs
+			continue
+		}
+		nr.AuthorID = ownerID
+		id, err := importAxis(ownerID, f, nr, assets)
+		if err != nil {
+			return results, err
+		}
+		results[i].AxisID = id
+	}
+	return results, nil
+}
+
+// readAssets reads the assets of an entry keyed by name. Read already
+// checked the names.
+func readAssets(b *axisbundle.Bundle, e axisbundle.Entry) (map[string][]byte, error) {
+	assets := make(map[string][]byte, len(e.Assets))
+	for _, ref := range e.Assets {
+		data, err := b.File(ref)
+		if err != nil {
+			return nil, err
+		}
+		assets[path.Base(ref.Path)] = data
+	}
+	return assets, nil
+}
+
+// prepareImport runs an entry through the checks of the create axis and
+// create revision endpoints. Rejections come back as msg or errs, only
+// database failures as err.
+func prepareImport(e axisbundle.Entry, data []byte) (Fields, NewRevision, string, axisfile.ValidationErrors, error) {
+	f := Fields{
+		Title:       strings.TrimSpace(e.Title),
+		StageID:     strings.TrimSpace(e.StageID),
+		Difficulty:  e.Difficulty,
+		Server:      e.Server,
+		Description: e.Description,
+		Language:    e.Language,
+		Visibility:  e.Visibility,
+	}
+	if f.Visibility == "" {
+		f.Visibility = VisibilityPublic
+	}
+	if f.Server == "" {
+		f.Server = Servers[0]
+	}
+	changelog := "Imported from bundle"
+	if e.Author != "" {
+		changelog = fmt.Sprintf("Imported from %s revision %d", e.Author, e.Revision)
+	}
+	nr := NewRevision{GameVersion: strings.TrimSpace(e.GameVersion), Changelog: changelog, Publish: true}
+	if msg := validateFields(f); msg != "" {
+		return f, nr, msg, nil, nil
+	}
+	if msg := validateRevisionMeta(nr.Changelog, nr.GameVersion); msg != "" {
+		return f, nr, msg, nil, nil
+	}
+	ok, err := catalog.StageExists(f.StageID)
+	if err != nil {
+		return f, nr, "", nil, err
+	}
+	if !ok {
+		return f, nr, "unknown stage_id", nil, nil
+	}
+	file, errs := axisfile.Parse(data)
+	if len(errs) == 0 {
+		errs, err = checkStudents(file)
+		if err != nil {
+			return f, nr, "", nil, err
+		}
+	}
+	if len(errs) > 0 {
+		return f, nr, "", errs, nil
+	}
+	content, err := json.Marshal(file)
+	if err != nil {
+		return f, nr, "", nil, err
+	}
+	nr.FormatVersion, nr.Content = file.FormatVersion, content
+	return f, nr, "", nil, nil
+}
+
+func importAxis(ownerID uint64, f Fields, nr NewRevision, assets map[string][]byte) (uint64, error) {
+	if err := storeContent(&nr); err != nil {
+		return 0, err
+	}
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO axes (owner_id, title, stage_id, difficulty, server, description, language, visibility) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		ownerID, f.Title, f.StageID, f.Difficulty, f.Server, f.Description, f.Language, f.Visibility,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := refreshScores(tx, uint64(id)); err != nil {
+		return 0, err
+	}
+	if err := recordChange(tx, uint64(id), ChangeCreated); err != nil {
+		return 0, err
+	}
+	if _, _, err := insertRevision(tx, uint64(id), nr); err != nil {
+		return 0, err
+	}
+	if err := insertAssets(tx, uint64(id), assets); err != nil {
+		return 0, err
+	}
+	return uint64(id), tx.Commit()
+}
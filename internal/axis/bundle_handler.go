@@ -0,0 +1,134 @@
+package axis
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/axisbundle"
+	"github.com/pur1fying/GO_BAAS/internal/upload"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+// handlerExportBundle downloads the axes named by repeated id parameters,
+// or every axis of the caller when none is given.
+func handlerExportBundle(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	ids := r.URL.Query()["id"]
+	if len(ids) > axisbundle.MaxAxes {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "too many axes")
+		return
+	}
+	var axes []Axis
+	if len(ids) == 0 {
+		var err error
+		if axes, err = ListOwned(userID); err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+	}
+	for _, v := range ids {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid id")
+			return
+		}
+		a, err := Get(id)
+		if err != nil {
+			respondAxisError(w, err)
+			return
+		}
+		allowed, err := canViewRequest(r, a)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+		if !allowed {
+			respondAxisError(w, ErrNotFound)
+			return
+		}
+		axes = append(axes, *a)
+	}
+
+	u, err := user.GetByID(userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	// Buffered so a failure halfway can still be answered with an error.
+	var buf bytes.Buffer
+	if _, err := ExportBundle(&buf, u.Username, axes); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	name := "axes-" + time.Now().Format("20060102") + axisbundle.Extension
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// handlerImportBundle takes the raw bundle as the request body, or the
+// upload_id of a bundle sent as a resumable upload, and answers with one
+// result per axis of the manifest.
+func handlerImportBundle(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var f *upload.File
+	var ok bool
+	if v := r.URL.Query().Get("upload_id"); v != "" {
+		f, ok = openBundleUpload(w, r, v, userID)
+	} else {
+		f, ok = upload.ProcessRequest(w, r, upload.KindBundle)
+	}
+	if !ok {
+		return
+	}
+	defer f.Close()
+	b, err := axisbundle.Read(f, f.Size)
+	if err != nil {
+		api.ResponseWithError(w, http.StatusUnprocessableEntity, api.CodeValidationFailed, err.Error())
+		return
+	}
+	results, err := ImportBundle(r.Context(), b, userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	imported := 0
+	for _, each := range results {
+		if each.AxisID != 0 {
+			imported++
+		}
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"imported": imported,
+		"failed":   len(results) - imported,
+		"results":  results,
+	})
+}
+
+// openBundleUpload opens a bundle the caller uploaded earlier. It already
+// went through the pipeline when it was saved.
+func openBundleUpload(w http.ResponseWriter, r *http.Request, v string, userID uint64) (*upload.File, bool) {
+	u, err := upload.GetByPublicID(v)
+	if err == nil && (u.UserID != userID || u.Kind != upload.KindBundle) {
+		err = upload.ErrNotFound
+	}
+	if errors.Is(err, upload.ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, "bundle upload not found")
+		return nil, false
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	f, err := upload.Open(r.Context(), u)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	return f, true
+}
@@ -0,0 +1,226 @@
+package axis
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/audit"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+const (
+	ClearPending  = "pending"
+	ClearVerified = "verified"
+	ClearRejected = "rejected"
+)
+
+var (
+	ErrClearNotFound   = errors.New("clear report not found")
+	ErrClearDuplicate  = errors.New("you already reported a clear for this revision")
+	ErrRevisionIsDraft = errors.New("clears can only be reported for published revisions")
+)
+
+// Clear is a user's proof that a revision works: a screenshot or video of
+// the result plus what it scored.
+type Clear struct {
+	ID           uint64     `db:"id" json:"id"`
+	AxisID       uint64     `db:"axis_id" json:"axis_id"`
+	RevisionID   uint64     `db:"revision_id" json:"-"`
+	Revision     uint32     `db:"revision_no" json:"revision"`
+	UserID       uint64     `db:"user_id" json:"user_id"`
+	Username     string     `db:"username" json:"username"`
+	EvidenceURL  string     `db:"evidence_url" json:"evidence_url"`
+	Score        *uint64    `db:"score" json:"score"`
+	ClearTimeMS  *uint32    `db:"clear_time_ms" json:"clear_time_ms"`
+	Server       string     `db:"server" json:"server"`
+	Note         *string    `db:"note" json:"note"`
+	Status       string     `db:"status" json:"status"`
+	ReviewedBy   *uint64    `db:"reviewed_by" json:"reviewed_by"`
+	ReviewReason *string    `db:"review_reason" json:"review_reason"`
+	TimeReviewed *time.Time `db:"time_reviewed" json:"time_reviewed"`
+	TimeCreate   time.Time  `db:"time_create" json:"time_create"`
+}
+
+type NewClear struct {
+	EvidenceURL string
+	Score       *uint64
+	ClearTimeMS *uint32
+	Server      string
+	Note        *string
+}
+
+type ClearFilter struct {
+	RevisionID *uint64
+	Status     string
+	// ViewerID also sees their own rejected clears; moderators see all.
+	ViewerID  uint64
+	Moderator bool
+	Offset    int
+	Limit     int
+}
+
+const clearColumns = `c.id, c.axis_id, c.revision_id, r.revision_no, c.user_id, u.username, c.evidence_url,
+	c.score, c.clear_time_ms, c.server, c.note, c.status, c.reviewed_by, c.review_reason, c.time_reviewed, c.time_create`
+
+const clearFrom = ` FROM axis_clears c JOIN axis_revisions r ON r.id = c.revision_id JOIN users u ON u.id = c.user_id `
+
+func GetClear(axisID, id uint64) (*Clear, error) {
+	var c Clear
+	err := database.DB.Get(&c, `SELECT `+clearColumns+clearFrom+`WHERE c.axis_id = ? AND c.id = ?`, axisID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClearNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListClears returns verified clears first, then the newest.
+func ListClears(axisID uint64, f ClearFilter) ([]Clear, error) {
+	query := `SELECT ` + clearColumns + clearFrom + `WHERE c.axis_id = ? AND u.is_deleted = FALSE`
+	args := []interface{}{axisID}
+	if f.RevisionID != nil {
+		query += ` AND c.revision_id = ?`
+		args = append(args, *f.RevisionID)
+	}
+	if f.Status != "" {
+		query += ` AND c.status = ?`
+		args = append(args, f.Status)
+	}
+	if !f.Moderator {
+		query += ` AND (c.status <> ? OR c.user_id = ?)`
+		args = append(args, ClearRejected, f.ViewerID)
+	}
+	args = append(args, ClearVerified, f.Limit, f.Offset)
+	clears := []Clear{}
+	err := database.DB.Select(&clears, query+` ORDER BY c.status = ? DESC, c.id DESC LIMIT ? OFFSET ?`, args...)
+	return clears, err
+}
+
+func CreateClear(rev *Revision, userID uint64, nc NewClear) (*Clear, error) {
+	if !rev.IsPublished {
+		return nil, ErrRevisionIsDraft
+	}
+	res, err := database.DB.Exec(
+		`INSERT IGNORE INTO axis_clears (axis_id, revision_id, user_id, evidence_url, score, clear_time_ms, server, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		rev.AxisID, rev.ID, userID, nc.EvidenceURL, nc.Score, nc.ClearTimeMS, nc.Server, nc.Note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrClearDuplicate
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetClear(rev.AxisID, uint64(id))
+}
+
+func DeleteClear(c *Clear) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM axis_clears WHERE id = ?`, c.ID); err != nil {
+		return err
+	}
+	if err := refreshClearCount(tx, c.AxisID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReviewClear marks a clear verified or rejected on behalf of a moderator
+// and records the decision in the audit log.
+func ReviewClear(c *Clear, status string, moderatorID uint64, reason *string) (*Clear, error) {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(
+		`UPDATE axis_clears SET status = ?, reviewed_by = ?, review_reason = ?, time_reviewed = NOW() WHERE id = ?`,
+		status, moderatorID, reason, c.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := refreshClearCount(tx, c.AxisID); err != nil {
+		return nil, err
+	}
+	details, err := json.Marshal(map[string]interface{}{
+		"axis_id":     c.AxisID,
+		"revision":    c.Revision,
+		"user_id":     c.UserID,
+		"from_status": c.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	action := "clear.verify"
+	if status == ClearRejected {
+		action = "clear.reject"
+	}
+	err = audit.Record(tx, audit.Entry{
+		ActorID:    audit.Actor(moderatorID),
+		Action:     action,
+		TargetType: "axis_clear",
+		TargetID:   c.ID,
+		Reason:     reason,
+		Details:    types.NullJSONText{JSONText: details, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetClear(c.AxisID, c.ID)
+}
+
+// refreshClearCount recounts verified clears without touching
+// time_last_update, like the other counters.
+func refreshClearCount(tx sqlx.Execer, axisID uint64) error {
+	_, err := tx.Exec(
+		`UPDATE axes SET verified_clear_count = (
+			SELECT COUNT(*) FROM axis_clears WHERE axis_id = ? AND status = ?
+		), time_last_update = time_last_update WHERE id = ?`,
+		axisID, ClearVerified, axisID,
+	)
+	return err
+}
+
+// purgeUserClears drops the clears of a purged user, called from PurgeUser.
+func purgeUserClears(tx *sqlx.Tx, userID uint64) error {
+	var axes []uint64
+	if err := tx.Select(&axes, `SELECT DISTINCT axis_id FROM axis_clears WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM axis_clears WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, axisID := range axes {
+		if err := refreshClearCount(tx, axisID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportUserClears lists the clears the user reported, for the personal data export.
+func ExportUserClears(userID uint64) (interface{}, error) {
+	clears := []Clear{}
+	err := database.DB.Select(&clears, `SELECT `+clearColumns+clearFrom+`WHERE c.user_id = ? ORDER BY c.id`, userID)
+	return clears, err
+}
@@ -0,0 +1,214 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+type createClearRequest struct {
+	Revision    uint32  `json:"revision"`
+	EvidenceURL string  `json:"evidence_url"`
+	Score       *uint64 `json:"score"`
+	ClearTimeMS *uint32 `json:"clear_time_ms"`
+	Server      string  `json:"server"`
+	Note        *string `json:"note"`
+}
+
+type reviewClearRequest struct {
+	Reason *string `json:"reason"`
+}
+
+func handlerListClears(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	f := ClearFilter{Status: q.Get("status")}
+	switch f.Status {
+	case "", ClearPending, ClearVerified, ClearRejected:
+	default:
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown status")
+		return
+	}
+	if v := q.Get("revision"); v != "" {
+		revisionNo, ok := parseRevisionNo(w, v)
+		if !ok {
+			return
+		}
+		rev, ok := visibleRevision(w, r, a, revisionNo)
+		if !ok {
+			return
+		}
+		f.RevisionID = &rev.ID
+	}
+	if userID, ok := api.CurrentUserID(r); ok {
+		moderator, err := rbac.RequestHasPermission(r, rbac.PermAxisModerate)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+		f.ViewerID, f.Moderator = userID, moderator
+	}
+	page, pageSize, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	f.Offset, f.Limit = (page-1)*pageSize, pageSize
+	clears, err := ListClears(a.ID, f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     clears,
+	})
+}
+
+func handlerCreateClear(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	var req createClearRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Revision == 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "revision is required")
+		return
+	}
+	nc := NewClear{
+		EvidenceURL: strings.TrimSpace(req.EvidenceURL),
+		Score:       req.Score,
+		ClearTimeMS: req.ClearTimeMS,
+		Server:      req.Server,
+		Note:        req.Note,
+	}
+	if msg := validateClear(nc); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	rev, ok := visibleRevision(w, r, a, req.Revision)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	c, err := CreateClear(rev, userID, nc)
+	if err != nil {
+		respondClearError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, c)
+}
+
+func handlerDeleteClear(w http.ResponseWriter, r *http.Request) {
+	_, c, ok := loadClear(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if c.UserID != userID {
+		allowed, err := rbac.RequestHasPermission(r, rbac.PermAxisModerate)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+		if !allowed {
+			api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "not the reporter of this clear")
+			return
+		}
+	}
+	if err := DeleteClear(c); err != nil {
+		respondClearError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerVerifyClear(w http.ResponseWriter, r *http.Request) {
+	reviewClear(w, r, ClearVerified)
+}
+
+func handlerRejectClear(w http.ResponseWriter, r *http.Request) {
+	reviewClear(w, r, ClearRejected)
+}
+
+func reviewClear(w http.ResponseWriter, r *http.Request, status string) {
+	a, c, ok := loadClear(w, r)
+	if !ok {
+		return
+	}
+	var req reviewClearRequest
+	if err := api.DecodeOptionalJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Reason != nil && utf8.RuneCountInString(*req.Reason) > 500 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "reason must be at most 500 characters")
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	reviewed, err := ReviewClear(c, status, userID, req.Reason)
+	if err != nil {
+		respondClearError(w, err)
+		return
+	}
+	go publishClearReview(a, reviewed)
+	api.ResponseWithJson(w, http.StatusOK, reviewed)
+}
+
+func loadClear(w http.ResponseWriter, r *http.Request) (*Axis, *Clear, bool) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	id, ok := api.URLParamID(r, "clear")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid clear id")
+		return nil, nil, false
+	}
+	c, err := GetClear(a.ID, id)
+	if err != nil {
+		respondClearError(w, err)
+		return nil, nil, false
+	}
+	return a, c, true
+}
+
+func validateClear(nc NewClear) string {
+	if len(nc.EvidenceURL) > 500 {
+		return "evidence_url must be at most 500 characters"
+	}
+	u, err := url.Parse(nc.EvidenceURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "evidence_url must be an http or https link"
+	}
+	if !IsValidServer(nc.Server) {
+		return "unknown server"
+	}
+	if nc.Note != nil && utf8.RuneCountInString(*nc.Note) > 500 {
+		return "note must be at most 500 characters"
+	}
+	return ""
+}
+
+func respondClearError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrClearNotFound):
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+	case errors.Is(err, ErrClearDuplicate), errors.Is(err, ErrRevisionIsDraft):
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+	default:
+		respondAxisError(w, err)
+	}
+}
@@ -0,0 +1,339 @@
+package axis
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/audit"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"github.com/pur1fying/GO_BAAS/internal/mail"
+	"github.com/pur1fying/GO_BAAS/internal/markdown"
+	"github.com/pur1fying/GO_BAAS/internal/notify"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+type CommentStatus int8
+
+const (
+	CommentVisible CommentStatus = 1
+	CommentHidden  CommentStatus = 2
+	CommentDeleted CommentStatus = 3
+)
+
+func (s CommentStatus) String() string {
+	switch s {
+	case CommentHidden:
+		return "hidden"
+	case CommentDeleted:
+		return "deleted"
+	}
+	return "visible"
+}
+
+func (s CommentStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+const (
+	maxCommentDepth = 8
+	maxMentions     = 10
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentTooDeep   = errors.New("reply thread is too deep")
+	ErrCommentNotActive = errors.New("comment was deleted")
+)
+
+type Comment struct {
+	ID         uint64        `db:"id" json:"id"`
+	AxisID     uint64        `db:"axis_id" json:"axis_id"`
+	RevisionID *uint64       `db:"revision_id" json:"-"`
+	Revision   *uint32       `db:"revision_no" json:"revision"`
+	ParentID   *uint64       `db:"parent_id" json:"parent_id"`
+	RootID     *uint64       `db:"root_id" json:"-"`
+	Depth      int           `db:"depth" json:"depth"`
+	AuthorID   uint64        `db:"author_id" json:"author_id"`
+	AuthorName string        `db:"author_name" json:"author_name"`
+	Body       *string       `db:"body" json:"body"`
+	BodyHTML   *string       `db:"body_html" json:"body_html"`
+	Status     CommentStatus `db:"status" json:"status"`
+	EditCount  uint32        `db:"edit_count" json:"edit_count"`
+	TimeCreate time.Time     `db:"time_create" json:"time_create"`
+	TimeEdited *time.Time    `db:"time_edited" json:"time_edited"`
+	Replies    []*Comment    `db:"-" json:"replies"`
+}
+
+type CommentEdit struct {
+	ID         uint64    `db:"id" json:"id"`
+	Body       string    `db:"body" json:"body"`
+	TimeCreate time.Time `db:"time_create" json:"time_create"`
+}
+
+const commentColumns = `c.id, c.axis_id, c.revision_id, r.revision_no, c.parent_id, c.root_id, c.depth,
+	c.author_id, u.username AS author_name, c.body, c.body_html, c.status, c.edit_count, c.time_create, c.time_edited`
+
+const commentFrom = ` FROM axis_comments c JOIN users u ON u.id = c.author_id
+	LEFT JOIN axis_revisions r ON r.id = c.revision_id `
+
+func GetComment(axisID, id uint64) (*Comment, error) {
+	var c Comment
+	err := database.DB.Get(&c, `SELECT `+commentColumns+commentFrom+`WHERE c.axis_id = ? AND c.id = ?`, axisID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetCommentByID loads a comment without knowing its axis, for references
+// from outside an axis route such as reports.
+func GetCommentByID(id uint64) (*Comment, error) {
+	var c Comment
+	err := database.DB.Get(&c, `SELECT `+commentColumns+commentFrom+`WHERE c.id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCommentThreads returns a page of top level comments, newest first,
+// each with its whole reply tree. revisionID limits the threads to the ones
+// started on that revision.
+func ListCommentThreads(axisID uint64, revisionID *uint64, offset, limit int) ([]*Comment, error) {
+	query := `SELECT ` + commentColumns + commentFrom + `WHERE c.axis_id = ? AND c.root_id IS NULL`
+	args := []interface{}{axisID}
+	if revisionID != nil {
+		query += ` AND c.revision_id = ?`
+		args = append(args, *revisionID)
+	}
+	args = append(args, limit, offset)
+	roots := []*Comment{}
+	if err := database.DB.Select(&roots, query+` ORDER BY c.id DESC LIMIT ? OFFSET ?`, args...); err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return roots, nil
+	}
+
+	ids := make([]uint64, len(roots))
+	byID := make(map[uint64]*Comment, len(roots))
+	for i, c := range roots {
+		ids[i] = c.ID
+		byID[c.ID] = c
+		c.Replies = []*Comment{}
+	}
+	query, inArgs, err := sqlx.In(`SELECT `+commentColumns+commentFrom+`WHERE c.root_id IN (?) ORDER BY c.id`, ids)
+	if err != nil {
+		return nil, err
+	}
+	var replies []*Comment
+	if err := database.DB.Select(&replies, query, inArgs...); err != nil {
+		return nil, err
+	}
+	// Replies are ordered by id, so a parent is always seen before its children.
+	for _, c := range replies {
+		c.Replies = []*Comment{}
+		byID[c.ID] = c
+		if parent, ok := byID[*c.ParentID]; ok {
+			parent.Replies = append(parent.Replies, c)
+		}
+	}
+	return roots, nil
+}
+
+// CreateComment adds a top level comment, or a reply when parent is set.
+// Replies always belong to the revision of the thread they are in.
+func CreateComment(axisID uint64, revisionID *uint64, parent *Comment, authorID uint64, body string) (*Comment, error) {
+	var parentID, rootID *uint64
+	depth := 0
+	if parent != nil {
+		if parent.Status == CommentDeleted {
+			return nil, ErrCommentNotActive
+		}
+		if parent.Depth+1 > maxCommentDepth {
+			return nil, ErrCommentTooDeep
+		}
+		parentID, rootID, depth = &parent.ID, parent.RootID, parent.Depth+1
+		if rootID == nil {
+			rootID = &parent.ID
+		}
+		revisionID = parent.RevisionID
+	}
+	res, err := database.DB.Exec(
+		`INSERT INTO axis_comments (axis_id, revision_id, parent_id, root_id, depth, author_id, body, body_html)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		axisID, revisionID, parentID, rootID, depth, authorID, body, markdown.Render(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetComment(axisID, uint64(id))
+}
+
+// EditComment replaces the body, keeping the previous one in the history.
+func EditComment(c *Comment, body string) (*Comment, error) {
+	if c.Status == CommentDeleted {
+		return nil, ErrCommentNotActive
+	}
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO axis_comment_edits (comment_id, body) VALUES (?, ?)`, c.ID, *c.Body); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		`UPDATE axis_comments SET body = ?, body_html = ?, edit_count = edit_count + 1, time_edited = NOW() WHERE id = ?`,
+		body, markdown.Render(body), c.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetComment(c.AxisID, c.ID)
+}
+
+func ListCommentEdits(commentID uint64) ([]CommentEdit, error) {
+	edits := []CommentEdit{}
+	err := database.DB.Select(&edits,
+		`SELECT id, body, time_create FROM axis_comment_edits WHERE comment_id = ? ORDER BY id DESC`, commentID,
+	)
+	return edits, err
+}
+
+// SetCommentStatus hides, restores or deletes a comment. Changes made by
+// anyone but the author are moderation and go to the audit log, actorID 0
+// being the system.
+func SetCommentStatus(c *Comment, status CommentStatus, actorID uint64, reason *string) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := SetCommentStatusTx(tx, c, status, actorID, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetCommentStatusTx is SetCommentStatus within tx.
+func SetCommentStatusTx(tx *sqlx.Tx, c *Comment, status CommentStatus, actorID uint64, reason *string) error {
+	if _, err := tx.Exec(`UPDATE axis_comments SET status = ? WHERE id = ?`, status, c.ID); err != nil {
+		return err
+	}
+	if actorID != c.AuthorID {
+		details, err := json.Marshal(map[string]interface{}{
+			"axis_id":     c.AxisID,
+			"author_id":   c.AuthorID,
+			"from_status": c.Status.String(),
+		})
+		if err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.Entry{
+			ActorID:    audit.Actor(actorID),
+			Action:     "comment." + commentAction(status),
+			TargetType: "axis_comment",
+			TargetID:   c.ID,
+			Reason:     reason,
+			Details:    types.NullJSONText{JSONText: details, Valid: true},
+		})
+	}
+	return nil
+}
+
+// ExportUserComments lists the comments the user wrote, for the personal data export.
+func ExportUserComments(userID uint64) (interface{}, error) {
+	comments := []Comment{}
+	err := database.DB.Select(&comments,
+		`SELECT `+commentColumns+commentFrom+`WHERE c.author_id = ? AND c.status <> ? ORDER BY c.id`,
+		userID, CommentDeleted,
+	)
+	return comments, err
+}
+
+func commentAction(status CommentStatus) string {
+	switch status {
+	case CommentHidden:
+		return "hide"
+	case CommentDeleted:
+		return "delete"
+	}
+	return "unhide"
+}
+
+// notifyMentions notifies and mails the users mentioned in body, skipping names already
+// mentioned in previous. Mentions in a private or hidden axis only reach
+// people who can open it, which is the owner and moderators.
+func notifyMentions(a *Axis, c *Comment, body string, previous string) {
+	already := map[string]bool{}
+	for _, name := range markdown.Mentions(previous) {
+		already[strings.ToLower(name)] = true
+	}
+	sent := 0
+	for _, name := range markdown.Mentions(body) {
+		if sent >= maxMentions {
+			break
+		}
+		if already[strings.ToLower(name)] {
+			continue
+		}
+		u, err := user.GetByUsername(name)
+		if errors.Is(err, user.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			logger.BAASError("Comment Mention Error :", err.Error())
+			continue
+		}
+		if u.ID == c.AuthorID || u.EffectiveStatus(time.Now()) != user.StatusActive {
+			continue
+		}
+		if !(Viewer{UserID: u.ID}).CanView(a) {
+			moderator, err := rbac.HasPermission(u.ID, rbac.PermAxisModerate)
+			if err != nil {
+				logger.BAASError("Comment Mention Error :", err.Error())
+				continue
+			}
+			if !moderator {
+				continue
+			}
+		}
+		sent++
+		notify.Publish(notify.EventAxisMention, map[string]interface{}{
+			"axis_id":     a.ID,
+			"title":       a.Title,
+			"comment_id":  c.ID,
+			"author_name": c.AuthorName,
+		}, u.ID)
+		link := config.Config.Server.PublicURL + "/axes/" + strconv.FormatUint(a.ID, 10) +
+			"#comment-" + strconv.FormatUint(c.ID, 10)
+		mail.Enqueue(&mail.Message{
+			TO:      []mail.Address{{Email: u.Email, DisplayName: u.Username}},
+			Subject: c.AuthorName + " mentioned you on " + a.Title,
+			Text:    c.AuthorName + " mentioned you in a comment on \"" + a.Title + "\":\n\n" + body + "\n\n" + link,
+		})
+	}
+}
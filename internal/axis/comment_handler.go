@@ -0,0 +1,319 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+type createCommentRequest struct {
+	Body     string  `json:"body"`
+	ParentID *uint64 `json:"parent_id"`
+	Revision *uint32 `json:"revision"`
+}
+
+type editCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type moderateCommentRequest struct {
+	Reason *string `json:"reason"`
+}
+
+func handlerListComments(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	var revisionID *uint64
+	if v := r.URL.Query().Get("revision"); v != "" {
+		revisionNo, ok := parseRevisionNo(w, v)
+		if !ok {
+			return
+		}
+		rev, ok := visibleRevision(w, r, a, revisionNo)
+		if !ok {
+			return
+		}
+		revisionID = &rev.ID
+	}
+	page, pageSize, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	threads, err := ListCommentThreads(a.ID, revisionID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	viewer, err := newCommentViewer(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	for _, c := range threads {
+		viewer.redact(c)
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     threads,
+	})
+}
+
+func handlerCreateComment(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req createCommentRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	body, ok := validateCommentBody(w, req.Body)
+	if !ok {
+		return
+	}
+	var parent *Comment
+	var revisionID *uint64
+	switch {
+	case req.ParentID != nil:
+		var err error
+		if parent, err = GetComment(a.ID, *req.ParentID); err != nil {
+			respondCommentError(w, err)
+			return
+		}
+	case req.Revision != nil:
+		rev, ok := visibleRevision(w, r, a, *req.Revision)
+		if !ok {
+			return
+		}
+		revisionID = &rev.ID
+	}
+	c, err := CreateComment(a.ID, revisionID, parent, userID, body)
+	if err != nil {
+		respondCommentError(w, err)
+		return
+	}
+	go notifyMentions(a, c, body, "")
+	go publishComment(a, c, parent)
+	c.Replies = []*Comment{}
+	api.ResponseWithJson(w, http.StatusCreated, c)
+}
+
+func handlerEditComment(w http.ResponseWriter, r *http.Request) {
+	a, c, ok := loadComment(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if c.AuthorID != userID {
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "only the author can edit a comment")
+		return
+	}
+	var req editCommentRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	body, ok := validateCommentBody(w, req.Body)
+	if !ok {
+		return
+	}
+	previous := *c.Body
+	edited, err := EditComment(c, body)
+	if err != nil {
+		respondCommentError(w, err)
+		return
+	}
+	go notifyMentions(a, edited, body, previous)
+	edited.Replies = []*Comment{}
+	api.ResponseWithJson(w, http.StatusOK, edited)
+}
+
+func handlerListCommentEdits(w http.ResponseWriter, r *http.Request) {
+	_, c, ok := loadComment(w, r)
+	if !ok {
+		return
+	}
+	viewer, err := newCommentViewer(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	if !viewer.canRead(c) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, ErrCommentNotFound.Error())
+		return
+	}
+	edits, err := ListCommentEdits(c.ID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, edits)
+}
+
+// handlerDeleteComment lets authors delete their own comments and
+// moderators delete anyone's.
+func handlerDeleteComment(w http.ResponseWriter, r *http.Request) {
+	a, c, ok := loadComment(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if c.AuthorID != userID {
+		allowed, err := rbac.RequestHasPermission(r, rbac.PermAxisModerate)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+		if !allowed {
+			api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "not the author of this comment")
+			return
+		}
+	}
+	req, ok := decodeModerateRequest(w, r)
+	if !ok {
+		return
+	}
+	setCommentStatus(w, r, a, c, CommentDeleted, req.Reason)
+}
+
+func handlerHideComment(w http.ResponseWriter, r *http.Request) {
+	moderateComment(w, r, CommentHidden)
+}
+
+func handlerUnhideComment(w http.ResponseWriter, r *http.Request) {
+	moderateComment(w, r, CommentVisible)
+}
+
+func moderateComment(w http.ResponseWriter, r *http.Request, status CommentStatus) {
+	a, c, ok := loadComment(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeModerateRequest(w, r)
+	if !ok {
+		return
+	}
+	setCommentStatus(w, r, a, c, status, req.Reason)
+}
+
+// setCommentStatus applies a status change and, when a moderator hid or
+// deleted someone else's comment, tells its author.
+func setCommentStatus(w http.ResponseWriter, r *http.Request, a *Axis, c *Comment, status CommentStatus, reason *string) {
+	if c.Status == CommentDeleted {
+		respondCommentError(w, ErrCommentNotActive)
+		return
+	}
+	if c.Status == status {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if err := SetCommentStatus(c, status, userID, reason); err != nil {
+		respondCommentError(w, err)
+		return
+	}
+	if userID != c.AuthorID && status != CommentVisible {
+		go NotifyModeration(a, c, commentAction(status), reason, false)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeModerateRequest(w http.ResponseWriter, r *http.Request) (moderateCommentRequest, bool) {
+	var req moderateCommentRequest
+	if err := api.DecodeOptionalJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return req, false
+	}
+	if req.Reason != nil && utf8.RuneCountInString(*req.Reason) > 500 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "reason must be at most 500 characters")
+		return req, false
+	}
+	return req, true
+}
+
+func loadComment(w http.ResponseWriter, r *http.Request) (*Axis, *Comment, bool) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	id, ok := api.URLParamID(r, "comment")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid comment id")
+		return nil, nil, false
+	}
+	c, err := GetComment(a.ID, id)
+	if err != nil {
+		respondCommentError(w, err)
+		return nil, nil, false
+	}
+	return a, c, true
+}
+
+func validateCommentBody(w http.ResponseWriter, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if n := utf8.RuneCountInString(body); n == 0 || n > 5000 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "body must be 1-5000 characters")
+		return "", false
+	}
+	return body, true
+}
+
+// commentViewer decides whose hidden comments a request may read: the
+// author's own and, for moderators, everyone's. Deleted comments are never
+// shown, they remain in the thread as placeholders.
+type commentViewer struct {
+	userID    uint64
+	moderator bool
+}
+
+func newCommentViewer(r *http.Request) (*commentViewer, error) {
+	userID, ok := api.CurrentUserID(r)
+	if !ok {
+		return &commentViewer{}, nil
+	}
+	moderator, err := rbac.RequestHasPermission(r, rbac.PermAxisModerate)
+	if err != nil {
+		return nil, err
+	}
+	return &commentViewer{userID: userID, moderator: moderator}, nil
+}
+
+func (v *commentViewer) canRead(c *Comment) bool {
+	switch c.Status {
+	case CommentHidden:
+		return v.moderator || (v.userID != 0 && v.userID == c.AuthorID)
+	case CommentDeleted:
+		return false
+	}
+	return true
+}
+
+func (v *commentViewer) redact(c *Comment) {
+	if !v.canRead(c) {
+		c.Body, c.BodyHTML = nil, nil
+	}
+	for _, reply := range c.Replies {
+		v.redact(reply)
+	}
+}
+
+func respondCommentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrCommentNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrCommentTooDeep) || errors.Is(err, ErrCommentNotActive) {
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+		return
+	}
+	respondAxisError(w, err)
+}
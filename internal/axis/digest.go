@@ -0,0 +1,56 @@
+package axis
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+// DigestFilter selects the public axes changed since a point in time that
+// match any of the followed authors, stages or students.
+type DigestFilter struct {
+	Since     time.Time
+	AuthorIDs []uint64
+	StageIDs  []string
+	Students  []uint32
+	// ExcludeOwnerID leaves out the reader's own axes.
+	ExcludeOwnerID uint64
+	Limit          int
+}
+
+// ListForDigest returns matching axes, most recently updated first. An axis
+// is new when it was created after Since, otherwise it was updated.
+func ListForDigest(f DigestFilter) ([]Axis, error) {
+	var match []string
+	args := []interface{}{f.Since, VisibilityPublic, f.ExcludeOwnerID}
+	if len(f.AuthorIDs) > 0 {
+		match = append(match, "a.owner_id IN (?)")
+		args = append(args, f.AuthorIDs)
+	}
+	if len(f.StageIDs) > 0 {
+		match = append(match, "a.stage_id IN (?)")
+		args = append(args, f.StageIDs)
+	}
+	if len(f.Students) > 0 {
+		match = append(match, "EXISTS (SELECT 1 FROM axis_students s WHERE s.axis_id = a.id AND s.student_id IN (?))")
+		args = append(args, f.Students)
+	}
+	axes := []Axis{}
+	if len(match) == 0 {
+		return axes, nil
+	}
+	args = append(args, f.Limit)
+	query, inArgs, err := sqlx.In(
+		`SELECT `+axisColumns+axisFrom+`WHERE a.time_last_update > ? AND a.head_revision_id IS NOT NULL
+		AND a.visibility = ? AND a.is_hidden = FALSE AND a.is_deleted = FALSE AND u.is_deleted = FALSE AND a.owner_id <> ?
+		AND (`+strings.Join(match, " OR ")+`) ORDER BY a.time_last_update DESC LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = database.DB.Select(&axes, query, inArgs...)
+	return axes, err
+}
@@ -0,0 +1,74 @@
+package axis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+// CountDownload increments download_count unless the same viewer already
+// downloaded the axis within the dedup window. Signed in viewers are keyed
+// by user, anonymous ones by IP; only a hash of either is stored.
+func CountDownload(axisID uint64, viewerKey string) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The upsert locks the viewer's row, created as counted just outside the
+	// window when it is new, so concurrent downloads queue on it instead of
+	// deadlocking on gap locks. The update then counts only one of them.
+	window := config.Config.Axis.DownloadDedupHours
+	_, err = tx.Exec(
+		`INSERT INTO axis_downloads (axis_id, viewer_key, time_last_count)
+		VALUES (?, ?, DATE_SUB(NOW(), INTERVAL ? HOUR))
+		ON DUPLICATE KEY UPDATE viewer_key = viewer_key`,
+		axisID, viewerKey, window,
+	)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE axis_downloads SET time_last_count = NOW()
+		WHERE axis_id = ? AND viewer_key = ? AND time_last_count <= DATE_SUB(NOW(), INTERVAL ? HOUR)`,
+		axisID, viewerKey, window,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	_, err = tx.Exec(
+		`UPDATE axes SET download_count = download_count + 1, time_last_update = time_last_update WHERE id = ?`, axisID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func userViewerKey(userID uint64) string {
+	return viewerKey("user:" + strconv.FormatUint(userID, 10))
+}
+
+func ipViewerKey(ip string) string {
+	return viewerKey("ip:" + ip)
+}
+
+func viewerKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func pruneDownloads() error {
+	_, err := database.DB.Exec(
+		`DELETE FROM axis_downloads WHERE time_last_count < DATE_SUB(NOW(), INTERVAL ? HOUR)`,
+		config.Config.Axis.DownloadDedupHours,
+	)
+	return err
+}
@@ -0,0 +1,71 @@
+package axis
+
+import (
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+type Favorite struct {
+	AxisID     uint64    `db:"axis_id" json:"axis_id"`
+	TimeCreate time.Time `db:"time_create" json:"time_create"`
+}
+
+// SetFavorite adds or removes an axis from the favorites of a user. It is
+// idempotent, favorite_count only moves when the state changes.
+func SetFavorite(axisID, userID uint64, favorite bool) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, delta, change := `INSERT IGNORE INTO axis_favorites (user_id, axis_id) VALUES (?, ?)`, "+ 1", ChangeSubscribed
+	if !favorite {
+		query, delta, change = `DELETE FROM axis_favorites WHERE user_id = ? AND axis_id = ?`, "- 1", ChangeUnsubscribed
+	}
+	res, err := tx.Exec(query, userID, axisID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		_, err = tx.Exec(
+			`UPDATE axes SET favorite_count = favorite_count `+delta+`, time_last_update = time_last_update WHERE id = ?`,
+			axisID,
+		)
+		if err != nil {
+			return err
+		}
+		// Favorites are what the BAAS client keeps in sync.
+		if err := recordUserChange(tx, axisID, userID, change); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListFavorites returns the favorited axes the user can still see, most
+// recently favorited first.
+func ListFavorites(userID uint64, offset, limit int) ([]Axis, error) {
+	axes := []Axis{}
+	err := database.DB.Select(&axes,
+		`SELECT `+axisColumns+axisFrom+`JOIN axis_favorites fav ON fav.axis_id = a.id AND fav.user_id = ?
+		WHERE a.is_deleted = FALSE AND u.is_deleted = FALSE AND ((a.visibility <> ? AND a.is_hidden = FALSE) OR a.owner_id = ?)
+		ORDER BY fav.time_create DESC, a.id DESC LIMIT ? OFFSET ?`,
+		userID, VisibilityPrivate, userID, limit, offset,
+	)
+	return axes, err
+}
+
+// ExportUserFavorites lists the favorites of the user, for the personal data export.
+func ExportUserFavorites(userID uint64) (interface{}, error) {
+	favorites := []Favorite{}
+	err := database.DB.Select(&favorites,
+		`SELECT axis_id, time_create FROM axis_favorites WHERE user_id = ? ORDER BY time_create`, userID,
+	)
+	return favorites, err
+}
@@ -0,0 +1,169 @@
+package axis
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/axisfile"
+	"github.com/pur1fying/GO_BAAS/internal/catalog"
+	"github.com/pur1fying/GO_BAAS/internal/storage"
+	"github.com/pur1fying/GO_BAAS/internal/upload"
+)
+
+func handlerGetSchema(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid schema version")
+		return
+	}
+	schema, err := axisfile.Schema(version)
+	if err != nil {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, "unknown schema version")
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(schema)
+}
+
+func handlerValidateFile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := readAxisFile(w, r); !ok {
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]bool{"valid": true})
+}
+
+func handlerGetFile(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	content, err := GetContent(a.ID)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	if content == nil {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, "axis has no published revision")
+		return
+	}
+	// Revisions are immutable, so the content hash identifies the file.
+	if api.CheckETag(w, r, ContentHash(content)) {
+		return
+	}
+	countDownload(r, a)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+// handlerGetFileLink hands out an expiring download link to the head file in
+// blob storage. Revisions are stored by content when they are created, so
+// identical files of any axis share one copy.
+func handlerGetFileLink(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	if a.HeadRevisionID == nil {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, "axis has no published revision")
+		return
+	}
+	head, err := getRevisionByID(*a.HeadRevisionID)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	if head.StorageKey == nil {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, "axis file is not in storage yet")
+		return
+	}
+	expiry := storage.URLExpiry()
+	link, err := storage.Store.SignedURL(r.Context(), *head.StorageKey, expiry)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	countDownload(r, a)
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"url":         link,
+		"sha256":      head.ContentHash,
+		"time_expire": time.Now().Add(expiry),
+	})
+}
+
+func readAxisFile(w http.ResponseWriter, r *http.Request) (*axisfile.File, bool) {
+	f, ok := upload.ProcessRequest(w, r, upload.KindAxis)
+	if !ok {
+		return nil, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f.Reader())
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	return parseAxisFile(w, data)
+}
+
+// scanAxisContent runs axis content that arrived inside another body, as a
+// JSON field or a bundle entry, through the same pipeline as an uploaded
+// file. Rejections satisfy upload.Rejected.
+func scanAxisContent(ctx context.Context, data []byte) error {
+	f, err := upload.Process(ctx, upload.KindAxis, bytes.NewReader(data), upload.TypeJSON)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// parseAxisFile validates an uploaded axis file, answering 422 with every
+// schema violation or unknown student when it is rejected.
+func parseAxisFile(w http.ResponseWriter, data []byte) (*axisfile.File, bool) {
+	f, errs := axisfile.Parse(data)
+	if len(errs) == 0 {
+		var err error
+		errs, err = checkStudents(f)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return nil, false
+		}
+	}
+	if len(errs) > 0 {
+		api.ResponseWithErrorDetails(w, http.StatusUnprocessableEntity, api.CodeValidationFailed, errs[0].Error(), errs)
+		return nil, false
+	}
+	return f, true
+}
+
+// checkStudents reports team members missing from the catalog. Actions can
+// only name team members, so checking the team is enough.
+func checkStudents(f *axisfile.File) (axisfile.ValidationErrors, error) {
+	var ids []uint32
+	paths := map[uint32]string{}
+	groups := []struct {
+		name    string
+		members []axisfile.Member
+	}{{"strikers", f.Team.Strikers}, {"specials", f.Team.Specials}}
+	for _, g := range groups {
+		for i, m := range g.members {
+			ids = append(ids, m.StudentID)
+			paths[m.StudentID] = "team." + g.name + "[" + strconv.Itoa(i) + "].student_id"
+		}
+	}
+	unknown, err := catalog.UnknownStudents(ids)
+	if err != nil {
+		return nil, err
+	}
+	var errs axisfile.ValidationErrors
+	for _, id := range unknown {
+		errs = append(errs, axisfile.ValidationError{Path: paths[id], Message: "is not a known student"})
+	}
+	return errs, nil
+}
@@ -0,0 +1,79 @@
+package axis
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+var ErrNoPublishedRevision = errors.New("axis has no published revision")
+
+// Fork copies the head revision of origin into a new axis owned by ownerID
+// and remembers that revision as the base of later merge requests.
+func Fork(origin *Axis, ownerID uint64, f Fields) (*Axis, error) {
+	if origin.HeadRevisionID == nil {
+		return nil, ErrNoPublishedRevision
+	}
+	head, file, err := getRevisionFileByID(*origin.HeadRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := GetRevisionContent(origin.ID, head.RevisionNo)
+	if err != nil {
+		return nil, err
+	}
+	nr := NewRevision{
+		AuthorID:      ownerID,
+		FormatVersion: file.FormatVersion,
+		Content:       content,
+		Changelog:     fmt.Sprintf("Forked from axis #%d revision %d", origin.ID, head.RevisionNo),
+		GameVersion:   head.GameVersion,
+		Publish:       true,
+	}
+	if err := storeContent(&nr); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO axes (owner_id, title, stage_id, difficulty, server, description, language, visibility, forked_from_axis_id, forked_from_revision_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ownerID, f.Title, f.StageID, f.Difficulty, f.Server, f.Description, f.Language, f.Visibility, origin.ID, head.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := refreshScores(tx, uint64(id)); err != nil {
+		return nil, err
+	}
+	if err := recordChange(tx, uint64(id), ChangeCreated); err != nil {
+		return nil, err
+	}
+	if _, _, err := insertRevision(tx, uint64(id), nr); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return Get(uint64(id))
+}
+
+func ListForks(originID uint64) ([]Axis, error) {
+	axes := []Axis{}
+	err := database.DB.Select(&axes,
+		`SELECT `+axisColumns+axisFrom+`WHERE a.forked_from_axis_id = ? AND a.visibility = ? AND a.is_hidden = FALSE
+		AND a.is_deleted = FALSE AND u.is_deleted = FALSE ORDER BY a.id DESC`,
+		originID, VisibilityPublic,
+	)
+	return axes, err
+}
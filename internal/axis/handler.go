@@ -0,0 +1,350 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/catalog"
+	"github.com/pur1fying/GO_BAAS/internal/i18n"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type createRequest struct {
+	Title       string  `json:"title"`
+	StageID     string  `json:"stage_id"`
+	Difficulty  string  `json:"difficulty"`
+	Server      string  `json:"server"`
+	Description *string `json:"description"`
+	Language    string  `json:"language"`
+	Visibility  string  `json:"visibility"`
+}
+
+type updateRequest struct {
+	Title       *string `json:"title"`
+	StageID     *string `json:"stage_id"`
+	Difficulty  *string `json:"difficulty"`
+	Server      *string `json:"server"`
+	Description *string `json:"description"`
+	Language    *string `json:"language"`
+	Visibility  *string `json:"visibility"`
+}
+
+func handlerCreateAxis(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req createRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Visibility == "" {
+		req.Visibility = VisibilityPublic
+	}
+	if req.Server == "" {
+		req.Server = Servers[0]
+	}
+	// Without a language the title is taken to be written in the one the
+	// client asked responses in.
+	if req.Language == "" {
+		req.Language = api.Language(r)
+	}
+	f := Fields{
+		Title:       strings.TrimSpace(req.Title),
+		StageID:     strings.TrimSpace(req.StageID),
+		Difficulty:  req.Difficulty,
+		Server:      req.Server,
+		Description: req.Description,
+		Language:    req.Language,
+		Visibility:  req.Visibility,
+	}
+	if msg := validateFields(f); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	if !checkStage(w, f.StageID) {
+		return
+	}
+	a, err := Create(userID, f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, a)
+}
+
+func handlerGetAxis(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	axes := []Axis{*a}
+	localize(r, axes)
+	api.ResponseWithJson(w, http.StatusOK, axes[0])
+}
+
+func handlerUpdateAxis(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	var req updateRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	f := Fields{
+		Title:       a.Title,
+		StageID:     a.StageID,
+		Difficulty:  a.Difficulty,
+		Server:      a.Server,
+		Description: a.Description,
+		Language:    a.Language,
+		Visibility:  a.Visibility,
+	}
+	if req.Title != nil {
+		f.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.StageID != nil {
+		f.StageID = strings.TrimSpace(*req.StageID)
+	}
+	if req.Difficulty != nil {
+		f.Difficulty = *req.Difficulty
+	}
+	if req.Server != nil {
+		f.Server = *req.Server
+	}
+	if req.Description != nil {
+		f.Description = req.Description
+	}
+	if req.Language != nil {
+		f.Language = *req.Language
+	}
+	if req.Visibility != nil {
+		f.Visibility = *req.Visibility
+	}
+	if msg := validateFields(f); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	// Axes created before a stage was dropped from the catalog stay editable.
+	if f.StageID != a.StageID && !checkStage(w, f.StageID) {
+		return
+	}
+	updated, err := Update(a.ID, f)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, updated)
+}
+
+func handlerDeleteAxis(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if userID == a.OwnerID {
+		if err := Delete(a.ID); err != nil {
+			respondAxisError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := ModerateAxis(a, ModerationDelete, userID, nil); err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	go NotifyModeration(a, nil, ModerationDelete, nil, false)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerListAxes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	viewerID, _ := api.CurrentUserID(r)
+	f := ListFilter{
+		StageID:    q.Get("stage_id"),
+		Difficulty: q.Get("difficulty"),
+		ViewerID:   viewerID,
+		Limit:      defaultPageSize,
+	}
+	if owner := q.Get("owner_id"); owner != "" {
+		id, err := strconv.ParseUint(owner, 10, 64)
+		if err != nil {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid owner_id")
+			return
+		}
+		f.OwnerID = id
+	}
+	if f.Difficulty != "" && !IsValidDifficulty(f.Difficulty) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown difficulty")
+		return
+	}
+	page, pageSize, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	f.Limit = pageSize
+	f.Offset = (page - 1) * pageSize
+
+	axes, err := List(f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	localize(r, axes)
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     axes,
+	})
+}
+
+func parsePage(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	page, pageSize := 1, defaultPageSize
+	var err error
+	if v := r.URL.Query().Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page")
+			return 0, 0, false
+		}
+	}
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page_size")
+			return 0, 0, false
+		}
+	}
+	return page, pageSize, true
+}
+
+func validateFields(f Fields) string {
+	if n := utf8.RuneCountInString(f.Title); n == 0 || n > 100 {
+		return "title must be 1-100 characters"
+	}
+	if n := utf8.RuneCountInString(f.StageID); n == 0 || n > 32 {
+		return "stage_id must be 1-32 characters"
+	}
+	if !IsValidDifficulty(f.Difficulty) {
+		return "unknown difficulty"
+	}
+	if !IsValidServer(f.Server) {
+		return "unknown server"
+	}
+	if f.Description != nil && utf8.RuneCountInString(*f.Description) > 5000 {
+		return "description must be at most 5000 characters"
+	}
+	// Axes from before languages were recorded keep an empty one.
+	if f.Language != "" && !i18n.IsSupported(f.Language) {
+		return "language must be one of " + strings.Join(i18n.Supported, ", ")
+	}
+	if !IsValidVisibility(f.Visibility) {
+		return "unknown visibility"
+	}
+	return ""
+}
+
+func checkStage(w http.ResponseWriter, stageID string) bool {
+	ok, err := catalog.StageExists(stageID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return false
+	}
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown stage_id")
+		return false
+	}
+	return true
+}
+
+// loadVisibleAxis hides private and moderator hidden axes from everyone but
+// their owner and moderators; unlisted axes are reachable by id.
+func loadVisibleAxis(w http.ResponseWriter, r *http.Request) (*Axis, bool) {
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid axis id")
+		return nil, false
+	}
+	a, err := Get(id)
+	if err != nil {
+		respondAxisError(w, err)
+		return nil, false
+	}
+	allowed, err := canViewRequest(r, a)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	if !allowed {
+		respondAxisError(w, ErrNotFound)
+		return nil, false
+	}
+	return a, true
+}
+
+func loadEditableAxis(w http.ResponseWriter, r *http.Request) (*Axis, bool) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return nil, false
+	}
+	allowed, err := isOwnerOrModerator(r, a)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	if !allowed {
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "not the owner of this axis")
+		return nil, false
+	}
+	return a, true
+}
+
+// ViewerOf returns the caller of r as a Viewer. The moderator override is
+// granted to signed in sessions only; an access token acts for the owner
+// of its axes and nothing more.
+func ViewerOf(r *http.Request) (Viewer, error) {
+	userID, _ := api.CurrentUserID(r)
+	moderator, err := rbac.RequestHasPermission(r, rbac.PermAxisModerate)
+	return Viewer{UserID: userID, Moderator: moderator}, err
+}
+
+func isOwnerOrModerator(r *http.Request, a *Axis) (bool, error) {
+	if userID, _ := api.CurrentUserID(r); userID != 0 && userID == a.OwnerID {
+		return true, nil
+	}
+	v, err := ViewerOf(r)
+	if err != nil {
+		return false, err
+	}
+	return v.CanEdit(a), nil
+}
+
+// canViewRequest is Viewer.CanView for the caller of r, without looking
+// up their permissions when the axis is public.
+func canViewRequest(r *http.Request, a *Axis) (bool, error) {
+	if a.Visibility != VisibilityPrivate && !a.IsHidden {
+		return true, nil
+	}
+	return isOwnerOrModerator(r, a)
+}
+
+func respondAxisError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionNotFound) || errors.Is(err, ErrMergeRequestNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrUnchanged) || errors.Is(err, ErrMergeRequestNotOpen) || errors.Is(err, ErrNoPublishedRevision) {
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+		return
+	}
+	api.ResponseWithInternalError(w, err)
+}
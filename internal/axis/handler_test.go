@@ -0,0 +1,56 @@
+package axis
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func TestViewerCanView(t *testing.T) {
+	private := &Axis{OwnerID: 1, Visibility: VisibilityPrivate}
+	hidden := &Axis{OwnerID: 1, Visibility: VisibilityPublic, IsHidden: true}
+	public := &Axis{OwnerID: 1, Visibility: VisibilityPublic}
+	tests := []struct {
+		name   string
+		viewer Viewer
+		axis   *Axis
+		want   bool
+	}{
+		{"anonymous public", Viewer{}, public, true},
+		{"anonymous private", Viewer{}, private, false},
+		{"owner private", Viewer{UserID: 1}, private, true},
+		{"other private", Viewer{UserID: 2}, private, false},
+		{"other hidden", Viewer{UserID: 2}, hidden, false},
+		{"moderator private", Viewer{UserID: 2, Moderator: true}, private, true},
+		{"moderator hidden", Viewer{UserID: 2, Moderator: true}, hidden, true},
+	}
+	for _, tt := range tests {
+		if got := tt.viewer.CanView(tt.axis); got != tt.want {
+			t.Errorf("%s: CanView = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// A token request must be decided without looking up roles at all: the
+// database is not set up here, so a lookup would panic.
+func TestTokenRequestGetsNoModeratorOverride(t *testing.T) {
+	r := httptest.NewRequest("GET", "/axes/1", nil)
+	ctx := api.WithUserID(r.Context(), 2)
+	r = r.WithContext(api.WithTokenScopes(ctx, []string{api.ScopeAxisRead, api.ScopeAxisWrite}))
+
+	v, err := ViewerOf(r)
+	if err != nil || v.Moderator {
+		t.Fatalf("ViewerOf = %+v, %v, want no moderator override", v, err)
+	}
+	a := &Axis{ID: 1, OwnerID: 1, Visibility: VisibilityPrivate}
+	if ok, err := canViewRequest(r, a); ok || err != nil {
+		t.Errorf("canViewRequest = %v, %v, want false", ok, err)
+	}
+	if ok, err := isOwnerOrModerator(r, &Axis{ID: 1, OwnerID: 1, Visibility: VisibilityPublic}); ok || err != nil {
+		t.Errorf("isOwnerOrModerator = %v, %v, want false", ok, err)
+	}
+	if ok, err := isOwnerOrModerator(r, &Axis{ID: 2, OwnerID: 2, Visibility: VisibilityPrivate}); !ok || err != nil {
+		t.Errorf("isOwnerOrModerator for the token owner = %v, %v, want true", ok, err)
+	}
+}
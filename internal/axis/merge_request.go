@@ -0,0 +1,315 @@
+package axis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/axisfile"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+const (
+	MergeRequestOpen     = "open"
+	MergeRequestMerged   = "merged"
+	MergeRequestRejected = "rejected"
+	MergeRequestClosed   = "closed"
+)
+
+var (
+	ErrMergeRequestNotFound = errors.New("merge request not found")
+	ErrMergeRequestNotOpen  = errors.New("merge request is not open")
+	ErrMergeConflict        = errors.New("merge request has conflicts")
+)
+
+type MergeRequest struct {
+	ID             uint64     `db:"id" json:"id"`
+	TargetAxisID   uint64     `db:"target_axis_id" json:"target_axis_id"`
+	SourceAxisID   uint64     `db:"source_axis_id" json:"source_axis_id"`
+	BaseRevisionID uint64     `db:"base_revision_id" json:"-"`
+	BaseRevision   uint32     `db:"base_revision" json:"base_revision"`
+	AuthorID       uint64     `db:"author_id" json:"author_id"`
+	AuthorName     string     `db:"author_name" json:"author_name"`
+	Title          string     `db:"title" json:"title"`
+	Description    *string    `db:"description" json:"description"`
+	Status         string     `db:"status" json:"status"`
+	ResolvedBy     *uint64    `db:"resolved_by" json:"resolved_by"`
+	ResolveReason  *string    `db:"resolve_reason" json:"resolve_reason"`
+	MergedRevision *uint32    `db:"merged_revision" json:"merged_revision"`
+	TimeCreate     time.Time  `db:"time_create" json:"time_create"`
+	TimeLastUpdate time.Time  `db:"time_last_update" json:"time_last_update"`
+	TimeResolved   *time.Time `db:"time_resolved" json:"time_resolved"`
+}
+
+type MergeRequestComment struct {
+	ID         uint64    `db:"id" json:"id"`
+	AuthorID   uint64    `db:"author_id" json:"author_id"`
+	AuthorName string    `db:"author_name" json:"author_name"`
+	Body       string    `db:"body" json:"body"`
+	TimeCreate time.Time `db:"time_create" json:"time_create"`
+}
+
+// Mergeability is the result of a dry-run three-way merge.
+type Mergeability struct {
+	Mergeable   bool                `json:"mergeable"`
+	FastForward bool                `json:"fast_forward"`
+	Conflicts   []axisfile.Conflict `json:"conflicts"`
+	Diff        *axisfile.FileDiff  `json:"diff,omitempty"`
+	merged      *axisfile.File
+	source      *Revision
+}
+
+const mergeRequestColumns = `m.id, m.target_axis_id, m.source_axis_id, m.base_revision_id, br.revision_no AS base_revision,
+	m.author_id, u.username AS author_name, m.title, m.description, m.status, m.resolved_by, m.resolve_reason,
+	mr.revision_no AS merged_revision, m.time_create, m.time_last_update, m.time_resolved`
+
+const mergeRequestFrom = ` FROM axis_merge_requests m
+	JOIN users u ON u.id = m.author_id
+	JOIN axis_revisions br ON br.id = m.base_revision_id
+	LEFT JOIN axis_revisions mr ON mr.id = m.merged_revision_id `
+
+// mergeBase is the common ancestor of a fork and its source: the fork
+// revision last merged by a three-way merge, or else the source revision it
+// was forked from or last fast-forwarded to.
+func (a *Axis) mergeBase() uint64 {
+	if a.MergeBaseRevisionID != nil {
+		return *a.MergeBaseRevisionID
+	}
+	return *a.ForkedFromRevisionID
+}
+
+func CreateMergeRequest(target *Axis, source *Axis, authorID uint64, title string, description *string) (*MergeRequest, error) {
+	res, err := database.DB.Exec(
+		`INSERT INTO axis_merge_requests (target_axis_id, source_axis_id, base_revision_id, author_id, title, description)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		target.ID, source.ID, source.mergeBase(), authorID, title, description,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetMergeRequest(target.ID, uint64(id))
+}
+
+func GetMergeRequest(targetAxisID uint64, id uint64) (*MergeRequest, error) {
+	var m MergeRequest
+	err := database.DB.Get(&m,
+		`SELECT `+mergeRequestColumns+mergeRequestFrom+`WHERE m.id = ? AND m.target_axis_id = ?`, id, targetAxisID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMergeRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func ListMergeRequests(targetAxisID uint64, status string) ([]MergeRequest, error) {
+	query := `SELECT ` + mergeRequestColumns + mergeRequestFrom + `WHERE m.target_axis_id = ?`
+	args := []interface{}{targetAxisID}
+	if status != "" {
+		query += ` AND m.status = ?`
+		args = append(args, status)
+	}
+	list := []MergeRequest{}
+	err := database.DB.Select(&list, query+` ORDER BY m.id DESC`, args...)
+	return list, err
+}
+
+func HasOpenMergeRequest(sourceAxisID uint64) (bool, error) {
+	var n int
+	err := database.DB.Get(&n,
+		`SELECT COUNT(*) FROM axis_merge_requests WHERE source_axis_id = ? AND status = ?`, sourceAxisID, MergeRequestOpen,
+	)
+	return n > 0, err
+}
+
+func AddMergeRequestComment(mergeRequestID uint64, authorID uint64, body string) (*MergeRequestComment, error) {
+	res, err := database.DB.Exec(
+		`INSERT INTO axis_merge_request_comments (merge_request_id, author_id, body) VALUES (?, ?, ?)`,
+		mergeRequestID, authorID, body,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	var c MergeRequestComment
+	err = database.DB.Get(&c,
+		`SELECT c.id, c.author_id, u.username AS author_name, c.body, c.time_create
+		FROM axis_merge_request_comments c JOIN users u ON u.id = c.author_id WHERE c.id = ?`, id,
+	)
+	return &c, err
+}
+
+func ListMergeRequestComments(mergeRequestID uint64) ([]MergeRequestComment, error) {
+	comments := []MergeRequestComment{}
+	err := database.DB.Select(&comments,
+		`SELECT c.id, c.author_id, u.username AS author_name, c.body, c.time_create
+		FROM axis_merge_request_comments c JOIN users u ON u.id = c.author_id
+		WHERE c.merge_request_id = ? ORDER BY c.id`, mergeRequestID,
+	)
+	return comments, err
+}
+
+// CheckMergeability merges the source head into the target head against
+// the recorded base. The target having moved on since the base is what
+// makes conflicts possible; otherwise the merge is a fast-forward. After a
+// three-way merge the base is a fork revision, so an unchanged target is
+// recognized by its content.
+func CheckMergeability(m *MergeRequest) (*Mergeability, error) {
+	target, err := Get(m.TargetAxisID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := Get(m.SourceAxisID)
+	if err != nil {
+		return nil, err
+	}
+	if target.HeadRevisionID == nil || source.HeadRevisionID == nil {
+		return nil, ErrNoPublishedRevision
+	}
+
+	baseRev, base, err := getRevisionFileByID(m.BaseRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	targetHead, ours, err := getRevisionFileByID(*target.HeadRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	sourceHead, theirs, err := getRevisionFileByID(*source.HeadRevisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Mergeability{
+		FastForward: targetHead.ID == baseRev.ID || targetHead.ContentHash == baseRev.ContentHash,
+		source:      sourceHead,
+	}
+	if result.FastForward {
+		result.merged = theirs
+	} else {
+		result.merged, result.Conflicts = axisfile.Merge(base, ours, theirs)
+	}
+	result.Mergeable = len(result.Conflicts) == 0
+	if result.Conflicts == nil {
+		result.Conflicts = []axisfile.Conflict{}
+	}
+	if result.Mergeable {
+		result.Diff = axisfile.Diff(ours, result.merged)
+	}
+	return result, nil
+}
+
+// AcceptMergeRequest applies the merge as a new published revision of the
+// target axis.
+func AcceptMergeRequest(m *MergeRequest, resolverID uint64) (*MergeRequest, *Mergeability, error) {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.Get(&status, `SELECT status FROM axis_merge_requests WHERE id = ? FOR UPDATE`, m.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status != MergeRequestOpen {
+		return nil, nil, ErrMergeRequestNotOpen
+	}
+	var locked uint64
+	if err := tx.Get(&locked, `SELECT id FROM axes WHERE id = ? FOR UPDATE`, m.TargetAxisID); err != nil {
+		return nil, nil, err
+	}
+
+	check, err := CheckMergeability(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !check.Mergeable {
+		return nil, check, ErrMergeConflict
+	}
+	content, err := json.Marshal(check.merged)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, revisionID, err := insertRevision(tx, m.TargetAxisID, NewRevision{
+		AuthorID:      m.AuthorID,
+		FormatVersion: check.merged.FormatVersion,
+		Content:       content,
+		Changelog:     fmt.Sprintf("Merge request #%d: %s", m.ID, m.Title),
+		GameVersion:   check.source.GameVersion,
+		Publish:       true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = tx.Exec(
+		`UPDATE axis_merge_requests SET status = ?, resolved_by = ?, merged_revision_id = ?, time_resolved = NOW() WHERE id = ?`,
+		MergeRequestMerged, resolverID, revisionID, m.ID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	// After a fast-forward the fork matches the new target head exactly,
+	// which makes it the right base for the next merge request. After a
+	// three-way merge only the merged fork revision is an ancestor of both.
+	if check.FastForward {
+		_, err = tx.Exec(
+			`UPDATE axes SET forked_from_revision_id = ?, merge_base_revision_id = NULL, time_last_update = time_last_update WHERE id = ?`,
+			revisionID, m.SourceAxisID,
+		)
+	} else {
+		_, err = tx.Exec(
+			`UPDATE axes SET merge_base_revision_id = ?, time_last_update = time_last_update WHERE id = ?`,
+			check.source.ID, m.SourceAxisID,
+		)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	// The merged content is only known under the locks above, so it is
+	// stored once they are released.
+	if err := storeRevisionContent(context.Background(), revisionID, content); err != nil {
+		logger.BAASError("Axis Revision Storage Error :", err.Error())
+	}
+	merged, err := GetMergeRequest(m.TargetAxisID, m.ID)
+	return merged, check, err
+}
+
+// ResolveMergeRequest rejects or closes an open merge request without
+// merging it.
+func ResolveMergeRequest(m *MergeRequest, status string, resolverID uint64, reason *string) (*MergeRequest, error) {
+	res, err := database.DB.Exec(
+		`UPDATE axis_merge_requests SET status = ?, resolved_by = ?, resolve_reason = ?, time_resolved = NOW()
+		WHERE id = ? AND status = ?`,
+		status, resolverID, reason, m.ID, MergeRequestOpen,
+	)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrMergeRequestNotOpen
+	}
+	return GetMergeRequest(m.TargetAxisID, m.ID)
+}
@@ -0,0 +1,292 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+type forkRequest struct {
+	Title      *string `json:"title"`
+	Visibility *string `json:"visibility"`
+}
+
+type createMergeRequestRequest struct {
+	SourceAxisID uint64  `json:"source_axis_id"`
+	Title        string  `json:"title"`
+	Description  *string `json:"description"`
+}
+
+type resolveRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+func handlerFork(w http.ResponseWriter, r *http.Request) {
+	origin, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req forkRequest
+	if err := api.DecodeOptionalJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	f := Fields{
+		Title:       origin.Title,
+		StageID:     origin.StageID,
+		Difficulty:  origin.Difficulty,
+		Server:      origin.Server,
+		Description: origin.Description,
+		Language:    origin.Language,
+		Visibility:  VisibilityPublic,
+	}
+	if req.Title != nil {
+		f.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Visibility != nil {
+		f.Visibility = *req.Visibility
+	}
+	if msg := validateFields(f); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	fork, err := Fork(origin, userID, f)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, fork)
+}
+
+func handlerListForks(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	forks, err := ListForks(a.ID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	localize(r, forks)
+	api.ResponseWithJson(w, http.StatusOK, forks)
+}
+
+func handlerCreateMergeRequest(w http.ResponseWriter, r *http.Request) {
+	target, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req createMergeRequestRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if n := utf8.RuneCountInString(req.Title); n == 0 || n > 100 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "title must be 1-100 characters")
+		return
+	}
+	if req.Description != nil && utf8.RuneCountInString(*req.Description) > 5000 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "description must be at most 5000 characters")
+		return
+	}
+
+	source, err := Get(req.SourceAxisID)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	if source.OwnerID != userID {
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "not the owner of the source axis")
+		return
+	}
+	if source.ForkedFromID == nil || *source.ForkedFromID != target.ID || source.ForkedFromRevisionID == nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "source axis is not a fork of this axis")
+		return
+	}
+	open, err := HasOpenMergeRequest(source.ID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	if open {
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, "source axis already has an open merge request")
+		return
+	}
+
+	m, err := CreateMergeRequest(target, source, userID, req.Title, req.Description)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	go publishMergeRequest(m, "opened", userID)
+	api.ResponseWithJson(w, http.StatusCreated, m)
+}
+
+func handlerListMergeRequests(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", MergeRequestOpen, MergeRequestMerged, MergeRequestRejected, MergeRequestClosed:
+	default:
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown status")
+		return
+	}
+	list, err := ListMergeRequests(a.ID, status)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, list)
+}
+
+func handlerGetMergeRequest(w http.ResponseWriter, r *http.Request) {
+	m, ok := loadMergeRequest(w, r, loadVisibleAxis)
+	if !ok {
+		return
+	}
+	resp := map[string]interface{}{"merge_request": m}
+	if m.Status == MergeRequestOpen {
+		check, err := CheckMergeability(m)
+		switch {
+		case err == nil:
+			resp["mergeability"] = check
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrNoPublishedRevision):
+			// The source axis is gone or empty; nothing to report.
+		default:
+			respondAxisError(w, err)
+			return
+		}
+	}
+	api.ResponseWithJson(w, http.StatusOK, resp)
+}
+
+func handlerAcceptMergeRequest(w http.ResponseWriter, r *http.Request) {
+	m, ok := loadMergeRequest(w, r, loadEditableAxis)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	merged, check, err := AcceptMergeRequest(m, userID)
+	if errors.Is(err, ErrMergeConflict) {
+		api.ResponseWithErrorDetails(w, http.StatusConflict, api.CodeConflict, err.Error(), check.Conflicts)
+		return
+	}
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	go publishMergeRequest(merged, MergeRequestMerged, userID)
+	api.ResponseWithJson(w, http.StatusOK, merged)
+}
+
+func handlerRejectMergeRequest(w http.ResponseWriter, r *http.Request) {
+	m, ok := loadMergeRequest(w, r, loadEditableAxis)
+	if !ok {
+		return
+	}
+	resolveMergeRequest(w, r, m, MergeRequestRejected)
+}
+
+func handlerCloseMergeRequest(w http.ResponseWriter, r *http.Request) {
+	m, ok := loadMergeRequest(w, r, loadVisibleAxis)
+	if !ok {
+		return
+	}
+	if userID, _ := api.CurrentUserID(r); userID != m.AuthorID {
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "only the author can close a merge request")
+		return
+	}
+	resolveMergeRequest(w, r, m, MergeRequestClosed)
+}
+
+func resolveMergeRequest(w http.ResponseWriter, r *http.Request, m *MergeRequest, status string) {
+	userID, _ := api.CurrentUserID(r)
+	var req resolveRequest
+	if err := api.DecodeOptionalJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Reason != nil && utf8.RuneCountInString(*req.Reason) > 500 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "reason must be at most 500 characters")
+		return
+	}
+	resolved, err := ResolveMergeRequest(m, status, userID, req.Reason)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	go publishMergeRequest(resolved, status, userID)
+	api.ResponseWithJson(w, http.StatusOK, resolved)
+}
+
+func handlerListMergeRequestComments(w http.ResponseWriter, r *http.Request) {
+	m, ok := loadMergeRequest(w, r, loadVisibleAxis)
+	if !ok {
+		return
+	}
+	comments, err := ListMergeRequestComments(m.ID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, comments)
+}
+
+func handlerAddMergeRequestComment(w http.ResponseWriter, r *http.Request) {
+	m, ok := loadMergeRequest(w, r, loadVisibleAxis)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req commentRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if n := utf8.RuneCountInString(req.Body); n == 0 || n > 5000 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "body must be 1-5000 characters")
+		return
+	}
+	c, err := AddMergeRequestComment(m.ID, userID, req.Body)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	go publishMergeRequest(m, "commented", userID)
+	api.ResponseWithJson(w, http.StatusCreated, c)
+}
+
+// loadMergeRequest resolves {mr} after loading the target axis with load,
+// which decides who may act on the merge request.
+func loadMergeRequest(w http.ResponseWriter, r *http.Request, load func(http.ResponseWriter, *http.Request) (*Axis, bool)) (*MergeRequest, bool) {
+	target, ok := load(w, r)
+	if !ok {
+		return nil, false
+	}
+	id, ok := api.URLParamID(r, "mr")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid merge request id")
+		return nil, false
+	}
+	m, err := GetMergeRequest(target.ID, id)
+	if err != nil {
+		respondAxisError(w, err)
+		return nil, false
+	}
+	return m, true
+}
@@ -0,0 +1,133 @@
+package axis
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/audit"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"github.com/pur1fying/GO_BAAS/internal/mail"
+	"github.com/pur1fying/GO_BAAS/internal/notify"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+const (
+	ModerationHide   = "hide"
+	ModerationUnhide = "unhide"
+	ModerationDelete = "delete"
+)
+
+// ModerateAxis hides, restores or deletes an axis on behalf of a moderator,
+// or of the system when actorID is 0, and records it in the audit log.
+func ModerateAxis(a *Axis, action string, actorID uint64, reason *string) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := ModerateAxisTx(tx, a, action, actorID, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ModerateAxisTx is ModerateAxis within tx, for callers that record the
+// decision in the same transaction.
+func ModerateAxisTx(tx *sqlx.Tx, a *Axis, action string, actorID uint64, reason *string) error {
+	var query string
+	change := ChangeUpdated
+	switch action {
+	case ModerationHide:
+		query = `UPDATE axes SET is_hidden = TRUE, time_last_update = time_last_update WHERE id = ?`
+	case ModerationUnhide:
+		query = `UPDATE axes SET is_hidden = FALSE, time_last_update = time_last_update WHERE id = ?`
+	case ModerationDelete:
+		query = `UPDATE axes SET is_deleted = TRUE, time_deleted = NOW(), time_last_update = time_last_update WHERE id = ? AND is_deleted = FALSE`
+		change = ChangeDeleted
+	}
+	res, err := tx.Exec(query, a.ID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+	if err := recordChange(tx, a.ID, change); err != nil {
+		return err
+	}
+	details, err := json.Marshal(map[string]interface{}{
+		"owner_id": a.OwnerID,
+		"title":    a.Title,
+	})
+	if err != nil {
+		return err
+	}
+	return audit.Record(tx, audit.Entry{
+		ActorID:    audit.Actor(actorID),
+		Action:     "axis." + action,
+		TargetType: "axis",
+		TargetID:   a.ID,
+		Reason:     reason,
+		Details:    types.NullJSONText{JSONText: details, Valid: true},
+	})
+}
+
+// NotifyModeration tells the author of an axis, or of c when it is set,
+// that a moderator or the report threshold hid or deleted it, as an event
+// and by mail. It runs after the change is committed and only logs
+// failures.
+func NotifyModeration(a *Axis, c *Comment, action string, reason *string, automatic bool) {
+	authorID := a.OwnerID
+	if c != nil {
+		authorID = c.AuthorID
+	}
+	author, err := user.GetByID(authorID)
+	if err != nil {
+		logger.BAASError("Moderation Notify Error :", err.Error())
+		return
+	}
+	if author.EffectiveStatus(time.Now()) == user.StatusBanned {
+		return
+	}
+
+	what := "Your axis \"" + a.Title + "\""
+	link := config.Config.Server.PublicURL + "/axes/" + strconv.FormatUint(a.ID, 10)
+	if c != nil {
+		what = "Your comment on the axis \"" + a.Title + "\""
+		link += "#comment-" + strconv.FormatUint(c.ID, 10)
+	}
+	verb := "hidden"
+	if action == ModerationDelete {
+		verb = "deleted"
+	}
+	text := what + " was " + verb + " by the moderators.\n"
+	if automatic {
+		text = what + " was hidden after being reported by several users. A moderator will review it.\n"
+	}
+	if reason != nil && *reason != "" {
+		text += "\nReason: " + *reason + "\n"
+	}
+	text += "\n" + link
+	event := map[string]interface{}{
+		"target_type": "axis",
+		"axis_id":     a.ID,
+		"title":       a.Title,
+		"resolution":  action,
+		"automatic":   automatic,
+		"reason":      reason,
+	}
+	if c != nil {
+		event["target_type"], event["comment_id"] = "comment", c.ID
+	}
+	notify.Publish(notify.EventModeration, event, author.ID)
+	mail.Enqueue(&mail.Message{
+		TO:      []mail.Address{{Email: author.Email, DisplayName: author.Username}},
+		Subject: what + " was " + verb,
+		Text:    text,
+	})
+}
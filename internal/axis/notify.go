@@ -0,0 +1,96 @@
+package axis
+
+import (
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"github.com/pur1fying/GO_BAAS/internal/notify"
+)
+
+// The publish helpers run after the change is committed, usually with go,
+// and only log failures.
+
+// publishRevision tells the users who favorited an axis about its new head.
+// Private and hidden axes only notify their owner.
+func publishRevision(a *Axis, rev *Revision) {
+	var recipients []uint64
+	if a.Visibility == VisibilityPrivate || a.IsHidden {
+		recipients = append(recipients, a.OwnerID)
+	} else {
+		err := database.DB.Select(&recipients, `SELECT user_id FROM axis_favorites WHERE axis_id = ?`, a.ID)
+		if err != nil {
+			logger.BAASError("Notify Revision Error :", err.Error())
+			return
+		}
+	}
+	recipients = without(recipients, rev.AuthorID)
+	notify.Publish(notify.EventAxisRevision, map[string]interface{}{
+		"axis_id":     a.ID,
+		"title":       a.Title,
+		"revision":    rev.RevisionNo,
+		"author_name": rev.AuthorName,
+		"changelog":   rev.Changelog,
+	}, recipients...)
+}
+
+// publishComment tells the axis owner and, for a reply, the parent author.
+func publishComment(a *Axis, c *Comment, parent *Comment) {
+	recipients := []uint64{a.OwnerID}
+	if parent != nil {
+		recipients = append(recipients, parent.AuthorID)
+	}
+	notify.Publish(notify.EventAxisComment, map[string]interface{}{
+		"axis_id":     a.ID,
+		"title":       a.Title,
+		"comment_id":  c.ID,
+		"parent_id":   c.ParentID,
+		"author_name": c.AuthorName,
+	}, without(recipients, c.AuthorID)...)
+}
+
+// publishMergeRequest tells the target owner and the merge request author
+// what happened to it, except whoever did it. A merge also announces the
+// new revision of the target.
+func publishMergeRequest(m *MergeRequest, action string, actorID uint64) {
+	target, err := Get(m.TargetAxisID)
+	if err != nil {
+		logger.BAASError("Notify Merge Request Error :", err.Error())
+		return
+	}
+	notify.Publish(notify.EventMergeRequest, map[string]interface{}{
+		"axis_id":          target.ID,
+		"title":            target.Title,
+		"merge_request_id": m.ID,
+		"merge_request":    m.Title,
+		"action":           action,
+		"status":           m.Status,
+	}, without([]uint64{target.OwnerID, m.AuthorID}, actorID)...)
+	if action == MergeRequestMerged && m.MergedRevision != nil {
+		rev, err := GetRevision(target.ID, *m.MergedRevision)
+		if err != nil {
+			logger.BAASError("Notify Merge Request Error :", err.Error())
+			return
+		}
+		publishRevision(target, rev)
+	}
+}
+
+func publishClearReview(a *Axis, c *Clear) {
+	notify.Publish(notify.EventClearReview, map[string]interface{}{
+		"axis_id":  a.ID,
+		"title":    a.Title,
+		"clear_id": c.ID,
+		"revision": c.Revision,
+		"status":   c.Status,
+		"reason":   c.ReviewReason,
+	}, c.UserID)
+}
+
+func without(ids []uint64, exclude uint64) []uint64 {
+	kept := ids[:0]
+	for _, id := range ids {
+		if id != exclude {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
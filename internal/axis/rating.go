@@ -0,0 +1,203 @@
+package axis
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+var (
+	ErrRatingNotFound = errors.New("rating not found")
+	ErrRateOwnAxis    = errors.New("cannot rate your own axis")
+)
+
+type Rating struct {
+	AxisID         uint64    `db:"axis_id" json:"axis_id"`
+	UserID         uint64    `db:"user_id" json:"user_id"`
+	Username       string    `db:"username" json:"username"`
+	Score          int       `db:"score" json:"score"`
+	Comment        *string   `db:"comment" json:"comment"`
+	TimeCreate     time.Time `db:"time_create" json:"time_create"`
+	TimeLastUpdate time.Time `db:"time_last_update" json:"time_last_update"`
+}
+
+const ratingColumns = `r.axis_id, r.user_id, u.username, r.score, r.comment, r.time_create, r.time_last_update`
+
+const ratingFrom = ` FROM axis_ratings r JOIN users u ON u.id = r.user_id `
+
+func GetRating(axisID, userID uint64) (*Rating, error) {
+	var rating Rating
+	err := database.DB.Get(&rating, `SELECT `+ratingColumns+ratingFrom+`WHERE r.axis_id = ? AND r.user_id = ?`, axisID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRatingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+func ListRatings(axisID uint64, offset, limit int) ([]Rating, error) {
+	ratings := []Rating{}
+	err := database.DB.Select(&ratings,
+		`SELECT `+ratingColumns+ratingFrom+`WHERE r.axis_id = ? AND u.is_deleted = FALSE
+		ORDER BY r.time_last_update DESC, r.user_id LIMIT ? OFFSET ?`,
+		axisID, limit, offset,
+	)
+	return ratings, err
+}
+
+// Rate creates or replaces the rating of a user and refreshes the axis score.
+func Rate(a *Axis, userID uint64, score int, comment *string) (*Rating, error) {
+	if a.OwnerID == userID {
+		return nil, ErrRateOwnAxis
+	}
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(
+		`INSERT INTO axis_ratings (axis_id, user_id, score, comment) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE score = VALUES(score), comment = VALUES(comment)`,
+		a.ID, userID, score, comment,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := refreshScores(tx, a.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetRating(a.ID, userID)
+}
+
+func Unrate(axisID, userID uint64) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`DELETE FROM axis_ratings WHERE axis_id = ? AND user_id = ?`, axisID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRatingNotFound
+	}
+	if err := refreshScores(tx, axisID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// refreshScores recomputes the rating aggregates of one axis, or of every
+// axis when axisID is 0. rating_score is a Bayesian average: each axis is
+// treated as having RatingPriorWeight extra ratings at the site wide mean,
+// so a single 5 star rating does not outrank a well reviewed axis.
+//
+// time_last_update is kept as is, scores are not edits of the axis.
+func refreshScores(tx sqlx.Ext, axisID uint64) error {
+	var mean float64
+	err := sqlx.Get(tx, &mean, `SELECT COALESCE(AVG(score), ?) FROM axis_ratings`, config.Config.Axis.RatingPriorMean)
+	if err != nil {
+		return err
+	}
+	weight := config.Config.Axis.RatingPriorWeight
+	if weight < 0 {
+		weight = 0
+	}
+
+	ratingFilter, axisFilter := "", ""
+	args := []interface{}{}
+	if axisID != 0 {
+		ratingFilter, axisFilter = `WHERE axis_id = ?`, `WHERE a.id = ?`
+		args = append(args, axisID)
+	}
+	args = append(args, weight, mean, weight)
+	if axisID != 0 {
+		args = append(args, axisID)
+	}
+	_, err = tx.Exec(
+		`UPDATE axes a LEFT JOIN (
+			SELECT axis_id, COUNT(*) AS n, SUM(score) AS s FROM axis_ratings `+ratingFilter+` GROUP BY axis_id
+		) r ON r.axis_id = a.id
+		SET a.rating_count = COALESCE(r.n, 0),
+			a.rating_sum = COALESCE(r.s, 0),
+			a.rating_score = COALESCE((? * ? + COALESCE(r.s, 0)) / NULLIF(? + COALESCE(r.n, 0), 0), 0),
+			a.time_last_update = a.time_last_update `+axisFilter,
+		args...,
+	)
+	return err
+}
+
+// StartScoreJob periodically recomputes every rating score, as they all
+// move with the site wide mean, and drops expired download dedup entries.
+func StartScoreJob() {
+	interval := time.Duration(config.Config.Axis.ScoreRefreshMinutes) * time.Minute
+	if interval <= 0 {
+		logger.BAASWarn("Axis score job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := refreshScores(database.DB, 0); err != nil {
+				logger.BAASError("Axis Score Error :", err.Error())
+			}
+			if err := pruneDownloads(); err != nil {
+				logger.BAASError("Axis Download Prune Error :", err.Error())
+			}
+		}
+	}()
+}
+
+// PurgeUser removes the ratings, favorites, clears and download records of
+// a purged user and corrects the counters of the affected axes.
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	if err := purgeUserClears(tx, userID); err != nil {
+		return err
+	}
+	var rated []uint64
+	if err := tx.Select(&rated, `SELECT axis_id FROM axis_ratings WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM axis_ratings WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, axisID := range rated {
+		if err := refreshScores(tx, axisID); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(
+		`UPDATE axes a JOIN axis_favorites f ON f.axis_id = a.id AND f.user_id = ?
+		SET a.favorite_count = a.favorite_count - 1, a.time_last_update = a.time_last_update`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM axis_favorites WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM axis_downloads WHERE viewer_key = ?`, userViewerKey(userID))
+	return err
+}
+
+// ExportUserRatings lists the ratings the user gave, for the personal data export.
+func ExportUserRatings(userID uint64) (interface{}, error) {
+	ratings := []Rating{}
+	err := database.DB.Select(&ratings, `SELECT `+ratingColumns+ratingFrom+`WHERE r.user_id = ? ORDER BY r.axis_id`, userID)
+	return ratings, err
+}
@@ -0,0 +1,162 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+type rateRequest struct {
+	Score   int     `json:"score"`
+	Comment *string `json:"comment"`
+}
+
+func handlerListRatings(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	page, pageSize, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	ratings, err := ListRatings(a.ID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     ratings,
+	})
+}
+
+func handlerGetMyRating(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	rating, err := GetRating(a.ID, userID)
+	if err != nil {
+		respondRatingError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, rating)
+}
+
+func handlerRate(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	var req rateRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Score < 1 || req.Score > 5 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "score must be 1-5")
+		return
+	}
+	if req.Comment != nil {
+		comment := strings.TrimSpace(*req.Comment)
+		if utf8.RuneCountInString(comment) > 1000 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "comment must be at most 1000 characters")
+			return
+		}
+		req.Comment = &comment
+		if comment == "" {
+			req.Comment = nil
+		}
+	}
+	userID, _ := api.CurrentUserID(r)
+	rating, err := Rate(a, userID, req.Score, req.Comment)
+	if err != nil {
+		respondRatingError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, rating)
+}
+
+func handlerUnrate(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if err := Unrate(a.ID, userID); err != nil {
+		respondRatingError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerListFavorites(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	page, pageSize, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	axes, err := ListFavorites(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	localize(r, axes)
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     axes,
+	})
+}
+
+func handlerFavorite(w http.ResponseWriter, r *http.Request) {
+	setFavorite(w, r, true)
+}
+
+func handlerUnfavorite(w http.ResponseWriter, r *http.Request) {
+	setFavorite(w, r, false)
+}
+
+func setFavorite(w http.ResponseWriter, r *http.Request, favorite bool) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if err := SetFavorite(a.ID, userID, favorite); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// countDownload records a file download. A failure is only logged, it must
+// not keep the viewer from getting the file.
+func countDownload(r *http.Request, a *Axis) {
+	key := ipViewerKey(api.ClientIP(r))
+	if userID, ok := api.CurrentUserID(r); ok {
+		key = userViewerKey(userID)
+	}
+	if err := CountDownload(a.ID, key); err != nil {
+		logger.BAASError("Axis Download Count Error :", err.Error())
+	}
+}
+
+func respondRatingError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrRatingNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrRateOwnAxis) {
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, err.Error())
+		return
+	}
+	api.ResponseWithInternalError(w, err)
+}
@@ -0,0 +1,392 @@
+package axis
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/axisfile"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"github.com/pur1fying/GO_BAAS/internal/storage"
+)
+
+const storeBatchSize = 100
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrUnchanged        = errors.New("content identical to the latest revision")
+)
+
+type Revision struct {
+	ID            uint64     `db:"id" json:"id"`
+	AxisID        uint64     `db:"axis_id" json:"axis_id"`
+	RevisionNo    uint32     `db:"revision_no" json:"revision"`
+	AuthorID      uint64     `db:"author_id" json:"author_id"`
+	AuthorName    string     `db:"author_name" json:"author_name"`
+	FormatVersion int        `db:"format_version" json:"format_version"`
+	ContentHash   string     `db:"content_hash" json:"content_hash"`
+	StorageKey    *string    `db:"storage_key" json:"-"`
+	Changelog     string     `db:"changelog" json:"changelog"`
+	GameVersion   string     `db:"game_version" json:"game_version"`
+	RollbackOf    *uint32    `db:"rollback_of" json:"rollback_of"`
+	IsPublished   bool       `db:"is_published" json:"is_published"`
+	TimeCreate    time.Time  `db:"time_create" json:"time_create"`
+	TimePublished *time.Time `db:"time_published" json:"time_published"`
+}
+
+type NewRevision struct {
+	AuthorID      uint64
+	FormatVersion int
+	Content       []byte
+	Changelog     string
+	GameVersion   string
+	Publish       bool
+	RollbackOf    *uint32
+	// StorageKey is set by storeContent. Revisions inserted without one are
+	// stored later by StoreRevisionContents.
+	StorageKey *string
+}
+
+const revisionColumns = `r.id, r.axis_id, r.revision_no, r.author_id, u.username AS author_name, r.format_version,
+	r.content_hash, r.storage_key, r.changelog, r.game_version, r.rollback_of, r.is_published, r.time_create, r.time_published`
+
+const revisionFrom = ` FROM axis_revisions r JOIN users u ON u.id = r.author_id `
+
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func ListRevisions(axisID uint64, includeDrafts bool) ([]Revision, error) {
+	query := `SELECT ` + revisionColumns + revisionFrom + `WHERE r.axis_id = ?`
+	if !includeDrafts {
+		query += ` AND r.is_published = TRUE`
+	}
+	revisions := []Revision{}
+	err := database.DB.Select(&revisions, query+` ORDER BY r.revision_no DESC`, axisID)
+	return revisions, err
+}
+
+func GetRevision(axisID uint64, revisionNo uint32) (*Revision, error) {
+	var rev Revision
+	err := database.DB.Get(&rev,
+		`SELECT `+revisionColumns+revisionFrom+`WHERE r.axis_id = ? AND r.revision_no = ?`, axisID, revisionNo,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func GetRevisionContent(axisID uint64, revisionNo uint32) ([]byte, error) {
+	var content string
+	err := database.DB.Get(&content,
+		`SELECT content FROM axis_revisions WHERE axis_id = ? AND revision_no = ?`, axisID, revisionNo,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+func getRevisionByID(id uint64) (*Revision, error) {
+	var rev Revision
+	err := database.DB.Get(&rev, `SELECT `+revisionColumns+revisionFrom+`WHERE r.id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func getRevisionFileByID(id uint64) (*Revision, *axisfile.File, error) {
+	rev, err := getRevisionByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := GetRevisionContent(rev.AxisID, rev.RevisionNo)
+	if err != nil {
+		return nil, nil, err
+	}
+	var f axisfile.File
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, nil, err
+	}
+	return rev, &f, nil
+}
+
+// GetContent returns the axis file of the head revision, or nil if nothing
+// has been published yet.
+func GetContent(id uint64) ([]byte, error) {
+	var content *string
+	err := database.DB.Get(&content,
+		`SELECT r.content FROM axes a LEFT JOIN axis_revisions r ON r.id = a.head_revision_id
+		WHERE a.id = ? AND a.is_deleted = FALSE`, id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil || content == nil {
+		return nil, err
+	}
+	return []byte(*content), nil
+}
+
+// CreateRevision appends a revision to the axis. Published revisions become
+// the new head; drafts are stored but leave the head untouched.
+func CreateRevision(axisID uint64, nr NewRevision) (*Revision, error) {
+	if err := storeContent(&nr); err != nil {
+		return nil, err
+	}
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	revisionNo, _, err := insertRevision(tx, axisID, nr)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetRevision(axisID, revisionNo)
+}
+
+// insertRevision returns the revision number and row id of the new revision.
+func insertRevision(tx *sqlx.Tx, axisID uint64, nr NewRevision) (uint32, uint64, error) {
+	// Locking the axis row serializes revision numbering per axis.
+	var locked uint64
+	err := tx.Get(&locked, `SELECT id FROM axes WHERE id = ? AND is_deleted = FALSE FOR UPDATE`, axisID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	var latest struct {
+		RevisionNo  uint32 `db:"revision_no"`
+		ContentHash string `db:"content_hash"`
+	}
+	err = tx.Get(&latest,
+		`SELECT revision_no, content_hash FROM axis_revisions WHERE axis_id = ? ORDER BY revision_no DESC LIMIT 1`, axisID,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, err
+	}
+
+	hash := ContentHash(nr.Content)
+	if latest.ContentHash == hash && nr.RollbackOf == nil {
+		return 0, 0, ErrUnchanged
+	}
+
+	revisionNo := latest.RevisionNo + 1
+	res, err := tx.Exec(
+		`INSERT INTO axis_revisions
+			(axis_id, revision_no, author_id, format_version, content, content_hash, storage_key, changelog, game_version, rollback_of, is_published, time_published)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, IF(?, NOW(), NULL))`,
+		axisID, revisionNo, nr.AuthorID, nr.FormatVersion, string(nr.Content), hash, nr.StorageKey,
+		nr.Changelog, nr.GameVersion, nr.RollbackOf, nr.Publish, nr.Publish,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, 0, err
+	}
+	if nr.Publish {
+		if err := setHead(tx, axisID, uint64(id)); err != nil {
+			return 0, 0, err
+		}
+	}
+	return revisionNo, uint64(id), nil
+}
+
+// storeContent copies the content of nr to storage ahead of the
+// transaction that inserts it, so no row lock is held during the upload. A
+// rolled back transaction leaves the copy behind, harmless as files are
+// shared by content.
+func storeContent(nr *NewRevision) error {
+	key, err := storage.PutContentBytes(context.Background(), storage.Store, nr.Content, "application/json")
+	if err != nil {
+		return err
+	}
+	nr.StorageKey = &key
+	return nil
+}
+
+// PublishRevision publishes a draft. The head only moves if no newer
+// revision has been published in the meantime.
+func PublishRevision(axisID uint64, revisionNo uint32) (*Revision, error) {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rev struct {
+		ID          uint64 `db:"id"`
+		IsPublished bool   `db:"is_published"`
+	}
+	err = tx.Get(&rev,
+		`SELECT id, is_published FROM axis_revisions WHERE axis_id = ? AND revision_no = ? FOR UPDATE`, axisID, revisionNo,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !rev.IsPublished {
+		_, err = tx.Exec(`UPDATE axis_revisions SET is_published = TRUE, time_published = NOW() WHERE id = ?`, rev.ID)
+		if err != nil {
+			return nil, err
+		}
+		var headID uint64
+		err = tx.Get(&headID,
+			`SELECT id FROM axis_revisions WHERE axis_id = ? AND is_published = TRUE ORDER BY revision_no DESC LIMIT 1`, axisID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := setHead(tx, axisID, headID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetRevision(axisID, revisionNo)
+}
+
+// Rollback restores the content of an earlier revision as a new published
+// revision, so history is never rewritten.
+func Rollback(axisID uint64, revisionNo uint32, authorID uint64, changelog string) (*Revision, error) {
+	old, err := GetRevision(axisID, revisionNo)
+	if err != nil {
+		return nil, err
+	}
+	content, err := GetRevisionContent(axisID, revisionNo)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRevision(axisID, NewRevision{
+		AuthorID:      authorID,
+		FormatVersion: old.FormatVersion,
+		Content:       content,
+		Changelog:     changelog,
+		GameVersion:   old.GameVersion,
+		Publish:       true,
+		RollbackOf:    &revisionNo,
+	})
+}
+
+// setHead moves the head and refreshes the axis_students index used by
+// search and roster matching.
+func setHead(tx *sqlx.Tx, axisID uint64, revisionID uint64) error {
+	if _, err := tx.Exec(`UPDATE axes SET head_revision_id = ? WHERE id = ?`, revisionID, axisID); err != nil {
+		return err
+	}
+	if err := recordChange(tx, axisID, ChangeRevision); err != nil {
+		return err
+	}
+	var content string
+	if err := tx.Get(&content, `SELECT content FROM axis_revisions WHERE id = ?`, revisionID); err != nil {
+		return err
+	}
+	var f axisfile.File
+	if err := json.Unmarshal([]byte(content), &f); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM axis_students WHERE axis_id = ?`, axisID); err != nil {
+		return err
+	}
+	for _, members := range [][]axisfile.Member{f.Team.Strikers, f.Team.Specials} {
+		for _, m := range members {
+			req := m.Requirements
+			if req == nil {
+				req = &axisfile.Requirements{}
+			}
+			_, err := tx.Exec(
+				`INSERT IGNORE INTO axis_students (axis_id, student_id, min_star, min_level, min_ue_level,
+					min_skill_ex, min_skill_basic, min_skill_enhanced, min_skill_sub)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				axisID, m.StudentID, req.Star, req.Level, req.UELevel,
+				req.SkillEX, req.SkillBasic, req.SkillEnhanced, req.SkillSub,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StoreRevisionContents copies the revisions created before contents were
+// kept in storage there, and returns how many.
+func StoreRevisionContents(ctx context.Context) (int, error) {
+	stored := 0
+	for {
+		var rows []struct {
+			ID      uint64 `db:"id"`
+			Content string `db:"content"`
+		}
+		err := database.DB.Select(&rows,
+			`SELECT id, content FROM axis_revisions WHERE storage_key IS NULL ORDER BY id LIMIT ?`, storeBatchSize,
+		)
+		if err != nil {
+			return stored, err
+		}
+		for _, row := range rows {
+			if err := storeRevisionContent(ctx, row.ID, []byte(row.Content)); err != nil {
+				return stored, err
+			}
+			stored++
+		}
+		if len(rows) < storeBatchSize {
+			return stored, nil
+		}
+	}
+}
+
+// storeRevisionContent stores the content of a committed revision that has
+// no storage key yet.
+func storeRevisionContent(ctx context.Context, id uint64, content []byte) error {
+	key, err := storage.PutContentBytes(ctx, storage.Store, content, "application/json")
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`UPDATE axis_revisions SET storage_key = ? WHERE id = ? AND storage_key IS NULL`, key, id)
+	return err
+}
+
+// StartRevisionStorageJob stores older revisions once in the background, so
+// startup does not wait for it.
+func StartRevisionStorageJob() {
+	go func() {
+		n, err := StoreRevisionContents(context.Background())
+		if err != nil {
+			logger.BAASError("Axis Revision Storage Error :", err.Error())
+		}
+		if n > 0 {
+			logger.BAASInfo("Stored axis revisions :", strconv.Itoa(n))
+		}
+	}()
+}
@@ -0,0 +1,290 @@
+package axis
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/axisfile"
+	"github.com/pur1fying/GO_BAAS/internal/upload"
+)
+
+type createRevisionRequest struct {
+	Content     json.RawMessage `json:"content"`
+	Changelog   string          `json:"changelog"`
+	GameVersion string          `json:"game_version"`
+	Draft       bool            `json:"draft"`
+}
+
+type rollbackRequest struct {
+	Revision  uint32 `json:"revision"`
+	Changelog string `json:"changelog"`
+}
+
+type revisionWithContent struct {
+	*Revision
+	Content json.RawMessage `json:"content"`
+}
+
+func handlerListRevisions(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	canEdit, err := isOwnerOrModerator(r, a)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	revisions, err := ListRevisions(a.ID, canEdit)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, revisions)
+}
+
+func handlerGetRevision(w http.ResponseWriter, r *http.Request) {
+	a, rev, ok := loadVisibleRevision(w, r)
+	if !ok {
+		return
+	}
+	content, err := GetRevisionContent(a.ID, rev.RevisionNo)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, revisionWithContent{Revision: rev, Content: content})
+}
+
+func handlerCreateRevision(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req createRevisionRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	req.Changelog = strings.TrimSpace(req.Changelog)
+	req.GameVersion = strings.TrimSpace(req.GameVersion)
+	if msg := validateRevisionMeta(req.Changelog, req.GameVersion); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	if len(req.Content) == 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "content is required")
+		return
+	}
+	if err := scanAxisContent(r.Context(), req.Content); err != nil {
+		upload.RespondError(w, err)
+		return
+	}
+	f, ok := parseAxisFile(w, req.Content)
+	if !ok {
+		return
+	}
+	content, err := json.Marshal(f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+
+	rev, err := CreateRevision(a.ID, NewRevision{
+		AuthorID:      userID,
+		FormatVersion: f.FormatVersion,
+		Content:       content,
+		Changelog:     req.Changelog,
+		GameVersion:   req.GameVersion,
+		Publish:       !req.Draft,
+	})
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	if rev.IsPublished {
+		go publishRevision(a, rev)
+	}
+	api.ResponseWithJson(w, http.StatusCreated, rev)
+}
+
+func handlerPublishRevision(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	revisionNo, ok := parseRevisionNo(w, chi.URLParam(r, "rev"))
+	if !ok {
+		return
+	}
+	draft, err := GetRevision(a.ID, revisionNo)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	rev, err := PublishRevision(a.ID, revisionNo)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	if !draft.IsPublished {
+		go publishRevision(a, rev)
+	}
+	api.ResponseWithJson(w, http.StatusOK, rev)
+}
+
+func handlerRollback(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req rollbackRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	req.Changelog = strings.TrimSpace(req.Changelog)
+	if req.Changelog == "" {
+		req.Changelog = "Rollback to revision " + strconv.FormatUint(uint64(req.Revision), 10)
+	}
+	if msg := validateRevisionMeta(req.Changelog, ""); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	rev, err := Rollback(a.ID, req.Revision, userID, req.Changelog)
+	if err != nil {
+		respondAxisError(w, err)
+		return
+	}
+	go publishRevision(a, rev)
+	api.ResponseWithJson(w, http.StatusCreated, rev)
+}
+
+// handlerDiff compares two revisions; "to" defaults to the head revision
+// and "from" to the revision before "to", given or not.
+func handlerDiff(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	var toNo uint32
+	if v := q.Get("to"); v != "" {
+		if toNo, ok = parseRevisionNo(w, v); !ok {
+			return
+		}
+	} else if a.HeadRevision != nil {
+		toNo = *a.HeadRevision
+	} else {
+		respondAxisError(w, ErrRevisionNotFound)
+		return
+	}
+	fromNo := toNo - 1
+	if v := q.Get("from"); v != "" {
+		if fromNo, ok = parseRevisionNo(w, v); !ok {
+			return
+		}
+	} else if fromNo == 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "from is required, revision 1 has no previous revision")
+		return
+	}
+	if fromNo == toNo {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "from and to must be two different revisions")
+		return
+	}
+
+	from, fromFile, ok := loadRevisionFile(w, r, a, fromNo)
+	if !ok {
+		return
+	}
+	to, toFile, ok := loadRevisionFile(w, r, a, toNo)
+	if !ok {
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"diff": axisfile.Diff(fromFile, toFile),
+	})
+}
+
+func loadRevisionFile(w http.ResponseWriter, r *http.Request, a *Axis, revisionNo uint32) (*Revision, *axisfile.File, bool) {
+	rev, ok := visibleRevision(w, r, a, revisionNo)
+	if !ok {
+		return nil, nil, false
+	}
+	content, err := GetRevisionContent(a.ID, revisionNo)
+	if err != nil {
+		respondAxisError(w, err)
+		return nil, nil, false
+	}
+	var f axisfile.File
+	if err := json.Unmarshal(content, &f); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, nil, false
+	}
+	return rev, &f, true
+}
+
+// loadVisibleRevision resolves {rev} of a visible axis. Drafts are only
+// visible to those who may edit the axis.
+func loadVisibleRevision(w http.ResponseWriter, r *http.Request) (*Axis, *Revision, bool) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	revisionNo, ok := parseRevisionNo(w, chi.URLParam(r, "rev"))
+	if !ok {
+		return nil, nil, false
+	}
+	rev, ok := visibleRevision(w, r, a, revisionNo)
+	return a, rev, ok
+}
+
+// visibleRevision loads a revision of a, drafts only being visible to the
+// people who can edit the axis.
+func visibleRevision(w http.ResponseWriter, r *http.Request, a *Axis, revisionNo uint32) (*Revision, bool) {
+	rev, err := GetRevision(a.ID, revisionNo)
+	if err != nil {
+		respondAxisError(w, err)
+		return nil, false
+	}
+	if !rev.IsPublished {
+		canEdit, err := isOwnerOrModerator(r, a)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return nil, false
+		}
+		if !canEdit {
+			respondAxisError(w, ErrRevisionNotFound)
+			return nil, false
+		}
+	}
+	return rev, true
+}
+
+func parseRevisionNo(w http.ResponseWriter, value string) (uint32, bool) {
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || n == 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid revision")
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+func validateRevisionMeta(changelog string, gameVersion string) string {
+	if utf8.RuneCountInString(changelog) > 500 {
+		return "changelog must be at most 500 characters"
+	}
+	if utf8.RuneCountInString(gameVersion) > 32 {
+		return "game_version must be at most 32 characters"
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package axis
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/schemas/{version}", handlerGetSchema)
+	r.Post("/validate", handlerValidateFile)
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireScope(api.ScopeAxisRead))
+		r.Get("/", handlerListAxes)
+		r.Get("/search", handlerSearch)
+		r.With(api.RequireUser, api.RequireScope(api.ScopeProfileRead)).Get("/runnable", handlerRunnable)
+		r.Get("/{id}", handlerGetAxis)
+		r.Get("/{id}/file", handlerGetFile)
+		r.Get("/{id}/file/link", handlerGetFileLink)
+		r.Get("/{id}/revisions", handlerListRevisions)
+		r.Get("/{id}/revisions/{rev}", handlerGetRevision)
+		r.Get("/{id}/diff", handlerDiff)
+		r.Get("/{id}/forks", handlerListForks)
+		r.Get("/{id}/merge-requests", handlerListMergeRequests)
+		r.Get("/{id}/merge-requests/{mr}", handlerGetMergeRequest)
+		r.Get("/{id}/merge-requests/{mr}/comments", handlerListMergeRequestComments)
+		r.Get("/{id}/ratings", handlerListRatings)
+		r.Get("/{id}/comments", handlerListComments)
+		r.Get("/{id}/comments/{comment}/edits", handlerListCommentEdits)
+		r.Get("/{id}/clears", handlerListClears)
+		r.Get("/{id}/translations", handlerListTranslations)
+		r.Get("/{id}/assets", handlerListAssets)
+		r.Get("/{id}/assets/{name}", handlerGetAsset)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireUser)
+		r.With(api.RequireScope(api.ScopeAxisRead)).Get("/favorites", handlerListFavorites)
+		r.With(api.RequireScope(api.ScopeAxisRead)).Get("/export", handlerExportBundle)
+		r.With(api.RequireScope(api.ScopeAxisRead)).Get("/sync", handlerSync)
+		r.With(api.RequireScope(api.ScopeAxisRead)).Get("/{id}/rating", handlerGetMyRating)
+
+		r.Group(func(r chi.Router) {
+			r.Use(api.RequireScope(api.ScopeAxisWrite))
+			r.Put("/{id}/rating", handlerRate)
+			r.Delete("/{id}/rating", handlerUnrate)
+			r.Put("/{id}/favorite", handlerFavorite)
+			r.Delete("/{id}/favorite", handlerUnfavorite)
+			r.Post("/{id}/comments", handlerCreateComment)
+			r.Patch("/{id}/comments/{comment}", handlerEditComment)
+			r.Delete("/{id}/comments/{comment}", handlerDeleteComment)
+			r.Post("/{id}/clears", handlerCreateClear)
+			r.Delete("/{id}/clears/{clear}", handlerDeleteClear)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(api.RequireSession, rbac.RequirePermission(rbac.PermAxisModerate))
+			r.Post("/{id}/comments/{comment}/hide", handlerHideComment)
+			r.Post("/{id}/comments/{comment}/unhide", handlerUnhideComment)
+			r.Post("/{id}/clears/{clear}/verify", handlerVerifyClear)
+			r.Post("/{id}/clears/{clear}/reject", handlerRejectClear)
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireScope(api.ScopeAxisWrite), rbac.RequirePermission(rbac.PermAxisCreate))
+		r.Post("/", handlerCreateAxis)
+		r.Post("/import", handlerImportBundle)
+		r.Patch("/{id}", handlerUpdateAxis)
+		r.Delete("/{id}", handlerDeleteAxis)
+		r.Put("/{id}/translations/{lang}", handlerSetTranslation)
+		r.Delete("/{id}/translations/{lang}", handlerDeleteTranslation)
+		r.Post("/{id}/revisions", handlerCreateRevision)
+		r.Post("/{id}/revisions/{rev}/publish", handlerPublishRevision)
+		r.Post("/{id}/rollback", handlerRollback)
+		r.Post("/{id}/fork", handlerFork)
+		r.Post("/{id}/merge-requests", handlerCreateMergeRequest)
+		r.Post("/{id}/merge-requests/{mr}/comments", handlerAddMergeRequestComment)
+		r.Post("/{id}/merge-requests/{mr}/accept", handlerAcceptMergeRequest)
+		r.Post("/{id}/merge-requests/{mr}/reject", handlerRejectMergeRequest)
+		r.Post("/{id}/merge-requests/{mr}/close", handlerCloseMergeRequest)
+	})
+	return r
+}
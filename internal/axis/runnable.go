@@ -0,0 +1,112 @@
+package axis
+
+import (
+	"sort"
+
+	"github.com/pur1fying/GO_BAAS/internal/axisfile"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/roster"
+)
+
+// maxRunnableCandidates bounds how many axes of a stage are checked against
+// a roster, taking the best rated ones first.
+const maxRunnableCandidates = 500
+
+type Blocker struct {
+	StudentID uint32 `json:"student_id"`
+	// Reason is "missing" or the name of the requirement that is not met.
+	Reason   string `json:"reason"`
+	Required int    `json:"required"`
+	Owned    int    `json:"owned"`
+}
+
+type RunnableAxis struct {
+	Axis
+	Runnable bool      `json:"runnable"`
+	Blockers []Blocker `json:"blockers"`
+}
+
+type axisRequirement struct {
+	AxisID    uint64 `db:"axis_id"`
+	StudentID uint32 `db:"student_id"`
+	axisfile.Requirements
+}
+
+// Runnable checks the public axes of a stage against the user's roster.
+// Axes the user can run come first, then those with the fewest blockers.
+func Runnable(userID uint64, stageID string, onlyRunnable bool) ([]RunnableAxis, error) {
+	owned, err := roster.Map(userID)
+	if err != nil {
+		return nil, err
+	}
+	axes := []Axis{}
+	err = database.DB.Select(&axes,
+		`SELECT `+axisColumns+axisFrom+`WHERE a.is_deleted = FALSE AND u.is_deleted = FALSE
+			AND a.visibility = ? AND a.is_hidden = FALSE AND a.stage_id = ? AND a.head_revision_id IS NOT NULL
+		ORDER BY a.rating_score DESC, a.id DESC LIMIT ?`,
+		VisibilityPublic, stageID, maxRunnableCandidates,
+	)
+	if err != nil || len(axes) == 0 {
+		return []RunnableAxis{}, err
+	}
+
+	var reqs []axisRequirement
+	err = database.DB.Select(&reqs,
+		`SELECT s.axis_id, s.student_id, s.min_star, s.min_level, s.min_ue_level, s.min_skill_ex,
+			s.min_skill_basic, s.min_skill_enhanced, s.min_skill_sub
+		FROM axis_students s JOIN axes a ON a.id = s.axis_id
+		WHERE a.is_deleted = FALSE AND a.visibility = ? AND a.stage_id = ?`,
+		VisibilityPublic, stageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	byAxis := map[uint64][]axisRequirement{}
+	for _, req := range reqs {
+		byAxis[req.AxisID] = append(byAxis[req.AxisID], req)
+	}
+
+	result := make([]RunnableAxis, 0, len(axes))
+	for _, a := range axes {
+		blockers := []Blocker{}
+		for _, req := range byAxis[a.ID] {
+			blockers = append(blockers, checkStudent(req, owned)...)
+		}
+		if onlyRunnable && len(blockers) > 0 {
+			continue
+		}
+		result = append(result, RunnableAxis{Axis: a, Runnable: len(blockers) == 0, Blockers: blockers})
+	}
+	// Candidates are already ordered by rating, a stable sort keeps that as
+	// the tie breaker.
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].Blockers) < len(result[j].Blockers)
+	})
+	return result, nil
+}
+
+func checkStudent(req axisRequirement, owned map[uint32]roster.Student) []Blocker {
+	s, ok := owned[req.StudentID]
+	if !ok {
+		return []Blocker{{StudentID: req.StudentID, Reason: "missing"}}
+	}
+	checks := []struct {
+		reason          string
+		required, owned int
+	}{
+		{"star", req.Star, s.Star},
+		{"level", req.Level, s.Level},
+		{"ue_level", req.UELevel, s.UELevel},
+		{"skill_ex", req.SkillEX, s.SkillEX},
+		{"skill_basic", req.SkillBasic, s.SkillBasic},
+		{"skill_enhanced", req.SkillEnhanced, s.SkillEnhanced},
+		{"skill_sub", req.SkillSub, s.SkillSub},
+	}
+	var blockers []Blocker
+	for _, c := range checks {
+		if c.owned < c.required {
+			blockers = append(blockers, Blocker{StudentID: req.StudentID, Reason: c.reason, Required: c.required, Owned: c.owned})
+		}
+	}
+	return blockers
+}
@@ -0,0 +1,262 @@
+package axis
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+const (
+	SortRelevance = "relevance"
+	SortRating    = "rating"
+	SortDownloads = "downloads"
+	SortClears    = "clears"
+	SortRecent    = "recent"
+)
+
+const facetLimit = 20
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type SearchQuery struct {
+	Keyword    string
+	StageID    string
+	Difficulty string
+	Server     string
+	Students   []uint32
+	AuthorID   uint64
+	// VerifiedOnly keeps axes with at least one verified clear.
+	VerifiedOnly bool
+	Sort         string
+	Cursor       string
+	Limit        int
+}
+
+type FacetCount struct {
+	Value string `db:"value" json:"value"`
+	Label string `db:"label" json:"label,omitempty"`
+	Count int    `db:"count" json:"count"`
+}
+
+type SearchResult struct {
+	Items      []Axis                  `json:"items"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	Facets     map[string][]FacetCount `json:"facets,omitempty"`
+}
+
+// searchCursor is the keyset position after the last returned row. It is
+// handed out base64 encoded and treated as opaque by clients. The sort value
+// keeps its type, Time for recent and Num for the others, so the next page
+// compares it the way the rows were ordered.
+type searchCursor struct {
+	Sort string    `json:"s"`
+	Num  float64   `json:"n,omitempty"`
+	Time time.Time `json:"t"`
+	ID   uint64    `json:"i"`
+}
+
+func (c *searchCursor) key() interface{} {
+	if c.Sort == SortRecent {
+		return c.Time
+	}
+	return c.Num
+}
+
+type searchRow struct {
+	Axis
+	Score float64 `db:"score"`
+}
+
+// cursorAfter returns the cursor of the page that starts after row.
+func cursorAfter(sort string, row searchRow) searchCursor {
+	c := searchCursor{Sort: sort, ID: row.ID}
+	switch sort {
+	case SortRelevance:
+		c.Num = row.Score
+	case SortRating:
+		c.Num = row.RatingScore
+	case SortDownloads:
+		c.Num = float64(row.DownloadCount)
+	case SortClears:
+		c.Num = float64(row.VerifiedClearCount)
+	case SortRecent:
+		c.Time = row.TimeLastUpdate
+	}
+	return c
+}
+
+const matchExpr = `MATCH(a.title, a.description) AGAINST (? IN NATURAL LANGUAGE MODE)`
+
+// translationMatch also finds axes by their translations. Relevance is only
+// scored on the original text, so such matches rank after direct ones.
+const translationMatch = `a.id IN (SELECT axis_id FROM axis_translations t
+	WHERE MATCH(t.title, t.description) AGAINST (? IN NATURAL LANGUAGE MODE))`
+
+// Search runs a keyword and facet filtered query over public axes. Facet
+// counts are only computed for the first page, as they do not change while
+// paging through the same query.
+func Search(q SearchQuery) (*SearchResult, error) {
+	if q.Sort == "" || (q.Sort == SortRelevance && q.Keyword == "") {
+		if q.Keyword != "" {
+			q.Sort = SortRelevance
+		} else {
+			q.Sort = SortRecent
+		}
+	}
+	where, args := searchWhere(q)
+
+	// Only relevance is not a column of the row, it is selected as score.
+	var sortExpr string
+	var sortArgs []interface{}
+	scoreExpr := "0"
+	switch q.Sort {
+	case SortRelevance:
+		sortExpr, sortArgs = matchExpr, []interface{}{q.Keyword}
+		scoreExpr = matchExpr
+	case SortRating:
+		sortExpr = "a.rating_score"
+	case SortDownloads:
+		sortExpr = "a.download_count"
+	case SortClears:
+		sortExpr = "a.verified_clear_count"
+	case SortRecent:
+		sortExpr = "a.time_last_update"
+	default:
+		return nil, errors.New("unknown sort " + q.Sort)
+	}
+
+	pageWhere := append([]string{}, where...)
+	pageArgs := append([]interface{}{}, args...)
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil || c.Sort != q.Sort {
+			return nil, ErrInvalidCursor
+		}
+		pageWhere = append(pageWhere, "("+sortExpr+" < ? OR ("+sortExpr+" = ? AND a.id < ?))")
+		pageArgs = append(pageArgs, sortArgs...)
+		pageArgs = append(pageArgs, c.key())
+		pageArgs = append(pageArgs, sortArgs...)
+		pageArgs = append(pageArgs, c.key(), c.ID)
+	}
+
+	// Placeholders appear in SELECT, WHERE, ORDER BY and LIMIT, in that order.
+	queryArgs := append([]interface{}{}, sortArgs...)
+	queryArgs = append(queryArgs, pageArgs...)
+	queryArgs = append(queryArgs, sortArgs...)
+	queryArgs = append(queryArgs, q.Limit+1)
+	var rows []searchRow
+	err := database.DB.Select(&rows,
+		`SELECT `+axisColumns+`, `+scoreExpr+` AS score`+axisFrom+
+			`WHERE `+strings.Join(pageWhere, " AND ")+
+			` ORDER BY `+sortExpr+` DESC, a.id DESC LIMIT ?`,
+		queryArgs...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{Items: []Axis{}}
+	for i, row := range rows {
+		if i == q.Limit {
+			result.NextCursor = encodeCursor(cursorAfter(q.Sort, rows[i-1]))
+			break
+		}
+		result.Items = append(result.Items, row.Axis)
+	}
+
+	if q.Cursor == "" {
+		if result.Facets, err = searchFacets(where, args); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func searchWhere(q SearchQuery) ([]string, []interface{}) {
+	where := []string{"a.is_deleted = FALSE", "u.is_deleted = FALSE", "a.visibility = ?", "a.is_hidden = FALSE", "a.head_revision_id IS NOT NULL"}
+	args := []interface{}{VisibilityPublic}
+	if q.Keyword != "" {
+		where = append(where, "("+matchExpr+" OR "+translationMatch+")")
+		args = append(args, q.Keyword, q.Keyword)
+	}
+	if q.StageID != "" {
+		where = append(where, "a.stage_id = ?")
+		args = append(args, q.StageID)
+	}
+	if q.Difficulty != "" {
+		where = append(where, "a.difficulty = ?")
+		args = append(args, q.Difficulty)
+	}
+	if q.Server != "" {
+		where = append(where, "a.server = ?")
+		args = append(args, q.Server)
+	}
+	if q.AuthorID != 0 {
+		where = append(where, "a.owner_id = ?")
+		args = append(args, q.AuthorID)
+	}
+	if q.VerifiedOnly {
+		where = append(where, "a.verified_clear_count > 0")
+	}
+	if len(q.Students) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(q.Students)), ", ")
+		where = append(where, `a.id IN (SELECT axis_id FROM axis_students WHERE student_id IN (`+placeholders+`)
+			GROUP BY axis_id HAVING COUNT(*) = ?)`)
+		for _, id := range q.Students {
+			args = append(args, id)
+		}
+		args = append(args, len(q.Students))
+	}
+	return where, args
+}
+
+func searchFacets(where []string, args []interface{}) (map[string][]FacetCount, error) {
+	facets := map[string]struct {
+		value string
+		label string
+		join  string
+	}{
+		"stage":      {value: "a.stage_id", label: "''"},
+		"difficulty": {value: "a.difficulty", label: "''"},
+		"server":     {value: "a.server", label: "''"},
+		"author":     {value: "CAST(a.owner_id AS CHAR)", label: "MAX(u.username)"},
+		"student":    {value: "CAST(s.student_id AS CHAR)", label: "''", join: "JOIN axis_students s ON s.axis_id = a.id "},
+	}
+	result := make(map[string][]FacetCount, len(facets))
+	for name, f := range facets {
+		counts := []FacetCount{}
+		err := database.DB.Select(&counts,
+			`SELECT `+f.value+` AS value, `+f.label+` AS label, COUNT(*) AS count`+axisFrom+f.join+
+				`WHERE `+strings.Join(where, " AND ")+
+				` GROUP BY value ORDER BY count DESC, value LIMIT `+strconv.Itoa(facetLimit),
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = counts
+	}
+	return result, nil
+}
+
+func encodeCursor(c searchCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c searchCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,103 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+const maxSearchStudents = 6
+
+func handlerSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := SearchQuery{
+		Keyword:      strings.TrimSpace(params.Get("q")),
+		StageID:      params.Get("stage_id"),
+		Difficulty:   params.Get("difficulty"),
+		Server:       params.Get("server"),
+		Sort:         params.Get("sort"),
+		Cursor:       params.Get("cursor"),
+		Limit:        defaultPageSize,
+		VerifiedOnly: params.Get("verified") == "true",
+	}
+	if utf8.RuneCountInString(q.Keyword) > 100 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "q must be at most 100 characters")
+		return
+	}
+	if q.Difficulty != "" && !IsValidDifficulty(q.Difficulty) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown difficulty")
+		return
+	}
+	if q.Server != "" && !IsValidServer(q.Server) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown server")
+		return
+	}
+	switch q.Sort {
+	case "", SortRelevance, SortRating, SortDownloads, SortClears, SortRecent:
+	default:
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown sort")
+		return
+	}
+	if v := params.Get("author_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid author_id")
+			return
+		}
+		q.AuthorID = id
+	}
+	for _, v := range params["student"] {
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+			if err != nil {
+				api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid student")
+				return
+			}
+			q.Students = append(q.Students, uint32(id))
+		}
+	}
+	if len(q.Students) > maxSearchStudents {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "too many students")
+		return
+	}
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid limit")
+			return
+		}
+		q.Limit = n
+	}
+
+	result, err := Search(q)
+	if errors.Is(err, ErrInvalidCursor) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	localize(r, result.Items)
+	api.ResponseWithJson(w, http.StatusOK, result)
+}
+
+func handlerRunnable(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	stageID := r.URL.Query().Get("stage_id")
+	if stageID == "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "stage_id is required")
+		return
+	}
+	onlyRunnable := r.URL.Query().Get("only_runnable") == "true"
+	axes, err := Runnable(userID, stageID, onlyRunnable)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, axes)
+}
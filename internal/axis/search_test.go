@@ -0,0 +1,41 @@
+package axis
+
+import (
+	"testing"
+	"time"
+)
+
+// The cursor has to hand back the sort value with its type and precision,
+// a rounded or stringified score would skip or repeat rows on the next page.
+func TestCursorKeepsSortValue(t *testing.T) {
+	updated := time.Date(2026, 3, 1, 12, 30, 5, 0, time.UTC)
+	row := searchRow{Axis: Axis{ID: 42, RatingScore: 4.123456789012345, DownloadCount: 10, TimeLastUpdate: updated}, Score: 0.30000000000000004}
+	tests := []struct {
+		sort string
+		want interface{}
+	}{
+		{SortRelevance, 0.30000000000000004},
+		{SortRating, 4.123456789012345},
+		{SortDownloads, float64(10)},
+		{SortRecent, updated},
+	}
+	for _, tt := range tests {
+		c, err := decodeCursor(encodeCursor(cursorAfter(tt.sort, row)))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if c.Sort != tt.sort || c.ID != 42 {
+			t.Errorf("%s: cursor = %+v", tt.sort, c)
+		}
+		switch want := tt.want.(type) {
+		case time.Time:
+			if got, ok := c.key().(time.Time); !ok || !got.Equal(want) {
+				t.Errorf("%s: key = %v, want %v", tt.sort, c.key(), want)
+			}
+		default:
+			if c.key() != want {
+				t.Errorf("%s: key = %v, want %v", tt.sort, c.key(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package axis
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+// Kinds of axis_changes rows. Subscribed and unsubscribed only concern the
+// user of the row.
+const (
+	ChangeCreated      = "created"
+	ChangeUpdated      = "updated"
+	ChangeDeleted      = "deleted"
+	ChangeRevision     = "revision"
+	ChangeSubscribed   = "subscribed"
+	ChangeUnsubscribed = "unsubscribed"
+)
+
+// syncSettleSeconds keeps the newest changes out of the feed for a moment.
+// Ids are taken when a transaction inserts its change but become visible on
+// commit, so a lower id can show up after a cursor already moved past it.
+const syncSettleSeconds = 5
+
+// SyncChange is the current state of a subscribed axis that changed since
+// the cursor. Deleted is set when the client should drop the axis: it was
+// deleted, became invisible to the user or was unsubscribed.
+type SyncChange struct {
+	AxisID  uint64    `json:"axis_id"`
+	Deleted bool      `json:"deleted"`
+	Axis    *Axis     `json:"axis,omitempty"`
+	Head    *Revision `json:"head_revision,omitempty"`
+}
+
+type SyncResult struct {
+	Changes []SyncChange `json:"changes"`
+	Cursor  string       `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+}
+
+func recordChange(db sqlx.Execer, axisID uint64, kind string) error {
+	_, err := db.Exec(`INSERT INTO axis_changes (axis_id, kind) VALUES (?, ?)`, axisID, kind)
+	return err
+}
+
+func recordUserChange(db sqlx.Execer, axisID, userID uint64, kind string) error {
+	_, err := db.Exec(`INSERT INTO axis_changes (axis_id, user_id, kind) VALUES (?, ?, ?)`, axisID, userID, kind)
+	return err
+}
+
+// RecordOwnerChange records a change of every axis of userID, whose account
+// was deleted, suspended or banned, or became available again. Run inside
+// the transaction changing the account so sync clients never miss it.
+func RecordOwnerChange(tx *sqlx.Tx, userID uint64, available bool) error {
+	kind := ChangeDeleted
+	if available {
+		kind = ChangeUpdated
+	}
+	_, err := tx.Exec(
+		`INSERT INTO axis_changes (axis_id, kind) SELECT id, ? FROM axes WHERE owner_id = ? AND is_deleted = FALSE`,
+		kind, userID,
+	)
+	return err
+}
+
+// Sync returns the axes userID subscribes to, the ones they own or
+// favorited, that changed after cursor. An empty cursor starts a full sync,
+// which leaves out tombstones since the client has nothing to delete yet.
+func Sync(userID uint64, cursor string, limit int) (*SyncResult, error) {
+	var after uint64
+	if cursor != "" {
+		var err error
+		if after, err = decodeSyncCursor(cursor); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	var rows []struct {
+		AxisID uint64 `db:"axis_id"`
+		Seq    uint64 `db:"seq"`
+	}
+	err := database.DB.Select(&rows,
+		`SELECT c.axis_id, MAX(c.id) AS seq FROM axis_changes c
+		WHERE c.id > ? AND c.time_create <= NOW() - INTERVAL ? SECOND AND (c.user_id IS NULL OR c.user_id = ?)
+		AND (c.user_id = ?
+			OR EXISTS (SELECT 1 FROM axes a WHERE a.id = c.axis_id AND a.owner_id = ?)
+			OR EXISTS (SELECT 1 FROM axis_favorites f WHERE f.axis_id = c.axis_id AND f.user_id = ?))
+		GROUP BY c.axis_id ORDER BY seq LIMIT ?`,
+		after, syncSettleSeconds, userID, userID, userID, userID, limit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Changes: []SyncChange{}, Cursor: cursor}
+	if len(rows) > limit {
+		rows, result.HasMore = rows[:limit], true
+	}
+	for _, row := range rows {
+		change, err := syncState(row.AxisID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !change.Deleted || after != 0 {
+			result.Changes = append(result.Changes, *change)
+		}
+		after = row.Seq
+	}
+	if len(rows) > 0 {
+		result.Cursor = encodeSyncCursor(after)
+	}
+	return result, nil
+}
+
+func syncState(axisID, userID uint64) (*SyncChange, error) {
+	tombstone := &SyncChange{AxisID: axisID, Deleted: true}
+	a, err := Get(axisID)
+	if errors.Is(err, ErrNotFound) {
+		return tombstone, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Syncing copies axes to a client, so moderators only get what they
+	// could see as plain users.
+	if !(Viewer{UserID: userID}).CanView(a) {
+		return tombstone, nil
+	}
+	if a.OwnerID != userID {
+		var favorited bool
+		err := database.DB.Get(&favorited,
+			`SELECT EXISTS (SELECT 1 FROM axis_favorites WHERE axis_id = ? AND user_id = ?)`, axisID, userID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !favorited {
+			return tombstone, nil
+		}
+	}
+	change := &SyncChange{AxisID: axisID, Axis: a}
+	if a.HeadRevisionID != nil {
+		if change.Head, err = getRevisionByID(*a.HeadRevisionID); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+func encodeSyncCursor(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(seq, 10)))
+}
+
+func decodeSyncCursor(s string) (uint64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(data), 10, 64)
+}
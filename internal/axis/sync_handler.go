@@ -0,0 +1,37 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+const maxSyncPageSize = 500
+
+// handlerSync is polled by the BAAS client with the cursor of its previous
+// response. A client that is not up to date yet gets has_more and should
+// ask again right away.
+func handlerSync(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	limit := maxSyncPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSyncPageSize {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	result, err := Sync(userID, r.URL.Query().Get("cursor"), limit)
+	if errors.Is(err, ErrInvalidCursor) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, result)
+}
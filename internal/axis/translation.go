@@ -0,0 +1,160 @@
+package axis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/i18n"
+)
+
+var (
+	ErrTranslationNotFound = errors.New("translation not found")
+	ErrOriginalLanguage    = errors.New("language is the original language of the axis")
+)
+
+// Translation is the title and description of an axis in a language other
+// than its own.
+type Translation struct {
+	Language       string    `db:"language" json:"language"`
+	Title          string    `db:"title" json:"title"`
+	Description    *string   `db:"description" json:"description"`
+	TimeCreate     time.Time `db:"time_create" json:"time_create"`
+	TimeLastUpdate time.Time `db:"time_last_update" json:"time_last_update"`
+}
+
+func ListTranslations(axisID uint64) ([]Translation, error) {
+	translations := []Translation{}
+	err := database.DB.Select(&translations,
+		`SELECT language, title, description, time_create, time_last_update FROM axis_translations
+		WHERE axis_id = ? ORDER BY language`, axisID,
+	)
+	return translations, err
+}
+
+// SetTranslation adds or replaces the translation of a into lang.
+func SetTranslation(a *Axis, lang, title string, description *string) (*Translation, error) {
+	if lang == originalLanguage(a) {
+		return nil, ErrOriginalLanguage
+	}
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(
+		`INSERT INTO axis_translations (axis_id, language, title, description) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE title = VALUES(title), description = VALUES(description)`,
+		a.ID, lang, title, description,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordChange(tx, a.ID, ChangeUpdated); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	var t Translation
+	err = database.DB.Get(&t,
+		`SELECT language, title, description, time_create, time_last_update FROM axis_translations
+		WHERE axis_id = ? AND language = ?`, a.ID, lang,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func DeleteTranslation(axisID uint64, lang string) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`DELETE FROM axis_translations WHERE axis_id = ? AND language = ?`, axisID, lang)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = ErrTranslationNotFound
+		}
+		return err
+	}
+	if err := recordChange(tx, axisID, ChangeUpdated); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Localize serves each axis in the first of langs it exists in, either its
+// original language or a translation. Axes without a match keep the
+// original.
+func Localize(axes []Axis, langs []string) error {
+	if len(axes) == 0 || len(langs) == 0 {
+		return nil
+	}
+	ids := make([]uint64, 0, len(axes))
+	for _, a := range axes {
+		// Nothing to load when the original is already the first choice.
+		if originalLanguage(&a) != langs[0] {
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(
+		`SELECT axis_id, language, title, description FROM axis_translations WHERE axis_id IN (?) AND language IN (?)`,
+		ids, langs,
+	)
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		AxisID      uint64  `db:"axis_id"`
+		Language    string  `db:"language"`
+		Title       string  `db:"title"`
+		Description *string `db:"description"`
+	}
+	if err := database.DB.Select(&rows, query, args...); err != nil {
+		return err
+	}
+	byAxis := map[uint64]map[string]int{}
+	for i, row := range rows {
+		if byAxis[row.AxisID] == nil {
+			byAxis[row.AxisID] = map[string]int{}
+		}
+		byAxis[row.AxisID][row.Language] = i
+	}
+	for i := range axes {
+		a := &axes[i]
+		translated := byAxis[a.ID]
+		if len(translated) == 0 {
+			continue
+		}
+		available := []string{originalLanguage(a)}
+		for lang := range translated {
+			available = append(available, lang)
+		}
+		best := i18n.Best(langs, available)
+		if best == "" || best == available[0] {
+			continue
+		}
+		row := rows[translated[best]]
+		a.Title, a.Description, a.Translation = row.Title, row.Description, best
+	}
+	return nil
+}
+
+// originalLanguage treats axes from before languages were recorded as
+// written in the default language.
+func originalLanguage(a *Axis) string {
+	if a.Language == "" {
+		return i18n.Default()
+	}
+	return a.Language
+}
@@ -0,0 +1,105 @@
+package axis
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/i18n"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+type translationRequest struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+}
+
+func handlerListTranslations(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadVisibleAxis(w, r)
+	if !ok {
+		return
+	}
+	translations, err := ListTranslations(a.ID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, translations)
+}
+
+func handlerSetTranslation(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	lang, ok := parseTranslationLanguage(w, r)
+	if !ok {
+		return
+	}
+	var req translationRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if n := utf8.RuneCountInString(title); n == 0 || n > 100 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "title must be 1-100 characters")
+		return
+	}
+	if req.Description != nil && utf8.RuneCountInString(*req.Description) > 5000 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "description must be at most 5000 characters")
+		return
+	}
+	t, err := SetTranslation(a, lang, title, req.Description)
+	if errors.Is(err, ErrOriginalLanguage) {
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, t)
+}
+
+func handlerDeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	a, ok := loadEditableAxis(w, r)
+	if !ok {
+		return
+	}
+	lang, ok := parseTranslationLanguage(w, r)
+	if !ok {
+		return
+	}
+	err := DeleteTranslation(a.ID, lang)
+	if errors.Is(err, ErrTranslationNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseTranslationLanguage(w http.ResponseWriter, r *http.Request) (string, bool) {
+	lang := chi.URLParam(r, "lang")
+	if !i18n.IsSupported(lang) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest,
+			"language must be one of "+strings.Join(i18n.Supported, ", "))
+		return "", false
+	}
+	return lang, true
+}
+
+// localize picks the translations for the languages of the request. A
+// failure only logs, the original text is still a valid answer.
+func localize(r *http.Request, axes []Axis) {
+	if err := Localize(axes, api.Languages(r)); err != nil {
+		logger.BAASError("Localize Error :", err.Error())
+	}
+}
@@ -0,0 +1,334 @@
+package axisbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A bundle is a zip archive with the .baasaxis extension:
+//
+//	manifest.json
+//	axes/1/axis.json
+//	axes/1/assets/cover.png
+//	axes/2/axis.json
+//
+// The manifest describes every axis and carries the SHA-256 of every other
+// file, so a bundle copied between instances or kept as a backup can be
+// checked before anything is imported. Files are read from the archive when
+// needed, one at a time, so large bundles are not held in memory.
+const (
+	Extension      = ".baasaxis"
+	Format         = "baasaxis"
+	CurrentVersion = 1
+	ManifestName   = "manifest.json"
+
+	MaxAxes      = 1000
+	MaxAssets    = 32
+	MaxFileSize  = 32 << 20
+	MaxTotalSize = 256 << 20
+	MaxAssetName = 100
+)
+
+var ErrInvalid = errors.New("invalid axis bundle")
+
+type Manifest struct {
+	Format        string    `json:"format"`
+	FormatVersion int       `json:"format_version"`
+	Generator     string    `json:"generator"`
+	Exporter      string    `json:"exporter"`
+	TimeCreate    time.Time `json:"time_create"`
+	Axes          []Entry   `json:"axes"`
+}
+
+// Entry is one axis, exported at a single revision.
+type Entry struct {
+	Title       string    `json:"title"`
+	StageID     string    `json:"stage_id"`
+	Difficulty  string    `json:"difficulty"`
+	Server      string    `json:"server"`
+	Description *string   `json:"description,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Visibility  string    `json:"visibility"`
+	Author      string    `json:"author"`
+	Revision    uint32    `json:"revision"`
+	GameVersion string    `json:"game_version"`
+	Changelog   string    `json:"changelog,omitempty"`
+	File        FileRef   `json:"file"`
+	Assets      []FileRef `json:"assets,omitempty"`
+}
+
+type FileRef struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Writer streams a bundle. The manifest is written last by Close, after
+// every file is known.
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+	total    int64
+}
+
+func NewWriter(w io.Writer, exporter string) *Writer {
+	return &Writer{
+		zw: zip.NewWriter(w),
+		manifest: Manifest{
+			Format:        Format,
+			FormatVersion: CurrentVersion,
+			Generator:     "GO_BAAS",
+			Exporter:      exporter,
+			TimeCreate:    time.Now().UTC(),
+			Axes:          []Entry{},
+		},
+	}
+}
+
+// AddAxis stores the axis file and its assets, keyed by asset name, under
+// a directory of their own and fills in the file references of e.
+func (bw *Writer) AddAxis(e Entry, file []byte, assets map[string][]byte) error {
+	if len(bw.manifest.Axes) >= MaxAxes {
+		return fmt.Errorf("%w : more than %d axes", ErrInvalid, MaxAxes)
+	}
+	dir := "axes/" + strconv.Itoa(len(bw.manifest.Axes)+1) + "/"
+	ref, err := bw.writeFile(dir+"axis.json", file)
+	if err != nil {
+		return err
+	}
+	e.File = ref
+	if len(assets) > MaxAssets {
+		return fmt.Errorf("%w : more than %d assets", ErrInvalid, MaxAssets)
+	}
+	names := make([]string, 0, len(assets))
+	for name := range assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	e.Assets = nil
+	for _, name := range names {
+		if !IsAssetName(name) {
+			return fmt.Errorf("%w : asset name %q", ErrInvalid, name)
+		}
+		ref, err := bw.writeFile(dir+"assets/"+name, assets[name])
+		if err != nil {
+			return err
+		}
+		e.Assets = append(e.Assets, ref)
+	}
+	bw.manifest.Axes = append(bw.manifest.Axes, e)
+	return nil
+}
+
+func (bw *Writer) Close() error {
+	data, err := json.MarshalIndent(bw.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := bw.writeFile(ManifestName, data); err != nil {
+		return err
+	}
+	return bw.zw.Close()
+}
+
+func (bw *Writer) writeFile(name string, data []byte) (FileRef, error) {
+	// Read would refuse the bundle, so it is refused here already.
+	if name != ManifestName {
+		if len(data) > MaxFileSize {
+			return FileRef{}, fmt.Errorf("%w : %q is too large", ErrInvalid, name)
+		}
+		if bw.total += int64(len(data)); bw.total > MaxTotalSize {
+			return FileRef{}, fmt.Errorf("%w : archive is too large", ErrInvalid)
+		}
+	}
+	f, err := bw.zw.Create(name)
+	if err != nil {
+		return FileRef{}, err
+	}
+	if _, err := f.Write(data); err != nil {
+		return FileRef{}, err
+	}
+	return FileRef{Path: name, SHA256: checksum(data), Size: int64(len(data))}, nil
+}
+
+// Bundle is a read and verified bundle. It reads from the archive it was
+// opened on, which must stay open while the bundle is used.
+type Bundle struct {
+	Manifest Manifest
+	files    map[string]*zip.File
+	// unpacked counts the bytes decompressed so far. Read checks every
+	// file once and File reads it once more, so twice MaxTotalSize is all
+	// a bundle can ever need.
+	unpacked int64
+}
+
+// Read parses a bundle and checks the manifest against the archive: every
+// referenced file must exist with the recorded size and checksum, and be
+// referenced only once. Files are hashed as they are read, none but the
+// manifest is kept.
+func Read(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalid, err)
+	}
+	b := &Bundle{files: map[string]*zip.File{}}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !isSafePath(f.Name) {
+			return nil, fmt.Errorf("%w : unsafe path %q", ErrInvalid, f.Name)
+		}
+		b.files[f.Name] = f
+	}
+
+	mf, ok := b.files[ManifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w : missing %s", ErrInvalid, ManifestName)
+	}
+	manifest, err := readZipFile(mf)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(manifest, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("%w : %s : %v", ErrInvalid, ManifestName, err)
+	}
+	m := &b.Manifest
+	if m.Format != Format {
+		return nil, fmt.Errorf("%w : unknown format %q", ErrInvalid, m.Format)
+	}
+	if m.FormatVersion < 1 || m.FormatVersion > CurrentVersion {
+		return nil, fmt.Errorf("%w : unsupported format_version %d", ErrInvalid, m.FormatVersion)
+	}
+	if len(m.Axes) > MaxAxes {
+		return nil, fmt.Errorf("%w : more than %d axes", ErrInvalid, MaxAxes)
+	}
+	// Sizes are checked before anything is decompressed: verify never reads
+	// past the recorded size, so their sum bounds the work below.
+	seen := map[string]bool{}
+	var total int64
+	for i, e := range m.Axes {
+		if len(e.Assets) > MaxAssets {
+			return nil, fmt.Errorf("%w : axes[%d] : more than %d assets", ErrInvalid, i, MaxAssets)
+		}
+		names := map[string]bool{}
+		for _, ref := range e.Assets {
+			name := path.Base(ref.Path)
+			if !IsAssetName(name) || names[name] {
+				return nil, fmt.Errorf("%w : axes[%d] : asset name %q", ErrInvalid, i, name)
+			}
+			names[name] = true
+		}
+		for _, ref := range append([]FileRef{e.File}, e.Assets...) {
+			if seen[ref.Path] {
+				return nil, fmt.Errorf("%w : axes[%d] : %q is referenced twice", ErrInvalid, i, ref.Path)
+			}
+			seen[ref.Path] = true
+			if ref.Size < 0 || ref.Size > MaxFileSize {
+				return nil, fmt.Errorf("%w : axes[%d] : %q is too large", ErrInvalid, i, ref.Path)
+			}
+			if total += ref.Size; total > MaxTotalSize {
+				return nil, fmt.Errorf("%w : archive is too large", ErrInvalid)
+			}
+		}
+	}
+	for i, e := range m.Axes {
+		for _, ref := range append([]FileRef{e.File}, e.Assets...) {
+			if err := b.verify(ref, io.Discard); err != nil {
+				return nil, fmt.Errorf("%w : axes[%d] : %v", ErrInvalid, i, err)
+			}
+		}
+	}
+	return b, nil
+}
+
+// File returns the content of a file referenced by the manifest, checked
+// again against its checksum.
+func (b *Bundle) File(ref FileRef) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := b.verify(ref, &buf); err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalid, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// verify copies the file of ref to w while checking its size and checksum.
+func (b *Bundle) verify(ref FileRef, w io.Writer) error {
+	f, ok := b.files[ref.Path]
+	if !ok {
+		return fmt.Errorf("missing file %q", ref.Path)
+	}
+	if ref.Size > MaxFileSize || f.UncompressedSize64 > MaxFileSize {
+		return fmt.Errorf("%q is too large", ref.Path)
+	}
+	if b.unpacked+ref.Size > 2*MaxTotalSize {
+		return errors.New("archive is too large")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	// The header size can lie, so the limit is enforced while reading too.
+	n, err := io.Copy(io.MultiWriter(w, h), io.LimitReader(rc, ref.Size+1))
+	b.unpacked += n
+	if err != nil {
+		return fmt.Errorf("%q : %v", ref.Path, err)
+	}
+	if n != ref.Size || hex.EncodeToString(h.Sum(nil)) != ref.SHA256 {
+		return fmt.Errorf("checksum mismatch for %q", ref.Path)
+	}
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > MaxFileSize {
+		return nil, fmt.Errorf("%w : %q is too large", ErrInvalid, f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalid, err)
+	}
+	defer rc.Close()
+	// The header size can lie, so the limit is enforced while reading too.
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(rc, MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w : %q : %v", ErrInvalid, f.Name, err)
+	}
+	if n > MaxFileSize {
+		return nil, fmt.Errorf("%w : %q is too large", ErrInvalid, f.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+func isSafePath(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	return path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+// IsAssetName reports whether name can name an asset: a single path
+// element, so assets always stay in the directory of their axis.
+func IsAssetName(name string) bool {
+	return name != "" && name != "." && name != ".." && len(name) <= MaxAssetName &&
+		!strings.ContainsAny(name, "/\\")
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,138 @@
+package axisbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func writeBundle(t *testing.T, file []byte, assets map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	bw := NewWriter(&buf, "sensei")
+	if err := bw.AddAxis(Entry{Title: "Binah Insane"}, file, assets); err != nil {
+		t.Fatal(err)
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// rewriteManifest rebuilds a bundle with the manifest changed by edit.
+func rewriteManifest(t *testing.T, data []byte, edit func(*Manifest)) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		content, err := readZipFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == ManifestName {
+			var m Manifest
+			if err := json.Unmarshal(content, &m); err != nil {
+				t.Fatal(err)
+			}
+			edit(&m)
+			if content, err = json.Marshal(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTripWithAssets(t *testing.T) {
+	file := []byte(`{"format_version":2}`)
+	data := writeBundle(t, file, map[string][]byte{"team.png": []byte("png"), "map.jpg": []byte("jpg")})
+	b, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := b.Manifest.Axes[0]
+	got, err := b.File(e.File)
+	if err != nil || !bytes.Equal(got, file) {
+		t.Fatalf("File = %q, %v", got, err)
+	}
+	if len(e.Assets) != 2 || e.Assets[0].Path != "axes/1/assets/map.jpg" {
+		t.Fatalf("Assets = %+v", e.Assets)
+	}
+	got, err = b.File(e.Assets[1])
+	if err != nil || string(got) != "png" {
+		t.Fatalf("asset = %q, %v", got, err)
+	}
+}
+
+func TestAddAxisRejectsAssetPaths(t *testing.T) {
+	bw := NewWriter(&bytes.Buffer{}, "sensei")
+	err := bw.AddAxis(Entry{}, []byte("{}"), map[string][]byte{"../escape.png": nil})
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestReadRejectsDuplicateReferences(t *testing.T) {
+	data := writeBundle(t, []byte("{}"), nil)
+	data = rewriteManifest(t, data, func(m *Manifest) {
+		m.Axes = append(m.Axes, m.Axes[0])
+	})
+	_, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err == nil || !strings.Contains(err.Error(), "referenced twice") {
+		t.Fatalf("err = %v, want a duplicate reference", err)
+	}
+}
+
+func TestReadRejectsChecksumMismatch(t *testing.T) {
+	data := writeBundle(t, []byte("{}"), map[string][]byte{"team.png": []byte("png")})
+	data = rewriteManifest(t, data, func(m *Manifest) {
+		m.Axes[0].Assets[0].SHA256 = m.Axes[0].File.SHA256
+	})
+	_, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("err = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestReadRejectsOversizedTotal(t *testing.T) {
+	data := writeBundle(t, []byte("{}"), nil)
+	data = rewriteManifest(t, data, func(m *Manifest) {
+		for i := 0; i < MaxTotalSize/MaxFileSize; i++ {
+			e := m.Axes[0]
+			e.File.Path = "axes/" + strings.Repeat("x", i+1) + "/axis.json"
+			e.File.Size = MaxFileSize
+			m.Axes = append(m.Axes, e)
+		}
+	})
+	_, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err == nil || !strings.Contains(err.Error(), "archive is too large") {
+		t.Fatalf("err = %v, want archive is too large", err)
+	}
+}
+
+func TestFileStopsAtTotalBudget(t *testing.T) {
+	data := writeBundle(t, []byte("{}"), nil)
+	b, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.unpacked = 2 * MaxTotalSize
+	if _, err := b.File(b.Manifest.Axes[0].File); err == nil {
+		t.Fatal("File read past the total budget")
+	}
+}
@@ -0,0 +1,222 @@
+package axisfile
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+const CurrentVersion = 2
+
+var SupportedVersions = []int{1, 2}
+
+var schemas = map[int]map[string]interface{}{}
+
+func init() {
+	for _, version := range SupportedVersions {
+		data, err := Schema(version)
+		if err != nil {
+			panic(err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			panic(fmt.Sprintf("axisfile: schema v%d : %v", version, err))
+		}
+		if err := compilePatterns(schema); err != nil {
+			panic(fmt.Sprintf("axisfile: schema v%d : %v", version, err))
+		}
+		schemas[version] = schema
+	}
+}
+
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + " " + e.Message
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, each := range e {
+		msgs[i] = each.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Requirements is the minimum growth of a team member; zero means any.
+// Files of format version 1 cannot carry it.
+type Requirements struct {
+	Star          int `json:"star,omitempty" db:"min_star"`
+	Level         int `json:"level,omitempty" db:"min_level"`
+	UELevel       int `json:"ue_level,omitempty" db:"min_ue_level"`
+	SkillEX       int `json:"skill_ex,omitempty" db:"min_skill_ex"`
+	SkillBasic    int `json:"skill_basic,omitempty" db:"min_skill_basic"`
+	SkillEnhanced int `json:"skill_enhanced,omitempty" db:"min_skill_enhanced"`
+	SkillSub      int `json:"skill_sub,omitempty" db:"min_skill_sub"`
+}
+
+type Member struct {
+	StudentID    uint32        `json:"student_id"`
+	Slot         int           `json:"slot"`
+	Requirements *Requirements `json:"requirements,omitempty"`
+}
+
+type Team struct {
+	Strikers []Member `json:"strikers"`
+	Specials []Member `json:"specials,omitempty"`
+}
+
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Action struct {
+	Time      float64   `json:"time"`
+	StudentID uint32    `json:"student_id"`
+	Cost      float64   `json:"cost"`
+	Target    *Position `json:"target,omitempty"`
+	Note      string    `json:"note,omitempty"`
+}
+
+type File struct {
+	FormatVersion int      `json:"format_version"`
+	Team          Team     `json:"team"`
+	Actions       []Action `json:"actions"`
+}
+
+// Schema returns the raw JSON Schema document of a format version.
+func Schema(version int) ([]byte, error) {
+	return schemaFS.ReadFile("schema/v" + strconv.Itoa(version) + ".json")
+}
+
+// Parse validates data against the schema selected by its format_version
+// and the cross-field rules the schema cannot express. On failure every
+// problem found is reported with the path of the offending value.
+func Parse(data []byte) (*File, ValidationErrors) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, ValidationErrors{{Path: "(root)", Message: "is not valid JSON: " + err.Error()}}
+	}
+	if decoder.More() {
+		return nil, ValidationErrors{{Path: "(root)", Message: "has trailing data after the JSON document"}}
+	}
+
+	schema, errs := selectSchema(doc)
+	if errs != nil {
+		return nil, errs
+	}
+	v := &validator{root: schema}
+	v.validate(doc, schema, "")
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+
+	// The validated document is decoded rather than data: the schema takes
+	// 10.0 and 1e3 as integers, encoding/json only takes them as floats.
+	normalized, err := json.Marshal(normalizeNumbers(doc))
+	if err != nil {
+		return nil, ValidationErrors{{Path: "(root)", Message: err.Error()}}
+	}
+	var f File
+	if err := json.Unmarshal(normalized, &f); err != nil {
+		return nil, ValidationErrors{{Path: "(root)", Message: err.Error()}}
+	}
+	if errs := f.check(); len(errs) > 0 {
+		return nil, errs
+	}
+	return &f, nil
+}
+
+// normalizeNumbers writes every integral number of a decoded document
+// without fraction or exponent.
+func normalizeNumbers(node interface{}) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for k, v := range node {
+			node[k] = normalizeNumbers(v)
+		}
+	case []interface{}:
+		for i, v := range node {
+			node[i] = normalizeNumbers(v)
+		}
+	case json.Number:
+		if _, err := node.Int64(); err == nil {
+			return node
+		}
+		f, err := node.Float64()
+		if err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return json.Number(strconv.FormatInt(int64(f), 10))
+		}
+	}
+	return node
+}
+
+func selectSchema(doc interface{}) (map[string]interface{}, ValidationErrors) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Path: "(root)", Message: "must be of type object"}}
+	}
+	raw, ok := obj["format_version"].(json.Number)
+	if !ok {
+		return nil, ValidationErrors{{Path: "format_version", Message: "is required"}}
+	}
+	version, err := strconv.Atoi(raw.String())
+	if schema, found := schemas[version]; err == nil && found {
+		return schema, nil
+	}
+	return nil, ValidationErrors{{Path: "format_version", Message: "must be one of " + formatJson(SupportedVersions)}}
+}
+
+// check enforces rules spanning several values.
+func (f *File) check() ValidationErrors {
+	var errs ValidationErrors
+	fail := func(path string, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seenStudent := map[uint32]string{}
+	checkMembers := func(group string, members []Member) {
+		seenSlot := map[int]int{}
+		for i, m := range members {
+			path := fmt.Sprintf("team.%s[%d]", group, i)
+			if prev, ok := seenStudent[m.StudentID]; ok {
+				fail(path+".student_id", "duplicates %s.student_id", prev)
+			} else {
+				seenStudent[m.StudentID] = path
+			}
+			if prev, ok := seenSlot[m.Slot]; ok {
+				fail(path+".slot", "duplicates team.%s[%d].slot", group, prev)
+			} else {
+				seenSlot[m.Slot] = i
+			}
+		}
+	}
+	checkMembers("strikers", f.Team.Strikers)
+	checkMembers("specials", f.Team.Specials)
+
+	for i, a := range f.Actions {
+		path := fmt.Sprintf("actions[%d]", i)
+		if _, ok := seenStudent[a.StudentID]; !ok {
+			fail(path+".student_id", "%d is not in the team", a.StudentID)
+		}
+		if i > 0 && a.Time < f.Actions[i-1].Time {
+			fail(path+".time", "must be >= actions[%d].time", i-1)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,91 @@
+package axisfile
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseRequirementsNeedVersion2(t *testing.T) {
+	doc := `{"format_version": %d, "team": {"strikers": [{"student_id": 10, "slot": 1, "requirements": {"star": 3}}]}, "actions": []}`
+
+	_, errs := Parse([]byte(fmt.Sprintf(doc, 1)))
+	if len(errs) != 1 || errs[0].Path != "team.strikers[0].requirements" {
+		t.Errorf("v1 with requirements : errs = %v", errs)
+	}
+	f, errs := Parse([]byte(fmt.Sprintf(doc, 2)))
+	if len(errs) > 0 {
+		t.Fatalf("v2 with requirements : errs = %v", errs)
+	}
+	if r := f.Team.Strikers[0].Requirements; r == nil || r.Star != 3 {
+		t.Errorf("requirements = %+v", r)
+	}
+}
+
+func TestParseVersion1(t *testing.T) {
+	doc := `{"format_version": 1, "team": {"strikers": [{"student_id": 10, "slot": 1}]}, "actions": [{"time": 1, "student_id": 10, "cost": 3}]}`
+	if _, errs := Parse([]byte(doc)); len(errs) > 0 {
+		t.Errorf("errs = %v", errs)
+	}
+	if _, errs := Parse([]byte(`{"format_version": 3}`)); len(errs) != 1 || errs[0].Path != "format_version" {
+		t.Errorf("unknown version : errs = %v", errs)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code": map[string]interface{}{"type": "string", "pattern": "^[A-Z]{2}$"},
+		},
+	}
+	if err := compilePatterns(schema); err != nil {
+		t.Fatal(err)
+	}
+	v := &validator{root: schema}
+	v.validate(map[string]interface{}{"code": "AB"}, schema, "")
+	v.validate(map[string]interface{}{"code": "abc"}, schema, "")
+	want := ValidationErrors{{Path: "code", Message: "must match ^[A-Z]{2}$"}}
+	if !reflect.DeepEqual(v.errs, want) {
+		t.Errorf("errs = %v, want %v", v.errs, want)
+	}
+	if err := compilePatterns(map[string]interface{}{"pattern": "("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestParseIntegralNumbers(t *testing.T) {
+	doc := `{"format_version": 1, "team": {"strikers": [{"student_id": 10.0, "slot": 1e0}]}, "actions": [{"time": 1, "student_id": 1e1, "cost": 3}]}`
+	f, errs := Parse([]byte(doc))
+	if len(errs) > 0 {
+		t.Fatalf("errs = %v", errs)
+	}
+	if f.Team.Strikers[0].StudentID != 10 || f.Actions[0].StudentID != 10 {
+		t.Errorf("file = %+v", f)
+	}
+
+	doc = `{"format_version": 1, "team": {"strikers": [{"student_id": 1e10, "slot": 1}]}, "actions": []}`
+	_, errs = Parse([]byte(doc))
+	if len(errs) != 1 || errs[0].Error() != "team.strikers[0].student_id must be <= 4294967295" {
+		t.Errorf("student_id out of range : errs = %v", errs)
+	}
+}
+
+func TestParseReportsRangePaths(t *testing.T) {
+	actions := ""
+	for i := 0; i < 13; i++ {
+		cost := 1
+		if i == 12 {
+			cost = 11
+		}
+		if i > 0 {
+			actions += ", "
+		}
+		actions += fmt.Sprintf(`{"time": %d, "student_id": 10, "cost": %d}`, i, cost)
+	}
+	doc := `{"format_version": 1, "team": {"strikers": [{"student_id": 10, "slot": 1}]}, "actions": [` + actions + `]}`
+	_, errs := Parse([]byte(doc))
+	if len(errs) != 1 || errs[0].Error() != "actions[12].cost must be <= 10" {
+		t.Errorf("errs = %v, want actions[12].cost must be <= 10", errs)
+	}
+}
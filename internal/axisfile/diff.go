@@ -0,0 +1,220 @@
+package axisfile
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	OpInserted = "inserted"
+	OpRemoved  = "removed"
+	// OpShifted marks an action whose only change is its time.
+	OpShifted = "shifted"
+	OpChanged = "changed"
+)
+
+type MemberChange struct {
+	Group     string `json:"group"`
+	StudentID uint32 `json:"student_id"`
+	FromSlot  int    `json:"from_slot,omitempty"`
+	ToSlot    int    `json:"to_slot,omitempty"`
+}
+
+type RequirementChange struct {
+	Group     string        `json:"group"`
+	StudentID uint32        `json:"student_id"`
+	From      *Requirements `json:"from"`
+	To        *Requirements `json:"to"`
+}
+
+type TeamDiff struct {
+	Added        []MemberChange      `json:"added"`
+	Removed      []MemberChange      `json:"removed"`
+	Moved        []MemberChange      `json:"moved"`
+	Requirements []RequirementChange `json:"requirements"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type ActionDiff struct {
+	Op        string        `json:"op"`
+	FromIndex *int          `json:"from_index,omitempty"`
+	ToIndex   *int          `json:"to_index,omitempty"`
+	StudentID uint32        `json:"student_id"`
+	Action    *Action       `json:"action,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+type FileDiff struct {
+	Team    TeamDiff     `json:"team"`
+	Actions []ActionDiff `json:"actions"`
+	Summary []string     `json:"summary"`
+}
+
+func (d *FileDiff) Empty() bool {
+	return len(d.Team.Added) == 0 && len(d.Team.Removed) == 0 && len(d.Team.Moved) == 0 &&
+		len(d.Team.Requirements) == 0 && len(d.Actions) == 0
+}
+
+// Diff compares two axis files semantically. Actions are aligned by the
+// longest common subsequence of their casting students, so inserting one
+// skill does not show every following action as changed.
+func Diff(from *File, to *File) *FileDiff {
+	d := &FileDiff{
+		Team: TeamDiff{
+			Added:        []MemberChange{},
+			Removed:      []MemberChange{},
+			Moved:        []MemberChange{},
+			Requirements: []RequirementChange{},
+		},
+		Actions: []ActionDiff{},
+		Summary: []string{},
+	}
+	d.diffTeam("strikers", from.Team.Strikers, to.Team.Strikers)
+	d.diffTeam("specials", from.Team.Specials, to.Team.Specials)
+	d.diffActions(from.Actions, to.Actions)
+	return d
+}
+
+func (d *FileDiff) diffTeam(group string, from []Member, to []Member) {
+	fromSlots, toSlots := slotsOf(from), slotsOf(to)
+	fromReqs := requirementsOf(from)
+
+	for _, m := range from {
+		if _, ok := toSlots[m.StudentID]; !ok {
+			d.Team.Removed = append(d.Team.Removed, MemberChange{Group: group, StudentID: m.StudentID, FromSlot: m.Slot})
+			d.summarize("Removed %s %d from slot %d", singular(group), m.StudentID, m.Slot)
+		}
+	}
+	for _, m := range to {
+		slot, ok := fromSlots[m.StudentID]
+		if !ok {
+			d.Team.Added = append(d.Team.Added, MemberChange{Group: group, StudentID: m.StudentID, ToSlot: m.Slot})
+			d.summarize("Added %s %d in slot %d", singular(group), m.StudentID, m.Slot)
+		} else if slot != m.Slot {
+			d.Team.Moved = append(d.Team.Moved, MemberChange{Group: group, StudentID: m.StudentID, FromSlot: slot, ToSlot: m.Slot})
+			d.summarize("Moved %s %d from slot %d to slot %d", singular(group), m.StudentID, slot, m.Slot)
+		}
+		if ok && !sameRequirements(fromReqs[m.StudentID], m.Requirements) {
+			d.Team.Requirements = append(d.Team.Requirements, RequirementChange{
+				Group: group, StudentID: m.StudentID, From: fromReqs[m.StudentID], To: m.Requirements,
+			})
+			d.summarize("Changed %s %d requirements", singular(group), m.StudentID)
+		}
+	}
+}
+
+func (d *FileDiff) diffActions(from []Action, to []Action) {
+	// lcs[i][j] is the LCS length of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i].StudentID == to[j].StudentID {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i].StudentID == to[j].StudentID:
+			d.compareAction(i, j, &from[i], &to[j])
+			i++
+			j++
+		case j < len(to) && (i == len(from) || lcs[i][j+1] >= lcs[i+1][j]):
+			d.Actions = append(d.Actions, ActionDiff{Op: OpInserted, ToIndex: intPtr(j), StudentID: to[j].StudentID, Action: &to[j]})
+			d.summarize("Inserted actions[%d]: student %d at %s, cost %s", j, to[j].StudentID, formatSeconds(to[j].Time), formatFloat(to[j].Cost))
+			j++
+		default:
+			d.Actions = append(d.Actions, ActionDiff{Op: OpRemoved, FromIndex: intPtr(i), StudentID: from[i].StudentID, Action: &from[i]})
+			d.summarize("Removed actions[%d]: student %d at %s", i, from[i].StudentID, formatSeconds(from[i].Time))
+			i++
+		}
+	}
+}
+
+func (d *FileDiff) compareAction(i int, j int, from *Action, to *Action) {
+	var changes []FieldChange
+	if from.Time != to.Time {
+		changes = append(changes, FieldChange{Field: "time", From: from.Time, To: to.Time})
+	}
+	if from.Cost != to.Cost {
+		changes = append(changes, FieldChange{Field: "cost", From: from.Cost, To: to.Cost})
+	}
+	if !samePosition(from.Target, to.Target) {
+		changes = append(changes, FieldChange{Field: "target", From: from.Target, To: to.Target})
+	}
+	if from.Note != to.Note {
+		changes = append(changes, FieldChange{Field: "note", From: from.Note, To: to.Note})
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	op := OpChanged
+	if len(changes) == 1 && changes[0].Field == "time" {
+		op = OpShifted
+		delta := to.Time - from.Time
+		sign := "+"
+		if delta < 0 {
+			sign = "-"
+			delta = -delta
+		}
+		d.summarize("Shifted actions[%d] (student %d) by %s%s to %s", j, to.StudentID, sign, formatSeconds(delta), formatSeconds(to.Time))
+	} else {
+		for _, c := range changes {
+			d.summarize("Changed actions[%d] (student %d) %s: %s -> %s", j, to.StudentID, c.Field, formatValue(c.From), formatValue(c.To))
+		}
+	}
+	d.Actions = append(d.Actions, ActionDiff{Op: op, FromIndex: intPtr(i), ToIndex: intPtr(j), StudentID: to.StudentID, Changes: changes})
+}
+
+func (d *FileDiff) summarize(format string, args ...interface{}) {
+	d.Summary = append(d.Summary, fmt.Sprintf(format, args...))
+}
+
+func samePosition(a *Position, b *Position) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func singular(group string) string {
+	return group[:len(group)-1]
+}
+
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', -1, 64) + "s"
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return formatFloat(v)
+	case *Position:
+		if v == nil {
+			return "none"
+		}
+		return "(" + formatFloat(v.X) + ", " + formatFloat(v.Y) + ")"
+	case string:
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,110 @@
+package axisfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testFile(strikers []Member, actions ...Action) *File {
+	return &File{FormatVersion: 1, Team: Team{Strikers: strikers}, Actions: append([]Action{}, actions...)}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	f := testFile([]Member{{StudentID: 10, Slot: 1}}, Action{Time: 1, StudentID: 10, Cost: 3})
+	d := Diff(f, f)
+	if !d.Empty() || len(d.Summary) != 0 {
+		t.Errorf("expected an empty diff, got %+v", d)
+	}
+}
+
+func TestDiffTeam(t *testing.T) {
+	from := testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}})
+	to := testFile([]Member{{StudentID: 10, Slot: 3, Requirements: &Requirements{Star: 5}}, {StudentID: 30, Slot: 2}})
+
+	d := Diff(from, to)
+	want := TeamDiff{
+		Added:   []MemberChange{{Group: "strikers", StudentID: 30, ToSlot: 2}},
+		Removed: []MemberChange{{Group: "strikers", StudentID: 20, FromSlot: 2}},
+		Moved:   []MemberChange{{Group: "strikers", StudentID: 10, FromSlot: 1, ToSlot: 3}},
+		Requirements: []RequirementChange{
+			{Group: "strikers", StudentID: 10, From: nil, To: &Requirements{Star: 5}},
+		},
+	}
+	if !reflect.DeepEqual(d.Team, want) {
+		t.Errorf("team = %+v, want %+v", d.Team, want)
+	}
+	wantSummary := []string{
+		"Removed striker 20 from slot 2",
+		"Moved striker 10 from slot 1 to slot 3",
+		"Changed striker 10 requirements",
+		"Added striker 30 in slot 2",
+	}
+	if !reflect.DeepEqual(d.Summary, wantSummary) {
+		t.Errorf("summary = %q, want %q", d.Summary, wantSummary)
+	}
+}
+
+// TestDiffRequirementsEmpty checks that dropping an empty requirements
+// object is not reported.
+func TestDiffRequirementsEmpty(t *testing.T) {
+	from := testFile([]Member{{StudentID: 10, Slot: 1, Requirements: &Requirements{}}})
+	to := testFile([]Member{{StudentID: 10, Slot: 1}})
+	if d := Diff(from, to); !d.Empty() {
+		t.Errorf("expected an empty diff, got %+v", d)
+	}
+}
+
+func TestDiffActions(t *testing.T) {
+	team := []Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}, {StudentID: 30, Slot: 3}}
+	from := testFile(team,
+		Action{Time: 1, StudentID: 10, Cost: 3},
+		Action{Time: 4, StudentID: 20, Cost: 5},
+		Action{Time: 8, StudentID: 30, Cost: 2},
+	)
+	to := testFile(team,
+		Action{Time: 1, StudentID: 10, Cost: 3},
+		Action{Time: 2, StudentID: 30, Cost: 4},
+		Action{Time: 4.5, StudentID: 20, Cost: 5},
+		Action{Time: 8, StudentID: 30, Cost: 2, Note: "burst"},
+	)
+
+	d := Diff(from, to)
+	var ops []string
+	for _, a := range d.Actions {
+		ops = append(ops, a.Op)
+	}
+	if want := []string{OpInserted, OpShifted, OpChanged}; !reflect.DeepEqual(ops, want) {
+		t.Fatalf("ops = %v, want %v", ops, want)
+	}
+	if a := d.Actions[0]; *a.ToIndex != 1 || a.FromIndex != nil || a.StudentID != 30 {
+		t.Errorf("inserted = %+v", a)
+	}
+	if a := d.Actions[1]; *a.FromIndex != 1 || *a.ToIndex != 2 {
+		t.Errorf("shifted = %+v", a)
+	}
+	if want := []FieldChange{{Field: "note", From: "", To: "burst"}}; !reflect.DeepEqual(d.Actions[2].Changes, want) {
+		t.Errorf("changes = %+v, want %+v", d.Actions[2].Changes, want)
+	}
+	wantSummary := []string{
+		"Inserted actions[1]: student 30 at 2s, cost 4",
+		"Shifted actions[2] (student 20) by +0.5s to 4.5s",
+		`Changed actions[3] (student 30) note: "" -> "burst"`,
+	}
+	if !reflect.DeepEqual(d.Summary, wantSummary) {
+		t.Errorf("summary = %q, want %q", d.Summary, wantSummary)
+	}
+}
+
+func TestDiffRemovedAction(t *testing.T) {
+	team := []Member{{StudentID: 10, Slot: 1}}
+	from := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3}, Action{Time: 5, StudentID: 10, Cost: 3})
+	to := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3})
+
+	d := Diff(from, to)
+	if len(d.Actions) != 1 || d.Actions[0].Op != OpRemoved || *d.Actions[0].FromIndex != 1 {
+		t.Fatalf("actions = %+v", d.Actions)
+	}
+	if want := []string{"Removed actions[1]: student 10 at 5s"}; !reflect.DeepEqual(d.Summary, want) {
+		t.Errorf("summary = %q, want %q", d.Summary, want)
+	}
+}
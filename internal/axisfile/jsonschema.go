@@ -0,0 +1,269 @@
+package axisfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxErrors bounds the report for pathological inputs.
+const maxErrors = 100
+
+// patterns holds the compiled pattern keywords of every loaded schema.
+var patterns = map[string]*regexp.Regexp{}
+
+// compilePatterns compiles the pattern keywords found anywhere in node, so
+// validating does not compile them again for every value.
+func compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			if pattern, ok := value.(string); ok && key == "pattern" {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return err
+				}
+				patterns[pattern] = re
+				continue
+			}
+			if err := compilePatterns(value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, value := range n {
+			if err := compilePatterns(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validator implements the subset of JSON Schema 2020-12 used by the axis
+// schemas: $ref into $defs, type, const, enum, properties, required,
+// additionalProperties, items, minItems, maxItems, minimum, maximum,
+// minLength, maxLength and pattern.
+type validator struct {
+	root map[string]interface{}
+	errs ValidationErrors
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	if len(v.errs) >= maxErrors {
+		return
+	}
+	if path == "" {
+		path = "(root)"
+	}
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(node interface{}, schema map[string]interface{}, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		v.validate(node, v.resolve(ref), path)
+		return
+	}
+
+	if c, ok := schema["const"]; ok && !jsonEqual(node, c) {
+		v.fail(path, "must be %s", formatJson(c))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(node, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "must be one of %s", formatJson(enum))
+			return
+		}
+	}
+	if t, ok := schema["type"]; ok && !matchesType(node, t) {
+		v.fail(path, "must be of type %s", formatType(t))
+		return
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		v.validateObject(n, schema, path)
+	case []interface{}:
+		v.validateArray(n, schema, path)
+	case string:
+		v.validateString(n, schema, path)
+	case json.Number:
+		v.validateNumber(n, schema, path)
+	}
+}
+
+func (v *validator) validateObject(node map[string]interface{}, schema map[string]interface{}, path string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			key := r.(string)
+			if _, ok := node[key]; !ok {
+				v.fail(joinKey(path, key), "is required")
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if sub, ok := properties[key].(map[string]interface{}); ok {
+			v.validate(node[key], sub, joinKey(path, key))
+			continue
+		}
+		if allowed, ok := schema["additionalProperties"].(bool); ok && !allowed {
+			v.fail(joinKey(path, key), "is not allowed")
+		}
+	}
+}
+
+func (v *validator) validateArray(node []interface{}, schema map[string]interface{}, path string) {
+	if min, ok := schemaInt(schema, "minItems"); ok && len(node) < min {
+		v.fail(path, "must have at least %d items", min)
+	}
+	if max, ok := schemaInt(schema, "maxItems"); ok && len(node) > max {
+		v.fail(path, "must have at most %d items", max)
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range node {
+			v.validate(item, items, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (v *validator) validateString(node string, schema map[string]interface{}, path string) {
+	length := utf8.RuneCountInString(node)
+	if min, ok := schemaInt(schema, "minLength"); ok && length < min {
+		v.fail(path, "must be at least %d characters", min)
+	}
+	if max, ok := schemaInt(schema, "maxLength"); ok && length > max {
+		v.fail(path, "must be at most %d characters", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !patterns[pattern].MatchString(node) {
+		v.fail(path, "must match %s", pattern)
+	}
+}
+
+func (v *validator) validateNumber(node json.Number, schema map[string]interface{}, path string) {
+	f, err := node.Float64()
+	if err != nil {
+		v.fail(path, "is not a valid number")
+		return
+	}
+	if min, ok := schema["minimum"].(float64); ok && f < min {
+		v.fail(path, "must be >= %s", formatFloat(min))
+	}
+	if max, ok := schema["maximum"].(float64); ok && f > max {
+		v.fail(path, "must be <= %s", formatFloat(max))
+	}
+}
+
+func (v *validator) resolve(ref string) map[string]interface{} {
+	const prefix = "#/$defs/"
+	defs, _ := v.root["$defs"].(map[string]interface{})
+	if !strings.HasPrefix(ref, prefix) {
+		panic("axisfile: unsupported $ref " + ref)
+	}
+	schema, ok := defs[strings.TrimPrefix(ref, prefix)].(map[string]interface{})
+	if !ok {
+		panic("axisfile: unresolved $ref " + ref)
+	}
+	return schema
+}
+
+func matchesType(node interface{}, t interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesSingleType(node, t)
+	case []interface{}:
+		for _, each := range t {
+			if s, ok := each.(string); ok && matchesSingleType(node, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesSingleType(node interface{}, t string) bool {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case nil:
+		return t == "null"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t == "integer" {
+			f, err := n.Float64()
+			return err == nil && f == math.Trunc(f) && !math.IsInf(f, 0)
+		}
+	}
+	return false
+}
+
+// jsonEqual compares a decoded document value (numbers as json.Number) with
+// a schema value (numbers as float64).
+func jsonEqual(a interface{}, b interface{}) bool {
+	if n, ok := a.(json.Number); ok {
+		f, err := n.Float64()
+		bf, isFloat := b.(float64)
+		return err == nil && isFloat && f == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func schemaInt(schema map[string]interface{}, key string) (int, bool) {
+	f, ok := schema[key].(float64)
+	return int(f), ok
+}
+
+func joinKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatJson(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func formatType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, each := range list {
+			names[i] = fmt.Sprint(each)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
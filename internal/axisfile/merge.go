@@ -0,0 +1,152 @@
+package axisfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type Conflict struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Merge performs a three-way merge of theirs into ours, both derived from
+// base. Team members merge individually; the action list is treated as one
+// unit, since interleaving two independently tuned skill rotations is never
+// what either author meant. The merged file is validated again, so a clean
+// merge that breaks the schema or cross-field rules is reported as conflicts
+// too.
+func Merge(base *File, ours *File, theirs *File) (*File, []Conflict) {
+	var conflicts []Conflict
+	merged := &File{FormatVersion: ours.FormatVersion}
+	if theirs.FormatVersion > merged.FormatVersion {
+		merged.FormatVersion = theirs.FormatVersion
+	}
+
+	var c []Conflict
+	merged.Team.Strikers, c = mergeMembers("strikers", base.Team.Strikers, ours.Team.Strikers, theirs.Team.Strikers)
+	conflicts = append(conflicts, c...)
+	merged.Team.Specials, c = mergeMembers("specials", base.Team.Specials, ours.Team.Specials, theirs.Team.Specials)
+	conflicts = append(conflicts, c...)
+
+	switch {
+	case reflect.DeepEqual(ours.Actions, base.Actions):
+		merged.Actions = theirs.Actions
+	case reflect.DeepEqual(theirs.Actions, base.Actions), reflect.DeepEqual(ours.Actions, theirs.Actions):
+		merged.Actions = ours.Actions
+	default:
+		merged.Actions = ours.Actions
+		conflicts = append(conflicts, Conflict{Path: "actions", Message: "changed on both sides"})
+	}
+
+	if len(conflicts) > 0 {
+		return nil, conflicts
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, []Conflict{{Path: "(root)", Message: err.Error()}}
+	}
+	merged, errs := Parse(data)
+	for _, e := range errs {
+		conflicts = append(conflicts, Conflict{Path: e.Path, Message: e.Message + " after merge"})
+	}
+	return merged, conflicts
+}
+
+func mergeMembers(group string, base []Member, ours []Member, theirs []Member) ([]Member, []Conflict) {
+	baseSlots, ourSlots, theirSlots := slotsOf(base), slotsOf(ours), slotsOf(theirs)
+	baseReqs, ourReqs, theirReqs := requirementsOf(base), requirementsOf(ours), requirementsOf(theirs)
+
+	// Keep our order, then append members only they added.
+	var order []uint32
+	seen := map[uint32]bool{}
+	for _, list := range [][]Member{ours, theirs} {
+		for _, m := range list {
+			if !seen[m.StudentID] {
+				seen[m.StudentID] = true
+				order = append(order, m.StudentID)
+			}
+		}
+	}
+	for _, m := range base {
+		if !seen[m.StudentID] {
+			seen[m.StudentID] = true
+			order = append(order, m.StudentID)
+		}
+	}
+
+	var merged []Member
+	var conflicts []Conflict
+	for _, id := range order {
+		b, o, t := baseSlots[id], ourSlots[id], theirSlots[id]
+		slot := o
+		switch {
+		case o == b:
+			slot = t
+		case t == b, t == o:
+			slot = o
+		default:
+			conflicts = append(conflicts, Conflict{
+				Path:    "team." + group,
+				Message: fmt.Sprintf("student %d %s on both sides", id, describeSlotChange(b, o, t)),
+			})
+		}
+		if slot == 0 {
+			continue
+		}
+		req := ourReqs[id]
+		br, or, tr := baseReqs[id], ourReqs[id], theirReqs[id]
+		switch {
+		case sameRequirements(or, br):
+			req = tr
+		case sameRequirements(tr, br), sameRequirements(tr, or):
+		default:
+			conflicts = append(conflicts, Conflict{
+				Path:    "team." + group,
+				Message: fmt.Sprintf("student %d requirements changed on both sides", id),
+			})
+		}
+		merged = append(merged, Member{StudentID: id, Slot: slot, Requirements: req})
+	}
+	return merged, conflicts
+}
+
+// slotsOf maps student id to slot; absent students map to 0.
+func slotsOf(members []Member) map[uint32]int {
+	slots := make(map[uint32]int, len(members))
+	for _, m := range members {
+		slots[m.StudentID] = m.Slot
+	}
+	return slots
+}
+
+func requirementsOf(members []Member) map[uint32]*Requirements {
+	reqs := make(map[uint32]*Requirements, len(members))
+	for _, m := range members {
+		reqs[m.StudentID] = m.Requirements
+	}
+	return reqs
+}
+
+// sameRequirements treats a missing requirements object like an empty one.
+func sameRequirements(a *Requirements, b *Requirements) bool {
+	var zero Requirements
+	if a == nil {
+		a = &zero
+	}
+	if b == nil {
+		b = &zero
+	}
+	return *a == *b
+}
+
+func describeSlotChange(base int, ours int, theirs int) string {
+	if base == 0 {
+		return fmt.Sprintf("added in slot %d and slot %d", ours, theirs)
+	}
+	if ours == 0 || theirs == 0 {
+		return "removed and moved"
+	}
+	return fmt.Sprintf("moved to slot %d and slot %d", ours, theirs)
+}
@@ -0,0 +1,129 @@
+package axisfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeTeam(t *testing.T) {
+	base := testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}})
+	ours := testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 3}})
+	theirs := testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}, {StudentID: 30, Slot: 4}})
+
+	merged, conflicts := Merge(base, ours, theirs)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts %v", conflicts)
+	}
+	want := []Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 3}, {StudentID: 30, Slot: 4}}
+	if !reflect.DeepEqual(merged.Team.Strikers, want) {
+		t.Errorf("strikers = %+v, want %+v", merged.Team.Strikers, want)
+	}
+}
+
+func TestMergeRemovedMember(t *testing.T) {
+	base := testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}})
+	ours := testFile([]Member{{StudentID: 10, Slot: 1}})
+	theirs := testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}})
+
+	merged, conflicts := Merge(base, ours, theirs)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts %v", conflicts)
+	}
+	if want := []Member{{StudentID: 10, Slot: 1}}; !reflect.DeepEqual(merged.Team.Strikers, want) {
+		t.Errorf("strikers = %+v, want %+v", merged.Team.Strikers, want)
+	}
+}
+
+func TestMergeActions(t *testing.T) {
+	team := []Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}}
+	base := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3})
+	ours := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3})
+	theirs := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3}, Action{Time: 5, StudentID: 20, Cost: 4})
+
+	merged, conflicts := Merge(base, ours, theirs)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts %v", conflicts)
+	}
+	if !reflect.DeepEqual(merged.Actions, theirs.Actions) {
+		t.Errorf("actions = %+v, want theirs %+v", merged.Actions, theirs.Actions)
+	}
+
+	// The same change on both sides is not a conflict.
+	merged, conflicts = Merge(base, theirs, theirs)
+	if len(conflicts) > 0 || !reflect.DeepEqual(merged.Actions, theirs.Actions) {
+		t.Errorf("identical changes : actions = %+v, conflicts %v", merged, conflicts)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	team := []Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}}
+	tests := []struct {
+		name   string
+		base   *File
+		ours   *File
+		theirs *File
+		want   []Conflict
+	}{
+		{
+			name:   "moved on both sides",
+			base:   testFile(team),
+			ours:   testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 3}}),
+			theirs: testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 4}}),
+			want:   []Conflict{{Path: "team.strikers", Message: "student 20 moved to slot 3 and slot 4 on both sides"}},
+		},
+		{
+			name:   "added in different slots",
+			base:   testFile(team),
+			ours:   testFile(append(team[:2:2], Member{StudentID: 30, Slot: 3})),
+			theirs: testFile(append(team[:2:2], Member{StudentID: 30, Slot: 4})),
+			want:   []Conflict{{Path: "team.strikers", Message: "student 30 added in slot 3 and slot 4 on both sides"}},
+		},
+		{
+			name:   "removed and moved",
+			base:   testFile(team),
+			ours:   testFile(team[:1]),
+			theirs: testFile([]Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 4}}),
+			want:   []Conflict{{Path: "team.strikers", Message: "student 20 removed and moved on both sides"}},
+		},
+		{
+			name:   "requirements",
+			base:   testFile(team),
+			ours:   testFile([]Member{{StudentID: 10, Slot: 1, Requirements: &Requirements{Star: 3}}, {StudentID: 20, Slot: 2}}),
+			theirs: testFile([]Member{{StudentID: 10, Slot: 1, Requirements: &Requirements{Star: 5}}, {StudentID: 20, Slot: 2}}),
+			want:   []Conflict{{Path: "team.strikers", Message: "student 10 requirements changed on both sides"}},
+		},
+		{
+			name:   "actions",
+			base:   testFile(team, Action{Time: 1, StudentID: 10, Cost: 3}),
+			ours:   testFile(team, Action{Time: 2, StudentID: 10, Cost: 3}),
+			theirs: testFile(team, Action{Time: 1, StudentID: 20, Cost: 3}),
+			want:   []Conflict{{Path: "actions", Message: "changed on both sides"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := Merge(tt.base, tt.ours, tt.theirs)
+			if merged != nil {
+				t.Errorf("expected no merged file, got %+v", merged)
+			}
+			if !reflect.DeepEqual(conflicts, tt.want) {
+				t.Errorf("conflicts = %v, want %v", conflicts, tt.want)
+			}
+		})
+	}
+}
+
+// TestMergeRevalidates checks that a merge clean on its own is still
+// rejected when the result breaks a cross-field rule.
+func TestMergeRevalidates(t *testing.T) {
+	team := []Member{{StudentID: 10, Slot: 1}, {StudentID: 20, Slot: 2}}
+	base := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3})
+	ours := testFile(team[:1], Action{Time: 1, StudentID: 10, Cost: 3})
+	theirs := testFile(team, Action{Time: 1, StudentID: 10, Cost: 3}, Action{Time: 2, StudentID: 20, Cost: 3})
+
+	_, conflicts := Merge(base, ours, theirs)
+	want := []Conflict{{Path: "actions[1].student_id", Message: "20 is not in the team after merge"}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", conflicts, want)
+	}
+}
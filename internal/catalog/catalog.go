@@ -0,0 +1,278 @@
+package catalog
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+const (
+	KindServers  = "servers"
+	KindBosses   = "bosses"
+	KindStages   = "stages"
+	KindStudents = "students"
+)
+
+// Kinds is also the import order, stages refer to bosses.
+var Kinds = []string{KindServers, KindBosses, KindStages, KindStudents}
+
+const (
+	RoleStriker = "striker"
+	RoleSpecial = "special"
+)
+
+var ErrNotFound = errors.New("catalog entry not found")
+
+type Server struct {
+	Code string `db:"code" json:"code" yaml:"code"`
+	Name string `db:"name" json:"name" yaml:"name"`
+}
+
+type Student struct {
+	ID     uint32 `db:"id" json:"id" yaml:"id"`
+	Name   string `db:"name" json:"name" yaml:"name"`
+	School string `db:"school" json:"school" yaml:"school"`
+	Role   string `db:"role" json:"role" yaml:"role"`
+	Rarity int    `db:"rarity" json:"rarity" yaml:"rarity"`
+}
+
+type Boss struct {
+	ID   string `db:"id" json:"id" yaml:"id"`
+	Name string `db:"name" json:"name" yaml:"name"`
+}
+
+type Stage struct {
+	ID     string  `db:"id" json:"id" yaml:"id"`
+	Area   string  `db:"area" json:"area" yaml:"area"`
+	Name   string  `db:"name" json:"name" yaml:"name"`
+	Type   string  `db:"type" json:"type" yaml:"type"`
+	BossID *string `db:"boss_id" json:"boss_id" yaml:"boss_id"`
+}
+
+// ImportState records the data file version currently loaded for a kind.
+type ImportState struct {
+	Kind       string    `db:"kind" json:"kind"`
+	Version    uint32    `db:"version" json:"version"`
+	File       string    `db:"file" json:"file"`
+	Checksum   string    `db:"checksum" json:"checksum"`
+	ItemCount  int       `db:"item_count" json:"item_count"`
+	TimeImport time.Time `db:"time_import" json:"time_import"`
+}
+
+type StudentFilter struct {
+	Role   string
+	School string
+}
+
+func ListServers(langs []string) ([]Server, error) {
+	servers := []Server{}
+	if err := database.DB.Select(&servers, `SELECT code, name FROM catalog_servers ORDER BY code`); err != nil {
+		return nil, err
+	}
+	names, err := localNames(KindServers, langs)
+	for i := range servers {
+		setName(&servers[i].Name, names, servers[i].Code)
+	}
+	return servers, err
+}
+
+func ListStudents(f StudentFilter, langs []string) ([]Student, error) {
+	query := `SELECT id, name, school, role, rarity FROM catalog_students WHERE 1 = 1`
+	var args []interface{}
+	if f.Role != "" {
+		query += ` AND role = ?`
+		args = append(args, f.Role)
+	}
+	if f.School != "" {
+		query += ` AND school = ?`
+		args = append(args, f.School)
+	}
+	students := []Student{}
+	if err := database.DB.Select(&students, query+` ORDER BY id`, args...); err != nil {
+		return nil, err
+	}
+	names, err := localNames(KindStudents, langs)
+	for i := range students {
+		setName(&students[i].Name, names, strconv.FormatUint(uint64(students[i].ID), 10))
+	}
+	return students, err
+}
+
+func GetStudent(id uint32, langs []string) (*Student, error) {
+	var s Student
+	err := database.DB.Get(&s, `SELECT id, name, school, role, rarity FROM catalog_students WHERE id = ?`, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	key := strconv.FormatUint(uint64(id), 10)
+	names, err := localNames(KindStudents, langs, key)
+	setName(&s.Name, names, key)
+	return &s, err
+}
+
+func ListBosses(langs []string) ([]Boss, error) {
+	bosses := []Boss{}
+	if err := database.DB.Select(&bosses, `SELECT id, name FROM catalog_bosses ORDER BY id`); err != nil {
+		return nil, err
+	}
+	names, err := localNames(KindBosses, langs)
+	for i := range bosses {
+		setName(&bosses[i].Name, names, bosses[i].ID)
+	}
+	return bosses, err
+}
+
+func GetBoss(id string, langs []string) (*Boss, error) {
+	var b Boss
+	err := database.DB.Get(&b, `SELECT id, name FROM catalog_bosses WHERE id = ?`, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	names, err := localNames(KindBosses, langs, id)
+	setName(&b.Name, names, id)
+	return &b, err
+}
+
+func ListStages(area string, langs []string) ([]Stage, error) {
+	query := `SELECT id, area, name, type, boss_id FROM catalog_stages`
+	var args []interface{}
+	if area != "" {
+		query += ` WHERE area = ?`
+		args = append(args, area)
+	}
+	stages := []Stage{}
+	if err := database.DB.Select(&stages, query+` ORDER BY area, id`, args...); err != nil {
+		return nil, err
+	}
+	names, err := localNames(KindStages, langs)
+	for i := range stages {
+		setName(&stages[i].Name, names, stages[i].ID)
+	}
+	return stages, err
+}
+
+func GetStage(id string, langs []string) (*Stage, error) {
+	var s Stage
+	err := database.DB.Get(&s, `SELECT id, area, name, type, boss_id FROM catalog_stages WHERE id = ?`, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	names, err := localNames(KindStages, langs, id)
+	setName(&s.Name, names, id)
+	return &s, err
+}
+
+func ListImports() ([]ImportState, error) {
+	states := []ImportState{}
+	err := database.DB.Select(&states,
+		`SELECT kind, version, file, checksum, item_count, time_import FROM catalog_imports ORDER BY kind`,
+	)
+	return states, err
+}
+
+// StageExists reports whether an axis may reference the stage. Checks only
+// apply once stage data has been imported, so a fresh install is usable
+// before the catalog is populated.
+func StageExists(id string) (bool, error) {
+	imported, err := isImported(KindStages)
+	if err != nil || !imported {
+		return true, err
+	}
+	var n int
+	err = database.DB.Get(&n, `SELECT COUNT(*) FROM catalog_stages WHERE id = ?`, id)
+	return n > 0, err
+}
+
+// UnknownStudents returns the ids that are not in the catalog, following the
+// same rule as StageExists.
+func UnknownStudents(ids []uint32) ([]uint32, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	imported, err := isImported(KindStudents)
+	if err != nil || !imported {
+		return nil, err
+	}
+	query, args, err := sqlx.In(`SELECT id FROM catalog_students WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	var known []uint32
+	if err := database.DB.Select(&known, query, args...); err != nil {
+		return nil, err
+	}
+	found := make(map[uint32]bool, len(known))
+	for _, id := range known {
+		found[id] = true
+	}
+	var unknown []uint32
+	for _, id := range ids {
+		if !found[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown, nil
+}
+
+// localNames returns the translated name of each entry of kind in the first
+// of langs it has one in, limited to ids when given. Entries without one keep
+// the name from the data file.
+func localNames(kind string, langs []string, ids ...string) (map[string]string, error) {
+	if len(langs) == 0 {
+		return nil, nil
+	}
+	query := `SELECT id, language, name FROM catalog_names WHERE kind = ? AND language IN (?)`
+	args := []interface{}{kind, langs}
+	if len(ids) > 0 {
+		query += ` AND id IN (?)`
+		args = append(args, ids)
+	}
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID       string `db:"id"`
+		Language string `db:"language"`
+		Name     string `db:"name"`
+	}
+	if err := database.DB.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+	rank := make(map[string]int, len(langs))
+	for i := len(langs) - 1; i >= 0; i-- {
+		rank[langs[i]] = i
+	}
+	names := map[string]string{}
+	best := map[string]int{}
+	for _, row := range rows {
+		if r, ok := best[row.ID]; !ok || rank[row.Language] < r {
+			best[row.ID] = rank[row.Language]
+			names[row.ID] = row.Name
+		}
+	}
+	return names, nil
+}
+
+func setName(name *string, names map[string]string, id string) {
+	if v, ok := names[id]; ok {
+		*name = v
+	}
+}
+
+func isImported(kind string) (bool, error) {
+	var n int
+	err := database.DB.Get(&n, `SELECT COUNT(*) FROM catalog_imports WHERE kind = ?`, kind)
+	return n > 0, err
+}
+
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func handlerListServers(w http.ResponseWriter, r *http.Request) {
+	servers, err := ListServers(api.Languages(r))
+	respond(w, servers, err)
+}
+
+func handlerListStudents(w http.ResponseWriter, r *http.Request) {
+	f := StudentFilter{
+		Role:   r.URL.Query().Get("role"),
+		School: r.URL.Query().Get("school"),
+	}
+	students, err := ListStudents(f, api.Languages(r))
+	respond(w, students, err)
+}
+
+func handlerGetStudent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid student id")
+		return
+	}
+	s, err := GetStudent(uint32(id), api.Languages(r))
+	respond(w, s, err)
+}
+
+func handlerListBosses(w http.ResponseWriter, r *http.Request) {
+	bosses, err := ListBosses(api.Languages(r))
+	respond(w, bosses, err)
+}
+
+func handlerGetBoss(w http.ResponseWriter, r *http.Request) {
+	b, err := GetBoss(chi.URLParam(r, "id"), api.Languages(r))
+	respond(w, b, err)
+}
+
+func handlerListStages(w http.ResponseWriter, r *http.Request) {
+	stages, err := ListStages(r.URL.Query().Get("area"), api.Languages(r))
+	respond(w, stages, err)
+}
+
+func handlerGetStage(w http.ResponseWriter, r *http.Request) {
+	s, err := GetStage(chi.URLParam(r, "id"), api.Languages(r))
+	respond(w, s, err)
+}
+
+func handlerListImports(w http.ResponseWriter, r *http.Request) {
+	states, err := ListImports()
+	respond(w, states, err)
+}
+
+func respond(w http.ResponseWriter, v interface{}, err error) {
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, v)
+}
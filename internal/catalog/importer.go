@@ -0,0 +1,403 @@
+package catalog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/i18n"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// A data file holds every entry of one kind:
+//
+//	kind: students
+//	version: 3
+//	items:
+//	  - {id: 10000, name: Aru, school: Gehenna, role: striker, rarity: 3, names: {ja: アル, zh: 阿露}}
+//
+// A file is only imported when its version is higher than the one loaded,
+// and then replaces the whole kind. names is optional and holds the
+// translations served to clients asking for those languages.
+type fileHeader struct {
+	Kind    string `json:"kind" yaml:"kind"`
+	Version uint32 `json:"version" yaml:"version"`
+}
+
+type ImportResult struct {
+	Kind      string `json:"kind"`
+	File      string `json:"file"`
+	Version   uint32 `json:"version"`
+	ItemCount int    `json:"item_count"`
+	// Skipped is set when the loaded version is already the same or newer.
+	Skipped bool `json:"skipped"`
+}
+
+// localized sits next to the entry in every item of a data file.
+type localized struct {
+	Names map[string]string `json:"names" yaml:"names"`
+}
+
+type dataFile struct {
+	path     string
+	header   fileHeader
+	data     []byte
+	checksum string
+	decode   func(data []byte, v interface{}) error
+}
+
+// Import loads every .json, .yaml and .yml data file in dir.
+func Import(dir string) ([]ImportResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.New("Catalog Import Error : " + err.Error())
+	}
+	files := map[string]*dataFile{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		f, err := readDataFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.New("Catalog Import Error : " + err.Error())
+		}
+		if f == nil {
+			continue
+		}
+		if prev, ok := files[f.header.Kind]; ok {
+			return nil, fmt.Errorf("Catalog Import Error : %s and %s both define %s", prev.path, f.path, f.header.Kind)
+		}
+		files[f.header.Kind] = f
+	}
+
+	var results []ImportResult
+	for _, kind := range Kinds {
+		f, ok := files[kind]
+		if !ok {
+			continue
+		}
+		result, err := importFile(f)
+		if err != nil {
+			return results, fmt.Errorf("Catalog Import Error : %s : %w", f.path, err)
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+func readDataFile(path string) (*dataFile, error) {
+	var decode func(data []byte, v interface{}) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decode = decodeJSON
+	case ".yaml", ".yml":
+		decode = yaml.Unmarshal
+	default:
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &dataFile{path: path, data: data, decode: decode}
+	if err := decode(data, &f.header); err != nil {
+		return nil, fmt.Errorf("%s : %w", path, err)
+	}
+	if !isKind(f.header.Kind) {
+		return nil, fmt.Errorf("%s : unknown kind %q", path, f.header.Kind)
+	}
+	if f.header.Version == 0 {
+		return nil, fmt.Errorf("%s : version is required", path)
+	}
+	sum := sha256.Sum256(data)
+	f.checksum = hex.EncodeToString(sum[:])
+	return f, nil
+}
+
+// decodeJSON ignores unknown fields so the header can be read on its own.
+func decodeJSON(data []byte, v interface{}) error {
+	return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func importFile(f *dataFile) (*ImportResult, error) {
+	result := &ImportResult{Kind: f.header.Kind, File: filepath.Base(f.path), Version: f.header.Version}
+
+	var current ImportState
+	err := database.DB.Get(&current, `SELECT kind, version, checksum FROM catalog_imports WHERE kind = ?`, f.header.Kind)
+	if err == nil && current.Version >= f.header.Version {
+		if current.Version == f.header.Version && current.Checksum != f.checksum {
+			logger.BAASWarn("Catalog", f.header.Kind, "file changed without a version bump :", f.path)
+		}
+		result.Skipped = true
+		return result, nil
+	}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	switch f.header.Kind {
+	case KindServers:
+		result.ItemCount, err = importServers(tx, f)
+	case KindBosses:
+		result.ItemCount, err = importBosses(tx, f)
+	case KindStages:
+		result.ItemCount, err = importStages(tx, f)
+	case KindStudents:
+		result.ItemCount, err = importStudents(tx, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO catalog_imports (kind, version, file, checksum, item_count) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE version = VALUES(version), file = VALUES(file), checksum = VALUES(checksum),
+			item_count = VALUES(item_count), time_import = CURRENT_TIMESTAMP`,
+		f.header.Kind, f.header.Version, result.File, f.checksum, result.ItemCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	logger.BAASInfo("Catalog imported", f.header.Kind, "version", strconv.FormatUint(uint64(f.header.Version), 10), ":", strconv.Itoa(result.ItemCount), "items")
+	return result, nil
+}
+
+func importServers(tx *sqlx.Tx, f *dataFile) (int, error) {
+	var doc struct {
+		Items []struct {
+			Server    `yaml:",inline"`
+			localized `yaml:",inline"`
+		} `json:"items" yaml:"items"`
+	}
+	if err := f.decode(f.data, &doc); err != nil {
+		return 0, err
+	}
+	keys := make([]string, len(doc.Items))
+	names := make([]map[string]string, len(doc.Items))
+	for i, each := range doc.Items {
+		if each.Code == "" || each.Name == "" {
+			return 0, fmt.Errorf("items[%d] : code and name are required", i)
+		}
+		keys[i] = each.Code
+		names[i] = each.Names
+	}
+	if err := replaceAll(tx, "catalog_servers", "code", keys); err != nil {
+		return 0, err
+	}
+	for _, each := range doc.Items {
+		_, err := tx.Exec(
+			`INSERT INTO catalog_servers (code, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)`,
+			each.Code, each.Name,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := replaceNames(tx, KindServers, keys, names); err != nil {
+		return 0, err
+	}
+	return len(doc.Items), nil
+}
+
+func importBosses(tx *sqlx.Tx, f *dataFile) (int, error) {
+	var doc struct {
+		Items []struct {
+			Boss      `yaml:",inline"`
+			localized `yaml:",inline"`
+		} `json:"items" yaml:"items"`
+	}
+	if err := f.decode(f.data, &doc); err != nil {
+		return 0, err
+	}
+	keys := make([]string, len(doc.Items))
+	names := make([]map[string]string, len(doc.Items))
+	for i, each := range doc.Items {
+		if each.ID == "" || each.Name == "" {
+			return 0, fmt.Errorf("items[%d] : id and name are required", i)
+		}
+		keys[i] = each.ID
+		names[i] = each.Names
+	}
+	if err := replaceAll(tx, "catalog_bosses", "id", keys); err != nil {
+		return 0, err
+	}
+	for _, each := range doc.Items {
+		_, err := tx.Exec(
+			`INSERT INTO catalog_bosses (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)`,
+			each.ID, each.Name,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := replaceNames(tx, KindBosses, keys, names); err != nil {
+		return 0, err
+	}
+	return len(doc.Items), nil
+}
+
+func importStages(tx *sqlx.Tx, f *dataFile) (int, error) {
+	var doc struct {
+		Items []struct {
+			Stage     `yaml:",inline"`
+			localized `yaml:",inline"`
+		} `json:"items" yaml:"items"`
+	}
+	if err := f.decode(f.data, &doc); err != nil {
+		return 0, err
+	}
+	var bosses []string
+	if err := tx.Select(&bosses, `SELECT id FROM catalog_bosses`); err != nil {
+		return 0, err
+	}
+	knownBoss := make(map[string]bool, len(bosses))
+	for _, id := range bosses {
+		knownBoss[id] = true
+	}
+	keys := make([]string, len(doc.Items))
+	names := make([]map[string]string, len(doc.Items))
+	for i, each := range doc.Items {
+		if each.ID == "" || each.Area == "" || each.Name == "" || each.Type == "" {
+			return 0, fmt.Errorf("items[%d] : id, area, name and type are required", i)
+		}
+		if len(each.ID) > 32 {
+			return 0, fmt.Errorf("items[%d] : id must be at most 32 characters", i)
+		}
+		if each.BossID != nil && !knownBoss[*each.BossID] {
+			return 0, fmt.Errorf("items[%d] : unknown boss %q", i, *each.BossID)
+		}
+		keys[i] = each.ID
+		names[i] = each.Names
+	}
+	if err := replaceAll(tx, "catalog_stages", "id", keys); err != nil {
+		return 0, err
+	}
+	for _, each := range doc.Items {
+		_, err := tx.Exec(
+			`INSERT INTO catalog_stages (id, area, name, type, boss_id) VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE area = VALUES(area), name = VALUES(name), type = VALUES(type), boss_id = VALUES(boss_id)`,
+			each.ID, each.Area, each.Name, each.Type, each.BossID,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := replaceNames(tx, KindStages, keys, names); err != nil {
+		return 0, err
+	}
+	return len(doc.Items), nil
+}
+
+func importStudents(tx *sqlx.Tx, f *dataFile) (int, error) {
+	var doc struct {
+		Items []struct {
+			Student   `yaml:",inline"`
+			localized `yaml:",inline"`
+		} `json:"items" yaml:"items"`
+	}
+	if err := f.decode(f.data, &doc); err != nil {
+		return 0, err
+	}
+	keys := make([]string, len(doc.Items))
+	names := make([]map[string]string, len(doc.Items))
+	for i, each := range doc.Items {
+		if each.ID == 0 || each.Name == "" {
+			return 0, fmt.Errorf("items[%d] : id and name are required", i)
+		}
+		if each.Role != RoleStriker && each.Role != RoleSpecial {
+			return 0, fmt.Errorf("items[%d] : role must be striker or special", i)
+		}
+		if each.Rarity < 1 || each.Rarity > 3 {
+			return 0, fmt.Errorf("items[%d] : rarity must be 1-3", i)
+		}
+		keys[i] = strconv.FormatUint(uint64(each.ID), 10)
+		names[i] = each.Names
+	}
+	if err := replaceAll(tx, "catalog_students", "id", keys); err != nil {
+		return 0, err
+	}
+	for _, each := range doc.Items {
+		_, err := tx.Exec(
+			`INSERT INTO catalog_students (id, name, school, role, rarity) VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE name = VALUES(name), school = VALUES(school), role = VALUES(role), rarity = VALUES(rarity)`,
+			each.ID, each.Name, each.School, each.Role, each.Rarity,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := replaceNames(tx, KindStudents, keys, names); err != nil {
+		return 0, err
+	}
+	return len(doc.Items), nil
+}
+
+// replaceAll checks keys for duplicates and removes the rows of table that
+// are no longer listed.
+func replaceAll(tx *sqlx.Tx, table, column string, keys []string) error {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			return fmt.Errorf("duplicate %s %q", column, key)
+		}
+		seen[key] = true
+	}
+	if len(keys) == 0 {
+		_, err := tx.Exec(`DELETE FROM ` + table)
+		return err
+	}
+	query, args, err := sqlx.In(`DELETE FROM `+table+` WHERE `+column+` NOT IN (?)`, keys)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// replaceNames swaps the translated names of kind for those of the file,
+// names[i] belonging to keys[i].
+func replaceNames(tx *sqlx.Tx, kind string, keys []string, names []map[string]string) error {
+	if _, err := tx.Exec(`DELETE FROM catalog_names WHERE kind = ?`, kind); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		for lang, name := range names[i] {
+			if !i18n.IsSupported(lang) {
+				return fmt.Errorf("items[%d] : unsupported language %q in names", i, lang)
+			}
+			if name == "" || utf8.RuneCountInString(name) > 64 {
+				return fmt.Errorf("items[%d] : names.%s must be 1-64 characters", i, lang)
+			}
+			_, err := tx.Exec(`INSERT INTO catalog_names (kind, id, language, name) VALUES (?, ?, ?, ?)`, kind, key, lang, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isKind(kind string) bool {
+	for _, each := range Kinds {
+		if each == kind {
+			return true
+		}
+	}
+	return false
+}
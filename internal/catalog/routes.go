@@ -0,0 +1,20 @@
+package catalog
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes serves the reference data read-only, it is public like the axis
+// file schemas.
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/servers", handlerListServers)
+	r.Get("/students", handlerListStudents)
+	r.Get("/students/{id}", handlerGetStudent)
+	r.Get("/bosses", handlerListBosses)
+	r.Get("/bosses/{id}", handlerGetBoss)
+	r.Get("/stages", handlerListStages)
+	r.Get("/stages/{id}", handlerGetStage)
+	r.Get("/imports", handlerListImports)
+	return r
+}
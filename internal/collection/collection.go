@@ -0,0 +1,366 @@
+package collection
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+const MaxItems = 100
+
+var (
+	ErrNotFound        = errors.New("collection not found")
+	ErrItemUnavailable = errors.New("axis revision not found or not published")
+)
+
+type Collection struct {
+	ID             uint64     `db:"id" json:"id"`
+	OwnerID        uint64     `db:"owner_id" json:"owner_id"`
+	OwnerName      string     `db:"owner_name" json:"owner_name"`
+	Title          string     `db:"title" json:"title"`
+	Description    *string    `db:"description" json:"description"`
+	Visibility     string     `db:"visibility" json:"visibility"`
+	ForkedFromID   *uint64    `db:"forked_from_collection_id" json:"forked_from_collection_id"`
+	ItemCount      int        `db:"item_count" json:"item_count"`
+	IsDeleted      bool       `db:"is_deleted" json:"-"`
+	TimeDeleted    *time.Time `db:"time_deleted" json:"-"`
+	TimeCreate     time.Time  `db:"time_create" json:"time_create"`
+	TimeLastUpdate time.Time  `db:"time_last_update" json:"time_last_update"`
+	Items          []Item     `db:"-" json:"items,omitempty"`
+}
+
+// Item is one step of a collection. Available is false when the viewer can
+// no longer see the axis, in which case only the position and note are
+// shown.
+type Item struct {
+	Position   int        `db:"position" json:"position"`
+	AxisID     uint64     `db:"axis_id" json:"axis_id"`
+	RevisionID uint64     `db:"revision_id" json:"-"`
+	Revision   uint32     `db:"revision_no" json:"revision"`
+	Note       *string    `db:"note" json:"note"`
+	Available  bool       `db:"-" json:"available"`
+	Axis       *axis.Axis `db:"-" json:"axis,omitempty"`
+}
+
+// Fields holds the user editable columns of a collection.
+type Fields struct {
+	Title       string
+	Description *string
+	Visibility  string
+}
+
+// NewItem references a revision by its number, as users see it.
+type NewItem struct {
+	AxisID   uint64
+	Revision uint32
+	Note     *string
+}
+
+type ListFilter struct {
+	OwnerID  uint64
+	ViewerID uint64
+	Offset   int
+	Limit    int
+}
+
+const collectionColumns = `c.id, c.owner_id, u.username AS owner_name, c.title, c.description, c.visibility,
+	c.forked_from_collection_id, (SELECT COUNT(*) FROM collection_items i WHERE i.collection_id = c.id) AS item_count,
+	c.is_deleted, c.time_deleted, c.time_create, c.time_last_update`
+
+const collectionFrom = ` FROM collections c JOIN users u ON u.id = c.owner_id `
+
+func Get(id uint64) (*Collection, error) {
+	var c Collection
+	err := database.DB.Get(&c,
+		`SELECT `+collectionColumns+collectionFrom+`WHERE c.id = ? AND c.is_deleted = FALSE AND u.is_deleted = FALSE`, id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func List(f ListFilter) ([]Collection, error) {
+	where := []string{"c.is_deleted = FALSE", "u.is_deleted = FALSE"}
+	var args []interface{}
+	if f.OwnerID != 0 {
+		where = append(where, "c.owner_id = ?")
+		args = append(args, f.OwnerID)
+	}
+	if f.OwnerID == 0 || f.OwnerID != f.ViewerID {
+		where = append(where, "c.visibility = ?")
+		args = append(args, axis.VisibilityPublic)
+	}
+	args = append(args, f.Limit, f.Offset)
+	collections := []Collection{}
+	err := database.DB.Select(&collections,
+		`SELECT `+collectionColumns+collectionFrom+`WHERE `+strings.Join(where, " AND ")+` ORDER BY c.id DESC LIMIT ? OFFSET ?`,
+		args...,
+	)
+	return collections, err
+}
+
+// CanView applies the axis visibility rules to a collection.
+func CanView(c *Collection, v axis.Viewer) bool {
+	if c.Visibility != axis.VisibilityPrivate {
+		return true
+	}
+	return CanEdit(c, v)
+}
+
+func CanEdit(c *Collection, v axis.Viewer) bool {
+	return v.Moderator || (v.UserID != 0 && v.UserID == c.OwnerID)
+}
+
+func Create(ownerID uint64, f Fields) (*Collection, error) {
+	res, err := database.DB.Exec(
+		`INSERT INTO collections (owner_id, title, description, visibility) VALUES (?, ?, ?, ?)`,
+		ownerID, f.Title, f.Description, f.Visibility,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return Get(uint64(id))
+}
+
+func Update(id uint64, f Fields) (*Collection, error) {
+	_, err := database.DB.Exec(
+		`UPDATE collections SET title = ?, description = ?, visibility = ? WHERE id = ? AND is_deleted = FALSE`,
+		f.Title, f.Description, f.Visibility, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return Get(id)
+}
+
+func Delete(id uint64) error {
+	res, err := database.DB.Exec(
+		`UPDATE collections SET is_deleted = TRUE, time_deleted = NOW() WHERE id = ? AND is_deleted = FALSE`, id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// LoadItems fills c.Items, resolving each axis for v.
+func LoadItems(c *Collection, v axis.Viewer) error {
+	items := []Item{}
+	err := database.DB.Select(&items,
+		`SELECT i.position, i.axis_id, i.revision_id, r.revision_no, i.note
+		FROM collection_items i JOIN axis_revisions r ON r.id = i.revision_id
+		WHERE i.collection_id = ? ORDER BY i.position`, c.ID,
+	)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		a, err := axis.Get(items[i].AxisID)
+		if errors.Is(err, axis.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if v.CanView(a) {
+			items[i].Axis, items[i].Available = a, true
+		}
+	}
+	c.Items = items
+	return nil
+}
+
+// SetItems replaces the ordered items of a collection. Every revision must
+// be published and visible to the editor v.
+func SetItems(c *Collection, v axis.Viewer, items []NewItem) error {
+	revisionIDs := make([]uint64, len(items))
+	for i, item := range items {
+		a, err := axis.Get(item.AxisID)
+		if errors.Is(err, axis.ErrNotFound) {
+			return ErrItemUnavailable
+		}
+		if err != nil {
+			return err
+		}
+		if !v.CanView(a) {
+			return ErrItemUnavailable
+		}
+		rev, err := axis.GetRevision(item.AxisID, item.Revision)
+		if errors.Is(err, axis.ErrRevisionNotFound) {
+			return ErrItemUnavailable
+		}
+		if err != nil {
+			return err
+		}
+		if !rev.IsPublished {
+			return ErrItemUnavailable
+		}
+		revisionIDs[i] = rev.ID
+	}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := replaceItems(tx, c.ID, items, revisionIDs); err != nil {
+		return err
+	}
+	// Item changes are edits of the collection.
+	if _, err := tx.Exec(`UPDATE collections SET time_last_update = NOW() WHERE id = ?`, c.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceItems(tx *sqlx.Tx, collectionID uint64, items []NewItem, revisionIDs []uint64) error {
+	if _, err := tx.Exec(`DELETE FROM collection_items WHERE collection_id = ?`, collectionID); err != nil {
+		return err
+	}
+	for i, item := range items {
+		_, err := tx.Exec(
+			`INSERT INTO collection_items (collection_id, position, axis_id, revision_id, note) VALUES (?, ?, ?, ?, ?)`,
+			collectionID, i+1, item.AxisID, revisionIDs[i], item.Note,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fork copies a collection for ownerID, keeping only the items the new
+// owner can see.
+func Fork(origin *Collection, ownerID uint64, f Fields) (*Collection, error) {
+	if err := LoadItems(origin, axis.Viewer{UserID: ownerID}); err != nil {
+		return nil, err
+	}
+	var items []NewItem
+	var revisionIDs []uint64
+	for _, item := range origin.Items {
+		if !item.Available {
+			continue
+		}
+		items = append(items, NewItem{AxisID: item.AxisID, Revision: item.Revision, Note: item.Note})
+		revisionIDs = append(revisionIDs, item.RevisionID)
+	}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(
+		`INSERT INTO collections (owner_id, title, description, visibility, forked_from_collection_id) VALUES (?, ?, ?, ?, ?)`,
+		ownerID, f.Title, f.Description, f.Visibility, origin.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := replaceItems(tx, uint64(id), items, revisionIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return Get(uint64(id))
+}
+
+// ExportUser lists the collections of the user with their items, for the
+// personal data export.
+func ExportUser(userID uint64) (interface{}, error) {
+	collections := []Collection{}
+	err := database.DB.Select(&collections,
+		`SELECT `+collectionColumns+collectionFrom+`WHERE c.owner_id = ? AND c.is_deleted = FALSE ORDER BY c.id`, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		if err := LoadItems(&collections[i], axis.Viewer{UserID: userID}); err != nil {
+			return nil, err
+		}
+	}
+	return collections, nil
+}
+
+// Bundle is a whole collection in one download, with the axis file of every
+// item the viewer can see embedded.
+type Bundle struct {
+	ID          uint64       `json:"id"`
+	Title       string       `json:"title"`
+	Description *string      `json:"description"`
+	OwnerName   string       `json:"owner_name"`
+	TimeExport  time.Time    `json:"time_export"`
+	Items       []BundleItem `json:"items"`
+}
+
+type BundleItem struct {
+	Position int             `json:"position"`
+	AxisID   uint64          `json:"axis_id"`
+	Title    string          `json:"title"`
+	StageID  string          `json:"stage_id"`
+	Revision uint32          `json:"revision"`
+	Note     *string         `json:"note"`
+	File     json.RawMessage `json:"file"`
+}
+
+// Export builds the bundle of c for v. Items the viewer cannot see are
+// left out.
+func Export(c *Collection, v axis.Viewer) (*Bundle, error) {
+	if err := LoadItems(c, v); err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		ID:          c.ID,
+		Title:       c.Title,
+		Description: c.Description,
+		OwnerName:   c.OwnerName,
+		TimeExport:  time.Now(),
+		Items:       []BundleItem{},
+	}
+	for _, item := range c.Items {
+		if !item.Available {
+			continue
+		}
+		content, err := axis.GetRevisionContent(item.AxisID, item.Revision)
+		if err != nil {
+			return nil, err
+		}
+		b.Items = append(b.Items, BundleItem{
+			Position: item.Position,
+			AxisID:   item.AxisID,
+			Title:    item.Axis.Title,
+			StageID:  item.Axis.StageID,
+			Revision: item.Revision,
+			Note:     item.Note,
+			File:     content,
+		})
+	}
+	return b, nil
+}
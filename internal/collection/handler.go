@@ -0,0 +1,340 @@
+package collection
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type createRequest struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+	Visibility  string  `json:"visibility"`
+}
+
+type updateRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Visibility  *string `json:"visibility"`
+}
+
+type itemRequest struct {
+	AxisID   uint64  `json:"axis_id"`
+	Revision uint32  `json:"revision"`
+	Note     *string `json:"note"`
+}
+
+type forkRequest struct {
+	Title      *string `json:"title"`
+	Visibility *string `json:"visibility"`
+}
+
+func handlerCreate(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req createRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Visibility == "" {
+		req.Visibility = axis.VisibilityPublic
+	}
+	f := Fields{
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	}
+	if msg := validateFields(f); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	c, err := Create(userID, f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, c)
+}
+
+func handlerGet(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadVisibleCollection(w, r)
+	if !ok {
+		return
+	}
+	v, err := axis.ViewerOf(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	if err := LoadItems(c, v); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, c)
+}
+
+func handlerList(w http.ResponseWriter, r *http.Request) {
+	viewerID, _ := api.CurrentUserID(r)
+	f := ListFilter{ViewerID: viewerID}
+	if owner := r.URL.Query().Get("owner_id"); owner != "" {
+		id, err := strconv.ParseUint(owner, 10, 64)
+		if err != nil {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid owner_id")
+			return
+		}
+		f.OwnerID = id
+	}
+	page, pageSize, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	f.Limit = pageSize
+	f.Offset = (page - 1) * pageSize
+
+	collections, err := List(f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     collections,
+	})
+}
+
+func handlerUpdate(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadEditableCollection(w, r)
+	if !ok {
+		return
+	}
+	var req updateRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	f := Fields{
+		Title:       c.Title,
+		Description: c.Description,
+		Visibility:  c.Visibility,
+	}
+	if req.Title != nil {
+		f.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		f.Description = req.Description
+	}
+	if req.Visibility != nil {
+		f.Visibility = *req.Visibility
+	}
+	if msg := validateFields(f); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	updated, err := Update(c.ID, f)
+	if err != nil {
+		respondCollectionError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, updated)
+}
+
+func handlerDelete(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadEditableCollection(w, r)
+	if !ok {
+		return
+	}
+	if err := Delete(c.ID); err != nil {
+		respondCollectionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerSetItems replaces the whole ordered list; reordering is done by
+// sending the items in their new order.
+func handlerSetItems(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadEditableCollection(w, r)
+	if !ok {
+		return
+	}
+	var req []itemRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if len(req) > MaxItems {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "too many items")
+		return
+	}
+	items := make([]NewItem, len(req))
+	for i, each := range req {
+		if each.AxisID == 0 || each.Revision == 0 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "["+strconv.Itoa(i)+"] axis_id and revision are required")
+			return
+		}
+		if each.Note != nil && utf8.RuneCountInString(*each.Note) > 500 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "["+strconv.Itoa(i)+"] note must be at most 500 characters")
+			return
+		}
+		items[i] = NewItem{AxisID: each.AxisID, Revision: each.Revision, Note: each.Note}
+	}
+	v, err := axis.ViewerOf(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	if err := SetItems(c, v, items); err != nil {
+		respondCollectionError(w, err)
+		return
+	}
+	handlerGet(w, r)
+}
+
+func handlerFork(w http.ResponseWriter, r *http.Request) {
+	origin, ok := loadVisibleCollection(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	var req forkRequest
+	if err := api.DecodeOptionalJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	f := Fields{
+		Title:       origin.Title,
+		Description: origin.Description,
+		Visibility:  axis.VisibilityPublic,
+	}
+	if req.Title != nil {
+		f.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Visibility != nil {
+		f.Visibility = *req.Visibility
+	}
+	if msg := validateFields(f); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	fork, err := Fork(origin, userID, f)
+	if err != nil {
+		respondCollectionError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, fork)
+}
+
+func handlerExport(w http.ResponseWriter, r *http.Request) {
+	c, ok := loadVisibleCollection(w, r)
+	if !ok {
+		return
+	}
+	v, err := axis.ViewerOf(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	b, err := Export(c, v)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="collection-`+strconv.FormatUint(c.ID, 10)+`.json"`)
+	api.ResponseWithJson(w, http.StatusOK, b)
+}
+
+func parsePage(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	page, pageSize := 1, defaultPageSize
+	var err error
+	if v := r.URL.Query().Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page")
+			return 0, 0, false
+		}
+	}
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page_size")
+			return 0, 0, false
+		}
+	}
+	return page, pageSize, true
+}
+
+func validateFields(f Fields) string {
+	if n := utf8.RuneCountInString(f.Title); n == 0 || n > 100 {
+		return "title must be 1-100 characters"
+	}
+	if f.Description != nil && utf8.RuneCountInString(*f.Description) > 5000 {
+		return "description must be at most 5000 characters"
+	}
+	if !axis.IsValidVisibility(f.Visibility) {
+		return "unknown visibility"
+	}
+	return ""
+}
+
+// loadVisibleCollection hides private collections from everyone but their
+// owner and moderators; unlisted ones are reachable by id.
+func loadVisibleCollection(w http.ResponseWriter, r *http.Request) (*Collection, bool) {
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid collection id")
+		return nil, false
+	}
+	c, err := Get(id)
+	if err != nil {
+		respondCollectionError(w, err)
+		return nil, false
+	}
+	v, err := axis.ViewerOf(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	if !CanView(c, v) {
+		respondCollectionError(w, ErrNotFound)
+		return nil, false
+	}
+	return c, true
+}
+
+func loadEditableCollection(w http.ResponseWriter, r *http.Request) (*Collection, bool) {
+	c, ok := loadVisibleCollection(w, r)
+	if !ok {
+		return nil, false
+	}
+	v, err := axis.ViewerOf(r)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	if !CanEdit(c, v) {
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "not the owner of this collection")
+		return nil, false
+	}
+	return c, true
+}
+
+func respondCollectionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrItemUnavailable) {
+		api.ResponseWithError(w, http.StatusUnprocessableEntity, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	api.ResponseWithInternalError(w, err)
+}
@@ -0,0 +1,27 @@
+package collection
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireScope(api.ScopeAxisRead))
+		r.Get("/", handlerList)
+		r.Get("/{id}", handlerGet)
+		r.Get("/{id}/export", handlerExport)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireUser, api.RequireScope(api.ScopeAxisWrite))
+		r.Post("/", handlerCreate)
+		r.Patch("/{id}", handlerUpdate)
+		r.Delete("/{id}", handlerDelete)
+		r.Put("/{id}/items", handlerSetItems)
+		r.Post("/{id}/fork", handlerFork)
+	})
+	return r
+}
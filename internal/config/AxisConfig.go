@@ -0,0 +1,22 @@
+package config
+
+type AxisConfig struct {
+	// RatingPriorWeight is how many ratings at the site wide mean every
+	// axis starts with when computing its ranking score.
+	RatingPriorWeight   float64 `yaml:"rating_prior_weight"`
+	RatingPriorMean     float64 `yaml:"rating_prior_mean"`
+	ScoreRefreshMinutes int     `yaml:"score_refresh_minutes"`
+	DownloadDedupHours  int     `yaml:"download_dedup_hours"`
+	// MaxBundleSizeMB caps the upload size of a .baasaxis bundle import.
+	MaxBundleSizeMB int `yaml:"max_bundle_size_mb"`
+}
+
+func DefaultAxisConfig() *AxisConfig {
+	return &AxisConfig{
+		RatingPriorWeight:   5,
+		RatingPriorMean:     3,
+		ScoreRefreshMinutes: 60,
+		DownloadDedupHours:  24,
+		MaxBundleSizeMB:     64,
+	}
+}
@@ -0,0 +1,14 @@
+package config
+
+type CatalogConfig struct {
+	// DataDir holds the versioned data files, empty means <executable dir>/data/catalog.
+	DataDir         string `yaml:"data_dir"`
+	ImportOnStartup bool   `yaml:"import_on_startup"`
+}
+
+func DefaultCatalogConfig() *CatalogConfig {
+	return &CatalogConfig{
+		DataDir:         "",
+		ImportOnStartup: true,
+	}
+}
@@ -9,6 +9,9 @@ type DatabaseConfig struct {
 	MaxOpenConn     int    `yaml:"max_open_conn"`
 	MaxIdleConn     int    `yaml:"max_idle_conn"`
 	ConnMaxLifetime int    `yaml:"conn_max_lifetime"`
+	// AutoMigrate applies the pending migrations of sql/ when the server
+	// starts, otherwise they are applied with the migrate command.
+	AutoMigrate bool `yaml:"auto_migrate"`
 }
 
 func DefaultDatabaseConfig() *DatabaseConfig {
@@ -21,5 +24,6 @@ func DefaultDatabaseConfig() *DatabaseConfig {
 		MaxOpenConn:     100,
 		MaxIdleConn:     10,
 		ConnMaxLifetime: 60,
+		AutoMigrate:     true,
 	}
 }
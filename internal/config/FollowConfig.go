@@ -0,0 +1,17 @@
+package config
+
+type FollowConfig struct {
+	MaxFollows int `yaml:"max_follows"`
+	// DigestCheckMinutes is how often the digest job looks for users whose
+	// daily or weekly digest is due. 0 disables digests.
+	DigestCheckMinutes int `yaml:"digest_check_minutes"`
+	DigestMaxAxes      int `yaml:"digest_max_axes"`
+}
+
+func DefaultFollowConfig() *FollowConfig {
+	return &FollowConfig{
+		MaxFollows:         200,
+		DigestCheckMinutes: 60,
+		DigestMaxAxes:      30,
+	}
+}
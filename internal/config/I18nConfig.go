@@ -0,0 +1,13 @@
+package config
+
+type I18nConfig struct {
+	// DefaultLanguage answers requests without a supported Accept-Language
+	// and is assumed for axes created without a language.
+	DefaultLanguage string `yaml:"default_language"`
+}
+
+func DefaultI18nConfig() *I18nConfig {
+	return &I18nConfig{
+		DefaultLanguage: "en",
+	}
+}
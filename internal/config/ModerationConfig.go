@@ -0,0 +1,15 @@
+package config
+
+type ModerationConfig struct {
+	// AutoHideThreshold hides reported content once this many different
+	// users reported it, until a moderator resolves the case. 0 disables it.
+	AutoHideThreshold int `yaml:"auto_hide_threshold"`
+	MaxReportsPerDay  int `yaml:"max_reports_per_day"`
+}
+
+func DefaultModerationConfig() *ModerationConfig {
+	return &ModerationConfig{
+		AutoHideThreshold: 5,
+		MaxReportsPerDay:  50,
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+type NotifyConfig struct {
+	// HeartbeatSeconds is how often an idle event stream gets a comment line
+	// so proxies do not close it.
+	HeartbeatSeconds int `yaml:"heartbeat_seconds"`
+	// RetentionDays bounds how far back a reconnecting client can replay.
+	RetentionDays int `yaml:"retention_days"`
+}
+
+func DefaultNotifyConfig() *NotifyConfig {
+	return &NotifyConfig{
+		HeartbeatSeconds: 25,
+		RetentionDays:    7,
+	}
+}
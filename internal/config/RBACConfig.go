@@ -0,0 +1,11 @@
+package config
+
+type RBACConfig struct {
+	InitialAdmin string `yaml:"initial_admin"`
+}
+
+func DefaultRBACConfig() *RBACConfig {
+	return &RBACConfig{
+		InitialAdmin: "",
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 type ServerConfig struct {
+	// PublicURL is the address users reach the site at, used for links in mail.
+	PublicURL string `yaml:"public_url"`
 }
 
 func DefaultServerConfig() *ServerConfig {
-	return &ServerConfig{}
+	return &ServerConfig{
+		PublicURL: "http://localhost:8080",
+	}
 }
@@ -0,0 +1,38 @@
+package config
+
+type StorageConfig struct {
+	// Backend is local or s3.
+	Backend string `yaml:"backend"`
+	// LocalRoot holds the files of the local backend, empty means
+	// <executable dir>/data/storage.
+	LocalRoot string   `yaml:"local_root"`
+	S3        S3Config `yaml:"s3"`
+	// SigningSecret signs the download links of the local backend. When
+	// empty a random one is used, so links stop working on restart.
+	SigningSecret    string `yaml:"signing_secret"`
+	URLExpiryMinutes int    `yaml:"url_expiry_minutes"`
+}
+
+// S3Config works with AWS S3 and S3 compatible servers such as MinIO, which
+// usually need PathStyle.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"`
+}
+
+func DefaultStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Backend:   "local",
+		LocalRoot: "",
+		S3: S3Config{
+			Endpoint: "https://s3.amazonaws.com",
+			Region:   "us-east-1",
+		},
+		SigningSecret:    "",
+		URLExpiryMinutes: 15,
+	}
+}
@@ -0,0 +1,15 @@
+package config
+
+type TokenConfig struct {
+	DefaultExpireDays int `yaml:"default_expire_days"`
+	MaxExpireDays     int `yaml:"max_expire_days"`
+	MaxTokensPerUser  int `yaml:"max_tokens_per_user"`
+}
+
+func DefaultTokenConfig() *TokenConfig {
+	return &TokenConfig{
+		DefaultExpireDays: 90,
+		MaxExpireDays:     365,
+		MaxTokensPerUser:  20,
+	}
+}
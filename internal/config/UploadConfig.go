@@ -0,0 +1,40 @@
+package config
+
+type UploadConfig struct {
+	MaxAvatarMB     int `yaml:"max_avatar_mb"`
+	MaxScreenshotMB int `yaml:"max_screenshot_mb"`
+	MaxVideoMB      int `yaml:"max_video_mb"`
+	// MaxImagePixels rejects images that are small on disk but huge once
+	// decoded.
+	MaxImagePixels int `yaml:"max_image_pixels"`
+	// Archive limits guard against zip bombs, they are checked against the
+	// sizes the archive declares before anything is extracted.
+	MaxArchiveEntries        int `yaml:"max_archive_entries"`
+	MaxArchiveUncompressedMB int `yaml:"max_archive_uncompressed_mb"`
+	MaxArchiveRatio          int `yaml:"max_archive_ratio"`
+	// ResumableDir keeps the data of unfinished resumable uploads, empty
+	// means <executable dir>/data/resumable. Every chunk of an upload has to
+	// reach the instance holding it.
+	ResumableDir         string `yaml:"resumable_dir"`
+	ResumableExpiryHours int    `yaml:"resumable_expiry_hours"`
+	// Unfinished resumable uploads hold disk space until they expire, so
+	// each user may only have this many, announcing this much in total.
+	MaxResumablePerUser   int `yaml:"max_resumable_per_user"`
+	MaxResumablePendingMB int `yaml:"max_resumable_pending_mb"`
+}
+
+func DefaultUploadConfig() *UploadConfig {
+	return &UploadConfig{
+		MaxAvatarMB:              2,
+		MaxScreenshotMB:          10,
+		MaxVideoMB:               500,
+		MaxImagePixels:           40_000_000,
+		MaxArchiveEntries:        5000,
+		MaxArchiveUncompressedMB: 256,
+		MaxArchiveRatio:          100,
+		ResumableDir:             "",
+		ResumableExpiryHours:     24,
+		MaxResumablePerUser:      5,
+		MaxResumablePendingMB:    2048,
+	}
+}
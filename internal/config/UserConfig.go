@@ -0,0 +1,15 @@
+package config
+
+type UserConfig struct {
+	DeletionGraceDays    int `yaml:"deletion_grace_days"`
+	PurgeIntervalMinutes int `yaml:"purge_interval_minutes"`
+	SessionTTLHours      int `yaml:"session_ttl_hours"`
+}
+
+func DefaultUserConfig() *UserConfig {
+	return &UserConfig{
+		DeletionGraceDays:    30,
+		PurgeIntervalMinutes: 60,
+		SessionTTLHours:      24 * 7,
+	}
+}
@@ -13,16 +13,38 @@ import (
 var Config *GOBAASConfig
 
 type GOBAASConfig struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Mail     MailConfig     `yaml:"mail"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Mail       MailConfig       `yaml:"mail"`
+	User       UserConfig       `yaml:"user"`
+	RBAC       RBACConfig       `yaml:"rbac"`
+	Token      TokenConfig      `yaml:"token"`
+	Catalog    CatalogConfig    `yaml:"catalog"`
+	Axis       AxisConfig       `yaml:"axis"`
+	Moderation ModerationConfig `yaml:"moderation"`
+	Notify     NotifyConfig     `yaml:"notify"`
+	Follow     FollowConfig     `yaml:"follow"`
+	I18n       I18nConfig       `yaml:"i18n"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Upload     UploadConfig     `yaml:"upload"`
 }
 
 func GenerateDefaultConfig() *GOBAASConfig {
 	return &GOBAASConfig{
-		Server:   *DefaultServerConfig(),
-		Database: *DefaultDatabaseConfig(),
-		Mail:     *DefaultMailConfig(),
+		Server:     *DefaultServerConfig(),
+		Database:   *DefaultDatabaseConfig(),
+		Mail:       *DefaultMailConfig(),
+		User:       *DefaultUserConfig(),
+		RBAC:       *DefaultRBACConfig(),
+		Token:      *DefaultTokenConfig(),
+		Catalog:    *DefaultCatalogConfig(),
+		Axis:       *DefaultAxisConfig(),
+		Moderation: *DefaultModerationConfig(),
+		Notify:     *DefaultNotifyConfig(),
+		Follow:     *DefaultFollowConfig(),
+		I18n:       *DefaultI18nConfig(),
+		Storage:    *DefaultStorageConfig(),
+		Upload:     *DefaultUploadConfig(),
 	}
 }
 
@@ -3,6 +3,7 @@ package database
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -25,6 +26,8 @@ func Init() error {
 	SqlConfig.User = config.Config.Database.Username
 	SqlConfig.Passwd = config.Config.Database.Password
 	SqlConfig.DBName = config.Config.Database.Name
+	SqlConfig.ParseTime = true
+	SqlConfig.ClientFoundRows = true
 	logger.BAASInfo("Addr   :", SqlConfig.Addr)
 	logger.BAASInfo("DBName :", SqlConfig.DBName)
 	logger.BAASInfo("User   :", SqlConfig.User)
@@ -40,12 +43,40 @@ func Init() error {
 	DB.SetConnMaxLifetime(time.Duration(config.Config.Database.ConnMaxLifetime) * time.Minute)
 
 	if err := DB.Ping(); err != nil {
-		return errors.New("Database Ping Error : " + err.Error())
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || mysqlErr.Number != errBadDB {
+			return errors.New("Database Ping Error : " + err.Error())
+		}
+		if err := createDatabase(); err != nil {
+			return errors.New("Database Create Error : " + err.Error())
+		}
+		if err := DB.Ping(); err != nil {
+			return errors.New("Database Ping Error : " + err.Error())
+		}
 	}
 
 	return nil
 }
 
+// errBadDB is ER_BAD_DB_ERROR, the configured database does not exist.
+const errBadDB = 1049
+
+// createDatabase creates the configured database, the migrations only
+// create what goes into it.
+func createDatabase() error {
+	logger.BAASWarn("Database", SqlConfig.DBName, "does not exist, creating it")
+	cfg := SqlConfig
+	cfg.DBName = ""
+	db, err := sqlx.Open(SqlDriver, cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	name := "`" + strings.ReplaceAll(SqlConfig.DBName, "`", "``") + "`"
+	_, err = db.Exec(`CREATE DATABASE IF NOT EXISTS ` + name + ` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci`)
+	return err
+}
+
 func Close() error {
 	if DB != nil {
 		return DB.Close()
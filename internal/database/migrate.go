@@ -0,0 +1,379 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+var (
+	ErrChecksumDrift    = errors.New("applied migrations were changed")
+	ErrUnknownMigration = errors.New("database has migrations this build does not know")
+	ErrNoDownMigration  = errors.New("migration has no down script")
+	ErrMigrationLocked  = errors.New("another instance is migrating the database")
+	ErrNeedsBaseline    = errors.New("database has tables but no migration history, run migrate baseline <version> first")
+	ErrAlreadyMigrated  = errors.New("database already has a migration history")
+)
+
+// migrationLockTimeout is how long, in seconds, an instance waits for
+// another one to finish migrating.
+const migrationLockTimeout = 60
+
+const createMigrationTable = "CREATE TABLE IF NOT EXISTS `schema_migrations` (" + `
+    version INT UNSIGNED PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL COMMENT 'SHA-256(升级脚本)',
+    time_apply DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+// migrationFile matches NNN_name.sql and its revert script NNN_name.down.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+?)(\.down)?\.sql$`)
+
+// Migration is one numbered script of the sql directory.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	HasDown  bool
+	Checksum string
+}
+
+// AppliedMigration is a row of schema_migrations.
+type AppliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	TimeApply time.Time `db:"time_apply"`
+}
+
+// MigrationState pairs a migration with its record, Applied is nil while it
+// is pending.
+type MigrationState struct {
+	Migration
+	Applied *AppliedMigration
+	Drift   bool
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// LoadMigrations reads the migrations of fsys in version order. The checksum
+// covers the up script only, a down script may still be added later.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s : %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %s : version %d is used by %s too", entry.Name(), version, m.Name)
+		}
+		if match[3] != "" {
+			m.Down, m.HasDown = string(data), true
+			continue
+		}
+		sum := sha256.Sum256(data)
+		m.Up, m.Checksum = string(data), hex.EncodeToString(sum[:])
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s : down script without an up script", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrate applies every pending migration of fsys.
+func Migrate(fsys fs.FS) error {
+	return MigrateTo(fsys, 0)
+}
+
+// MigrateTo applies the pending migrations up to target, 0 meaning all of
+// them. MySQL commits DDL as it goes, so a script failing halfway is left
+// partly applied and unrecorded, and has to be cleaned up by hand.
+func MigrateTo(fsys fs.FS, target int) error {
+	return withMigrationLock(func(ctx context.Context, conn *sqlx.Conn) error {
+		states, err := migrationStates(ctx, conn, fsys)
+		if err != nil {
+			return err
+		}
+		if err := checkStates(ctx, conn, states); err != nil {
+			return err
+		}
+		applied := 0
+		for _, s := range states {
+			if s.Applied != nil || (target != 0 && s.Version > target) {
+				continue
+			}
+			logger.BAASInfo("Applying migration :", s.String())
+			if err := execScript(ctx, conn, &s.Migration, s.Up); err != nil {
+				return err
+			}
+			_, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`,
+				s.Version, s.Name, s.Checksum,
+			)
+			if err != nil {
+				return err
+			}
+			applied++
+		}
+		if applied == 0 {
+			logger.BAASInfo("Database schema is up to date")
+		}
+		return nil
+	})
+}
+
+// Rollback reverts applied migrations, newest first, until target is the
+// latest one left. It stops before a migration without a down script.
+func Rollback(fsys fs.FS, target int) error {
+	return withMigrationLock(func(ctx context.Context, conn *sqlx.Conn) error {
+		states, err := migrationStates(ctx, conn, fsys)
+		if err != nil {
+			return err
+		}
+		if err := checkStates(ctx, conn, states); err != nil {
+			return err
+		}
+		for i := len(states) - 1; i >= 0; i-- {
+			s := states[i]
+			if s.Applied == nil || s.Version <= target {
+				continue
+			}
+			if !s.HasDown {
+				return fmt.Errorf("%w : %s", ErrNoDownMigration, s.String())
+			}
+			logger.BAASInfo("Reverting migration :", s.String())
+			if err := execScript(ctx, conn, &s.Migration, s.Down); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, s.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Baseline records the migrations up to version as applied without running
+// them, for databases whose schema was set up by hand before migrations were
+// tracked.
+func Baseline(fsys fs.FS, version int) error {
+	return withMigrationLock(func(ctx context.Context, conn *sqlx.Conn) error {
+		states, err := migrationStates(ctx, conn, fsys)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			if s.Applied != nil {
+				return ErrAlreadyMigrated
+			}
+		}
+		for _, s := range states {
+			if s.Version > version {
+				break
+			}
+			_, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`,
+				s.Version, s.Name, s.Checksum,
+			)
+			if err != nil {
+				return err
+			}
+			logger.BAASInfo("Marked migration as applied :", s.String())
+		}
+		return nil
+	})
+}
+
+// MigrationStatus lists every migration with its record. Applied migrations
+// this build does not know are reported as ErrUnknownMigration along with
+// the list.
+func MigrationStatus(fsys fs.FS) ([]MigrationState, error) {
+	ctx := context.Background()
+	conn, err := DB.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return migrationStates(ctx, conn, fsys)
+}
+
+func migrationStates(ctx context.Context, conn *sqlx.Conn, fsys fs.FS) ([]MigrationState, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, createMigrationTable); err != nil {
+		return nil, err
+	}
+	applied := []AppliedMigration{}
+	if err := conn.SelectContext(ctx, &applied, `SELECT version, name, checksum, time_apply FROM schema_migrations`); err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*AppliedMigration, len(applied))
+	for i := range applied {
+		byVersion[applied[i].Version] = &applied[i]
+	}
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i] = MigrationState{Migration: m, Applied: byVersion[m.Version]}
+		states[i].Drift = states[i].Applied != nil && states[i].Applied.Checksum != m.Checksum
+		delete(byVersion, m.Version)
+	}
+	if len(byVersion) > 0 {
+		unknown := make([]string, 0, len(byVersion))
+		for _, a := range byVersion {
+			unknown = append(unknown, fmt.Sprintf("%03d_%s", a.Version, a.Name))
+		}
+		sort.Strings(unknown)
+		return states, fmt.Errorf("%w : %s", ErrUnknownMigration, strings.Join(unknown, ", "))
+	}
+	return states, nil
+}
+
+// checkStates refuses to migrate when applied scripts were edited since, or
+// when the schema predates migration tracking.
+func checkStates(ctx context.Context, conn *sqlx.Conn, states []MigrationState) error {
+	var drifted []string
+	tracked := false
+	for _, s := range states {
+		if s.Drift {
+			drifted = append(drifted, s.String())
+		}
+		tracked = tracked || s.Applied != nil
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("%w : %s", ErrChecksumDrift, strings.Join(drifted, ", "))
+	}
+	if tracked {
+		return nil
+	}
+	var tables int
+	err := conn.GetContext(ctx, &tables,
+		`SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name <> 'schema_migrations'`,
+	)
+	if err != nil {
+		return err
+	}
+	if tables > 0 {
+		return ErrNeedsBaseline
+	}
+	return nil
+}
+
+func execScript(ctx context.Context, conn *sqlx.Conn, m *Migration, script string) error {
+	for i, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %s statement %d : %w", m, i+1, err)
+		}
+	}
+	return nil
+}
+
+// withMigrationLock runs fn holding a MySQL advisory lock named after the
+// database, so instances starting together migrate one after another. The
+// lock belongs to a connection, so fn has to use conn.
+func withMigrationLock(fn func(ctx context.Context, conn *sqlx.Conn) error) error {
+	ctx := context.Background()
+	conn, err := DB.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	name := "go_baas_migrate:" + SqlConfig.DBName
+	var locked sql.NullInt64
+	if err := conn.GetContext(ctx, &locked, `SELECT GET_LOCK(?, ?)`, name, migrationLockTimeout); err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrMigrationLocked
+	}
+	defer func() {
+		var released sql.NullInt64
+		if err := conn.GetContext(ctx, &released, `SELECT RELEASE_LOCK(?)`, name); err != nil {
+			logger.BAASWarn("Migration Lock Release Error :", err.Error())
+		}
+	}()
+	return fn(ctx, conn)
+}
+
+// splitStatements cuts a script at the semicolons outside of quotes and
+// comments. Pieces holding nothing but comments are dropped.
+func splitStatements(script string) []string {
+	var statements []string
+	start := 0
+	hasCode := false
+	flush := func(end int) {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(script[start:end]))
+		}
+		start, hasCode = end+1, false
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			hasCode = true
+			for i++; i < len(script) && script[i] != c; i++ {
+				if script[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "--") &&
+			(i+2 == len(script) || script[i+2] == ' ' || script[i+2] == '\t' || script[i+2] == '\n' || script[i+2] == '\r')):
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			flush(i)
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			hasCode = true
+		}
+	}
+	flush(len(script))
+	return statements
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "missing final semicolon",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (\n    id INT\n)",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (\n    id INT\n)"},
+		},
+		{
+			name:   "semicolons in quotes",
+			script: "INSERT INTO t VALUES ('a;b', \"c;d\");SELECT `e;f` FROM t;",
+			want:   []string{"INSERT INTO t VALUES ('a;b', \"c;d\")", "SELECT `e;f` FROM t"},
+		},
+		{
+			name:   "escaped quotes",
+			script: `INSERT INTO t VALUES ('it\'s;', "say \";\"");SELECT 1;`,
+			want:   []string{`INSERT INTO t VALUES ('it\'s;', "say \";\"")`, "SELECT 1"},
+		},
+		{
+			name:   "doubled quotes",
+			script: "INSERT INTO t VALUES ('it''s;');SELECT 1;",
+			want:   []string{"INSERT INTO t VALUES ('it''s;')", "SELECT 1"},
+		},
+		{
+			name:   "dash comments",
+			script: "-- first; not a statement\nSELECT 1; -- trailing;\nSELECT 2;",
+			want:   []string{"-- first; not a statement\nSELECT 1", "-- trailing;\nSELECT 2"},
+		},
+		{
+			name:   "double dash without space is code",
+			script: "SELECT 1--2;",
+			want:   []string{"SELECT 1--2"},
+		},
+		{
+			name:   "hash comments",
+			script: "# a; b\nSELECT 1;# c;",
+			want:   []string{"# a; b\nSELECT 1"},
+		},
+		{
+			name:   "block comments",
+			script: "/* a; b */ SELECT 1 /* ; */ + 1;\n/* only a comment; */",
+			want:   []string{"/* a; b */ SELECT 1 /* ; */ + 1"},
+		},
+		{
+			name:   "comment only",
+			script: "-- 数据库本身不随迁移删除\n",
+			want:   nil,
+		},
+		{
+			name:   "empty statements",
+			script: ";;\n  ;SELECT 1;;",
+			want:   []string{"SELECT 1"},
+		},
+		{
+			name:   "quotes in comments",
+			script: "-- it's\nSELECT 1;/* \" */SELECT 2;",
+			want:   []string{"-- it's\nSELECT 1", "/* \" */SELECT 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.script)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q)\n got  %q\n want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_b.sql":      {Data: []byte("SELECT 2;")},
+		"001_a.sql":      {Data: []byte("SELECT 1;")},
+		"001_a.down.sql": {Data: []byte("SELECT -1;")},
+		"README.md":      {Data: []byte("not a migration")},
+	}
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].String() != "001_a" || migrations[1].String() != "002_b" {
+		t.Fatalf("unexpected migrations %v", migrations)
+	}
+	if !migrations[0].HasDown || migrations[0].Down != "SELECT -1;" || migrations[1].HasDown {
+		t.Errorf("down scripts not paired: %+v", migrations)
+	}
+
+	fsys["002_c.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 3;")}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Error("expected an error for two names sharing a version")
+	}
+}
+
+// TestRepositoryMigrations checks that every script of sql/ splits into
+// statements and can be reverted.
+func TestRepositoryMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(os.DirFS("../../sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %s : expected version %d", m.String(), i+1)
+		}
+		if !m.HasDown {
+			t.Errorf("migration %s has no down script", m.String())
+		}
+		if m.Version > 1 && len(splitStatements(m.Up)) == 0 {
+			t.Errorf("migration %s has no statements", m.String())
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package follow
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+	"github.com/pur1fying/GO_BAAS/internal/mail"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+const digestBatchSize = 100
+
+// dueSettings returns digests whose period has passed since the last one,
+// or since the settings were created for a first digest.
+func dueSettings() ([]DigestSettings, error) {
+	due := []DigestSettings{}
+	err := database.DB.Select(&due,
+		`SELECT user_id, frequency, unsubscribe_token, time_last_sent, time_create FROM digest_settings
+		WHERE (frequency = ? AND COALESCE(time_last_sent, time_create) <= NOW() - INTERVAL 1 DAY)
+			OR (frequency = ? AND COALESCE(time_last_sent, time_create) <= NOW() - INTERVAL 7 DAY)
+		ORDER BY user_id LIMIT ?`,
+		FrequencyDaily, FrequencyWeekly, digestBatchSize,
+	)
+	return due, err
+}
+
+// SendDueDigests mails every digest that is due and returns how many were
+// sent. A digest is marked sent before it is queued, so a failure skips a
+// period rather than mailing twice; users without matches get no mail.
+func SendDueDigests() (int, error) {
+	sent := 0
+	for {
+		due, err := dueSettings()
+		if err != nil {
+			return sent, err
+		}
+		for _, s := range due {
+			since := s.TimeCreate
+			if s.TimeLastSent != nil {
+				since = *s.TimeLastSent
+			}
+			_, err := database.DB.Exec(`UPDATE digest_settings SET time_last_sent = NOW() WHERE user_id = ?`, s.UserID)
+			if err != nil {
+				return sent, err
+			}
+			msg, err := buildDigest(&s, since)
+			if err != nil {
+				logger.BAASError("Digest Error :", strconv.FormatUint(s.UserID, 10), err.Error())
+				continue
+			}
+			if msg != nil {
+				mail.EnqueueWait(msg)
+				sent++
+			}
+		}
+		if len(due) < digestBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+func buildDigest(s *DigestSettings, since time.Time) (*mail.Message, error) {
+	u, err := user.GetByID(s.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u.EffectiveStatus(time.Now()) != user.StatusActive {
+		return nil, nil
+	}
+	follows, err := List(s.UserID)
+	if err != nil {
+		return nil, err
+	}
+	f := axis.DigestFilter{Since: since, ExcludeOwnerID: s.UserID, Limit: config.Config.Follow.DigestMaxAxes}
+	for _, each := range follows {
+		switch each.TargetType {
+		case TargetAuthor:
+			if id, err := strconv.ParseUint(each.TargetID, 10, 64); err == nil {
+				f.AuthorIDs = append(f.AuthorIDs, id)
+			}
+		case TargetStage:
+			f.StageIDs = append(f.StageIDs, each.TargetID)
+		case TargetStudent:
+			if id, err := strconv.ParseUint(each.TargetID, 10, 32); err == nil {
+				f.Students = append(f.Students, uint32(id))
+			}
+		}
+	}
+	axes, err := axis.ListForDigest(f)
+	if err != nil || len(axes) == 0 {
+		return nil, err
+	}
+
+	base := config.Config.Server.PublicURL
+	var text strings.Builder
+	text.WriteString("New and updated axes matching what you follow:\n\n")
+	for _, a := range axes {
+		state := "updated"
+		if a.TimeCreate.After(since) {
+			state = "new"
+		}
+		text.WriteString("- [" + state + "] " + a.Title + " (" + a.StageID + " " + a.Difficulty + ") by " + a.OwnerName + "\n")
+		text.WriteString("  " + base + "/axes/" + strconv.FormatUint(a.ID, 10) + "\n")
+	}
+	text.WriteString("\nYou get this " + s.Frequency + " digest because you follow authors, stages or students on GO_BAAS.\n")
+	text.WriteString("Unsubscribe: " + unsubscribeLink(s.UnsubscribeToken, nil) + "\n")
+	if len(follows) > 0 {
+		text.WriteString("\nStop following:\n")
+		for _, each := range follows {
+			text.WriteString("- " + each.TargetType + " " + each.TargetID + " : " + unsubscribeLink(s.UnsubscribeToken, &each) + "\n")
+		}
+	}
+	return &mail.Message{
+		TO:      []mail.Address{{Email: u.Email, DisplayName: u.Username}},
+		Subject: "Your " + s.Frequency + " GO_BAAS digest: " + strconv.Itoa(len(axes)) + " axes",
+		Text:    text.String(),
+	}, nil
+}
+
+// unsubscribeLink works without login, the token in it is the credential.
+func unsubscribeLink(token string, f *Follow) string {
+	q := url.Values{"token": {token}}
+	if f != nil {
+		q.Set("type", f.TargetType)
+		q.Set("target", f.TargetID)
+	}
+	return config.Config.Server.PublicURL + "/api/v1/follows/unsubscribe?" + q.Encode()
+}
+
+func StartDigestJob() {
+	interval := time.Duration(config.Config.Follow.DigestCheckMinutes) * time.Minute
+	if interval <= 0 {
+		logger.BAASWarn("Follow digest job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := SendDueDigests()
+			if err != nil {
+				logger.BAASError("Digest Job Error :", err.Error())
+			}
+			if n > 0 {
+				logger.BAASInfo("Digests sent :", strconv.Itoa(n))
+			}
+		}
+	}()
+}
@@ -0,0 +1,228 @@
+package follow
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/catalog"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+const (
+	TargetAuthor  = "author"
+	TargetStage   = "stage"
+	TargetStudent = "student"
+)
+
+const (
+	FrequencyOff    = "off"
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+var (
+	ErrNotFound       = errors.New("follow not found")
+	ErrUnknownTarget  = errors.New("unknown follow target")
+	ErrTooManyFollows = errors.New("too many follows")
+	ErrInvalidToken   = errors.New("invalid unsubscribe link")
+)
+
+type Follow struct {
+	TargetType string    `db:"target_type" json:"target_type"`
+	TargetID   string    `db:"target_id" json:"target_id"`
+	TimeCreate time.Time `db:"time_create" json:"time_create"`
+}
+
+type DigestSettings struct {
+	UserID           uint64     `db:"user_id" json:"-"`
+	Frequency        string     `db:"frequency" json:"frequency"`
+	UnsubscribeToken string     `db:"unsubscribe_token" json:"-"`
+	TimeLastSent     *time.Time `db:"time_last_sent" json:"time_last_sent"`
+	TimeCreate       time.Time  `db:"time_create" json:"-"`
+}
+
+func IsValidTargetType(t string) bool {
+	return t == TargetAuthor || t == TargetStage || t == TargetStudent
+}
+
+func IsValidFrequency(f string) bool {
+	return f == FrequencyOff || f == FrequencyDaily || f == FrequencyWeekly
+}
+
+func List(userID uint64) ([]Follow, error) {
+	follows := []Follow{}
+	err := database.DB.Select(&follows,
+		`SELECT target_type, target_id, time_create FROM follows WHERE user_id = ? ORDER BY time_create DESC`, userID,
+	)
+	return follows, err
+}
+
+// CheckTarget reports ErrUnknownTarget for authors, stages and students that
+// do not exist.
+func CheckTarget(targetType, targetID string) error {
+	switch targetType {
+	case TargetAuthor:
+		id, err := strconv.ParseUint(targetID, 10, 64)
+		if err != nil {
+			return ErrUnknownTarget
+		}
+		_, err = user.GetByID(id)
+		if errors.Is(err, user.ErrNotFound) {
+			return ErrUnknownTarget
+		}
+		return err
+	case TargetStage:
+		ok, err := catalog.StageExists(targetID)
+		if err == nil && !ok {
+			return ErrUnknownTarget
+		}
+		return err
+	case TargetStudent:
+		id, err := strconv.ParseUint(targetID, 10, 32)
+		if err != nil {
+			return ErrUnknownTarget
+		}
+		unknown, err := catalog.UnknownStudents([]uint32{uint32(id)})
+		if err == nil && len(unknown) > 0 {
+			return ErrUnknownTarget
+		}
+		return err
+	}
+	return ErrUnknownTarget
+}
+
+// Add follows a target, creating the digest settings on the first follow.
+// Following twice is not an error.
+func Add(userID uint64, targetType, targetID string, maxFollows int) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var count int
+	// Locking the user's follows keeps concurrent adds under the limit.
+	if err := tx.Get(&count, `SELECT COUNT(*) FROM follows WHERE user_id = ? FOR UPDATE`, userID); err != nil {
+		return err
+	}
+	if count >= maxFollows {
+		return ErrTooManyFollows
+	}
+	_, err = tx.Exec(
+		`INSERT IGNORE INTO follows (user_id, target_type, target_id) VALUES (?, ?, ?)`, userID, targetType, targetID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := ensureSettings(tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func Remove(userID uint64, targetType, targetID string) error {
+	res, err := database.DB.Exec(
+		`DELETE FROM follows WHERE user_id = ? AND target_type = ? AND target_id = ?`, userID, targetType, targetID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func GetSettings(userID uint64) (*DigestSettings, error) {
+	if err := ensureSettings(database.DB, userID); err != nil {
+		return nil, err
+	}
+	var s DigestSettings
+	err := database.DB.Get(&s,
+		`SELECT user_id, frequency, unsubscribe_token, time_last_sent, time_create FROM digest_settings WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func SetFrequency(userID uint64, frequency string) (*DigestSettings, error) {
+	if err := ensureSettings(database.DB, userID); err != nil {
+		return nil, err
+	}
+	_, err := database.DB.Exec(`UPDATE digest_settings SET frequency = ? WHERE user_id = ?`, frequency, userID)
+	if err != nil {
+		return nil, err
+	}
+	return GetSettings(userID)
+}
+
+// CheckUnsubscribeToken returns ErrInvalidToken unless token belongs to a
+// user.
+func CheckUnsubscribeToken(token string) error {
+	_, err := userOfToken(token)
+	return err
+}
+
+func userOfToken(token string) (uint64, error) {
+	var userID uint64
+	err := database.DB.Get(&userID, `SELECT user_id FROM digest_settings WHERE unsubscribe_token = ?`, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidToken
+	}
+	return userID, err
+}
+
+// Unsubscribe handles the link of a digest mail. Without a follow it turns
+// the digest off, with one it only drops that follow.
+func Unsubscribe(token string, targetType, targetID string) error {
+	userID, err := userOfToken(token)
+	if err != nil {
+		return err
+	}
+	if targetType != "" {
+		err := Remove(userID, targetType, targetID)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	_, err = database.DB.Exec(`UPDATE digest_settings SET frequency = ? WHERE user_id = ?`, FrequencyOff, userID)
+	return err
+}
+
+func ensureSettings(db sqlx.Execer, userID uint64) error {
+	token, err := user.NewToken()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT IGNORE INTO digest_settings (user_id, unsubscribe_token) VALUES (?, ?)`, userID, token,
+	)
+	return err
+}
+
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(
+		`DELETE FROM follows WHERE user_id = ? OR (target_type = ? AND target_id = ?)`,
+		userID, TargetAuthor, strconv.FormatUint(userID, 10),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM digest_settings WHERE user_id = ?`, userID)
+	return err
+}
+
+func ExportUser(userID uint64) (interface{}, error) {
+	return List(userID)
+}
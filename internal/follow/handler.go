@@ -0,0 +1,186 @@
+package follow
+
+import (
+	"errors"
+	"html/template"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+type digestRequest struct {
+	Frequency string `json:"frequency"`
+}
+
+func handlerList(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	follows, err := List(userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, follows)
+}
+
+func handlerAdd(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	targetType, targetID, ok := parseTarget(w, r)
+	if !ok {
+		return
+	}
+	err := CheckTarget(targetType, targetID)
+	if err == nil {
+		err = Add(userID, targetType, targetID, config.Config.Follow.MaxFollows)
+	}
+	if err != nil {
+		respondFollowError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerRemove(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	targetType, targetID, ok := parseTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := Remove(userID, targetType, targetID); err != nil {
+		respondFollowError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerGetDigest(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	s, err := GetSettings(userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, s)
+}
+
+func handlerSetDigest(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req digestRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if !IsValidFrequency(req.Frequency) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "frequency must be off, daily or weekly")
+		return
+	}
+	s, err := SetFrequency(userID, req.Frequency)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, s)
+}
+
+// unsubscribePage is what a mail client opening an unsubscribe link shows.
+// The link only asks for confirmation, as mail scanners and link previews
+// follow links on their own; the form posts back to apply it.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>GO_BAAS</title></head>
+<body>
+<p>{{.Message}}</p>
+{{if .Confirm}}<form method="post"><button type="submit">Unsubscribe</button></form>{{end}}
+</body>
+</html>
+`))
+
+// handlerConfirmUnsubscribe serves the links of digest mails. It changes
+// nothing and answers with a page confirming through POST.
+func handlerConfirmUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	targetType, ok := parseUnsubscribeType(w, q.Get("type"))
+	if !ok {
+		return
+	}
+	err := CheckUnsubscribeToken(q.Get("token"))
+	if errors.Is(err, ErrInvalidToken) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	msg := "Stop receiving digest mails?"
+	if targetType != "" {
+		msg = "Stop following this " + targetType + "?"
+	}
+	writeUnsubscribePage(w, msg, true)
+}
+
+// handlerUnsubscribe applies an unsubscribe link, the token replaces the
+// login. The confirmation form gets a page back, other clients JSON.
+func handlerUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	targetType, ok := parseUnsubscribeType(w, q.Get("type"))
+	if !ok {
+		return
+	}
+	err := Unsubscribe(q.Get("token"), targetType, q.Get("target"))
+	if errors.Is(err, ErrInvalidToken) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	msg := "You will no longer receive digest mails."
+	if targetType != "" {
+		msg = "You no longer follow this " + targetType + "."
+	}
+	if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t == "application/x-www-form-urlencoded" {
+		writeUnsubscribePage(w, msg, false)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]string{"message": msg})
+}
+
+func parseUnsubscribeType(w http.ResponseWriter, targetType string) (string, bool) {
+	if targetType != "" && !IsValidTargetType(targetType) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid follow type")
+		return "", false
+	}
+	return targetType, true
+}
+
+func writeUnsubscribePage(w http.ResponseWriter, msg string, confirm bool) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	unsubscribePage.Execute(w, map[string]interface{}{"Message": msg, "Confirm": confirm})
+}
+
+func parseTarget(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	targetType := chi.URLParam(r, "type")
+	if !IsValidTargetType(targetType) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "follow type must be author, stage or student")
+		return "", "", false
+	}
+	return targetType, chi.URLParam(r, "target"), true
+}
+
+func respondFollowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownTarget), errors.Is(err, ErrNotFound):
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+	case errors.Is(err, ErrTooManyFollows):
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+	default:
+		api.ResponseWithInternalError(w, err)
+	}
+}
@@ -0,0 +1,27 @@
+package follow
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/unsubscribe", handlerConfirmUnsubscribe)
+	r.Post("/unsubscribe", handlerUnsubscribe)
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireUser, api.RequireScope(api.ScopeProfileRead))
+		r.Get("/", handlerList)
+		r.Get("/digest", handlerGetDigest)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireUser, api.RequireScope(api.ScopeProfileWrite))
+		r.Put("/digest", handlerSetDigest)
+		r.Put("/{type}/{target}", handlerAdd)
+		r.Delete("/{type}/{target}", handlerRemove)
+	})
+	return r
+}
@@ -10,6 +10,9 @@ var GO_BAAS_EXECUTABLE_DIR string
 var GO_BAAS_CONFIG_DIR string
 var GO_BAAS_OUTPUT_DIR string
 var GO_BAAS_DEFAULT_CONFIG_PATH string
+var GO_BAAS_CATALOG_DIR string
+var GO_BAAS_STORAGE_DIR string
+var GO_BAAS_RESUMABLE_DIR string
 
 func InitGlobalInfo() {
 	GO_BAAS_EXECUTABLE_PATH, _ = os.Executable()
@@ -17,4 +20,7 @@ func InitGlobalInfo() {
 	GO_BAAS_OUTPUT_DIR = filepath.Join(GO_BAAS_EXECUTABLE_DIR, "output")
 	GO_BAAS_CONFIG_DIR = filepath.Join(GO_BAAS_EXECUTABLE_DIR, "config")
 	GO_BAAS_DEFAULT_CONFIG_PATH = filepath.Join(GO_BAAS_CONFIG_DIR, "global_config.yaml")
+	GO_BAAS_CATALOG_DIR = filepath.Join(GO_BAAS_EXECUTABLE_DIR, "data", "catalog")
+	GO_BAAS_STORAGE_DIR = filepath.Join(GO_BAAS_EXECUTABLE_DIR, "data", "storage")
+	GO_BAAS_RESUMABLE_DIR = filepath.Join(GO_BAAS_EXECUTABLE_DIR, "data", "resumable")
 }
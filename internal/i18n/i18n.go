@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+// Languages are primary subtags, one per game server community: Global
+// players read English, JP Japanese and CN Simplified Chinese.
+const (
+	English  = "en"
+	Japanese = "ja"
+	Chinese  = "zh"
+)
+
+var Supported = []string{English, Japanese, Chinese}
+
+func IsSupported(lang string) bool {
+	for _, v := range Supported {
+		if v == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Default is the configured default language, English when it is not
+// supported.
+func Default() string {
+	if lang := Normalize(config.Config.I18n.DefaultLanguage); lang != "" {
+		return lang
+	}
+	return English
+}
+
+// Normalize maps a language tag such as "ja-JP" or "zh-Hans-CN" to a
+// supported language, or "" when there is none.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if IsSupported(tag) {
+		return tag
+	}
+	return ""
+}
+
+// ParseAcceptLanguage returns the supported languages of an Accept-Language
+// header, most preferred first. Unsupported tags, "*" and q=0 are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		lang := Normalize(fields[0])
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if v, ok := strings.CutPrefix(param, "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{lang, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	var langs []string
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if !seen[t.lang] {
+			seen[t.lang] = true
+			langs = append(langs, t.lang)
+		}
+	}
+	return langs
+}
+
+// Best returns the first preferred language that is available, or "" when
+// none is.
+func Best(prefs []string, available []string) string {
+	for _, lang := range prefs {
+		for _, v := range available {
+			if v == lang {
+				return lang
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"sync"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+const queueSize = 256
+
+var startQueueOnce sync.Once
+
+// Enqueue hands a message to the background sender so request handlers do
+// not wait on SMTP. When the queue is full the message is dropped and logged.
+func Enqueue(msg *Message) {
+	select {
+	case mailQueue <- msg:
+	default:
+		logger.BAASWarn("Mail queue full, dropped :", msg.Subject)
+	}
+}
+
+// EnqueueWait is Enqueue for background jobs sending many messages, it
+// waits for room instead of dropping.
+func EnqueueWait(msg *Message) {
+	if mailQueue == nil {
+		logger.BAASWarn("Mail queue not started, dropped :", msg.Subject)
+		return
+	}
+	mailQueue <- msg
+}
+
+func startQueue() {
+	startQueueOnce.Do(func() {
+		mailQueue = make(chan *Message, queueSize)
+		go func() {
+			for msg := range mailQueue {
+				deliver(msg)
+			}
+		}()
+	})
+}
+
+// deliver sends msg, redialing between attempts as the SMTP connection is
+// long lived and the server may have closed it.
+func deliver(msg *Message) {
+	if msg.From.Email == "" {
+		msg.From.Email = config.Config.Mail.From
+	}
+	var err error
+	for attempt := 0; attempt <= int(config.Config.Mail.Retry); attempt++ {
+		if _SMTPClient == nil || attempt > 0 {
+			if err = DialAndAuth(); err != nil {
+				continue
+			}
+		}
+		if err = SendMail(msg); err == nil {
+			return
+		}
+	}
+	logger.BAASError("Send Mail Error :", msg.Subject, ":", err.Error())
+}
@@ -38,6 +38,8 @@ func InitMail() error {
 	authCode = config.Config.Mail.AuthCode
 	smtpAddr = fmt.Sprintf("%s:%d", smtpHost, smtpPort)
 
+	startQueue()
+
 	logger.HighLight("Init Mail")
 	logger.BAASInfo("Addr :", smtpAddr)
 	if err := checkPort(); err != nil {
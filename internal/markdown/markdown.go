@@ -0,0 +1,275 @@
+// Package markdown renders the small Markdown subset used in user comments.
+//
+// Raw HTML is never passed through: all text is escaped and the only tags in
+// the output are the ones the renderer writes itself, so the result is safe
+// to embed without a separate sanitizer. Link targets are limited to http,
+// https and mailto.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const maxQuoteDepth = 4
+
+var (
+	headingRe     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	unorderedRe   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedRe     = regexp.MustCompile(`^\s*\d{1,9}[.)]\s+(.*)$`)
+	fenceLangRe   = regexp.MustCompile(`^[A-Za-z0-9_+-]{1,20}$`)
+	usernameChars = func(r rune) bool {
+		return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.')
+	}
+)
+
+// Render converts src to HTML.
+func Render(src string) string {
+	r := &renderer{}
+	return r.blocks(splitLines(src), 0)
+}
+
+// Mentions returns the distinct @usernames in src, in order of appearance.
+// Mentions inside code are ignored.
+func Mentions(src string) []string {
+	r := &renderer{seen: map[string]bool{}}
+	r.blocks(splitLines(src), 0)
+	return r.mentions
+}
+
+type renderer struct {
+	mentions []string
+	seen     map[string]bool
+}
+
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	return strings.Split(src, "\n")
+}
+
+func (r *renderer) blocks(lines []string, depth int) string {
+	var b strings.Builder
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		b.WriteString("<p>")
+		for i, line := range paragraph {
+			if i > 0 {
+				b.WriteString("<br>\n")
+			}
+			b.WriteString(r.inline(strings.TrimSpace(line)))
+		}
+		b.WriteString("</p>\n")
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if fenceLangRe.MatchString(lang) {
+				b.WriteString(` class="language-` + lang + `"`)
+			}
+			b.WriteString(">")
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case headingRe.MatchString(trimmed):
+			flush()
+			m := headingRe.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			b.WriteString("<h" + level + ">" + r.inline(m[2]) + "</h" + level + ">\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			if depth >= maxQuoteDepth {
+				paragraph = append(paragraph, quoted...)
+				continue
+			}
+			b.WriteString("<blockquote>\n" + r.blocks(quoted, depth+1) + "</blockquote>\n")
+
+		case unorderedRe.MatchString(line):
+			flush()
+			i = r.list(&b, lines, i, unorderedRe, "ul")
+
+		case orderedRe.MatchString(line):
+			flush()
+			i = r.list(&b, lines, i, orderedRe, "ol")
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// list writes consecutive items matching re and returns the index of the
+// last line consumed.
+func (r *renderer) list(b *strings.Builder, lines []string, i int, re *regexp.Regexp, tag string) int {
+	b.WriteString("<" + tag + ">\n")
+	for ; i < len(lines) && re.MatchString(lines[i]); i++ {
+		b.WriteString("<li>" + r.inline(re.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i - 1
+}
+
+func (r *renderer) inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		rest := s[i:]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()#>@-+.!", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(s[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(s[i+2:], "**"); end > 0 {
+				b.WriteString("<strong>" + r.inline(s[i+2:i+2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+
+		case (c == '*' || c == '_') && (c == '*' || i == 0 || !isWordByte(s[i-1])):
+			if end := strings.IndexByte(s[i+1:], c); end > 0 && s[i+1] != ' ' {
+				closeAt := i + 1 + end
+				if c == '*' || closeAt+1 >= len(s) || !isWordByte(s[closeAt+1]) {
+					b.WriteString("<em>" + r.inline(s[i+1:closeAt]) + "</em>")
+					i = closeAt + 1
+					continue
+				}
+			}
+
+		case c == '[':
+			if text, target, n, ok := parseLink(rest); ok {
+				if href, safe := safeURL(target); safe {
+					b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer">` + r.inline(text) + "</a>")
+				} else {
+					b.WriteString(r.inline(text))
+				}
+				i += n
+				continue
+			}
+
+		case (strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) && (i == 0 || !isWordByte(s[i-1])):
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '<' || r == '>' || r == '"' })
+			if end < 0 {
+				end = len(rest)
+			}
+			link := strings.TrimRight(rest[:end], ".,;:!?)")
+			if href, safe := safeURL(link); safe && len(link) > len("https://") {
+				b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer">` + html.EscapeString(link) + "</a>")
+				i += len(link)
+				continue
+			}
+
+		case c == '@' && (i == 0 || !isWordByte(s[i-1])):
+			end := strings.IndexFunc(rest[1:], func(r rune) bool { return !usernameChars(r) })
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := strings.TrimRight(rest[1:1+end], ".-")
+			if name != "" && len(name) <= 50 {
+				r.mention(name)
+				b.WriteString(`<span class="mention" data-username="` + html.EscapeString(name) + `">@` + html.EscapeString(name) + "</span>")
+				i += 1 + len(name)
+				continue
+			}
+		}
+		if strings.IndexByte(`<>&'"`, c) >= 0 {
+			b.WriteString(html.EscapeString(s[i : i+1]))
+		} else {
+			b.WriteByte(c)
+		}
+		i++
+	}
+	return b.String()
+}
+
+func (r *renderer) mention(name string) {
+	if r.seen == nil || r.seen[strings.ToLower(name)] {
+		return
+	}
+	r.seen[strings.ToLower(name)] = true
+	r.mentions = append(r.mentions, name)
+}
+
+// parseLink matches [text](target) at the start of s and returns the number
+// of bytes it spans.
+func parseLink(s string) (string, string, int, bool) {
+	closeText := strings.Index(s, "](")
+	if closeText < 0 {
+		return "", "", 0, false
+	}
+	closeTarget, depth := -1, 0
+	for i, c := range s[closeText+2:] {
+		if c == '(' {
+			depth++
+		} else if c == ')' {
+			if depth == 0 {
+				closeTarget = i
+				break
+			}
+			depth--
+		}
+	}
+	if closeTarget < 0 {
+		return "", "", 0, false
+	}
+	target := strings.TrimSpace(s[closeText+2 : closeText+2+closeTarget])
+	return s[1:closeText], target, closeText + 3 + closeTarget, true
+}
+
+func safeURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,190 @@
+package moderation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type reportRequest struct {
+	TargetType string  `json:"target_type"`
+	TargetID   uint64  `json:"target_id"`
+	Reason     string  `json:"reason"`
+	Details    *string `json:"details"`
+}
+
+type resolveRequest struct {
+	Resolution string  `json:"resolution"`
+	Reason     *string `json:"reason"`
+}
+
+func handlerReport(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req reportRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.TargetType != TargetAxis && req.TargetType != TargetComment {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "target_type must be axis or comment")
+		return
+	}
+	if !IsValidReason(req.Reason) {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "reason must be one of "+strings.Join(Reasons, ", "))
+		return
+	}
+	if req.Details != nil {
+		details := strings.TrimSpace(*req.Details)
+		if utf8.RuneCountInString(details) > 1000 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "details must be at most 1000 characters")
+			return
+		}
+		req.Details = &details
+	}
+	report, err := File(userID, req.TargetType, req.TargetID, req.Reason, req.Details)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, report)
+}
+
+func handlerListCases(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := CaseFilter{Status: q.Get("status"), TargetType: q.Get("target_type"), Limit: defaultPageSize}
+	switch f.Status {
+	case "", StatusOpen, StatusClaimed, StatusResolved:
+	default:
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown status")
+		return
+	}
+	page := 1
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page")
+			return
+		}
+		page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid page_size")
+			return
+		}
+		f.Limit = n
+	}
+	f.Offset = (page - 1) * f.Limit
+	cases, err := ListCases(f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"page":      page,
+		"page_size": f.Limit,
+		"items":     cases,
+	})
+}
+
+func handlerGetCase(w http.ResponseWriter, r *http.Request) {
+	id, ok := caseID(w, r)
+	if !ok {
+		return
+	}
+	c, err := GetCase(id)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, c)
+}
+
+func handlerClaimCase(w http.ResponseWriter, r *http.Request) {
+	id, ok := caseID(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if err := Claim(id, userID); err != nil {
+		respondError(w, err)
+		return
+	}
+	handlerGetCase(w, r)
+}
+
+func handlerUnclaimCase(w http.ResponseWriter, r *http.Request) {
+	id, ok := caseID(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	if err := Unclaim(id, userID); err != nil {
+		respondError(w, err)
+		return
+	}
+	handlerGetCase(w, r)
+}
+
+func handlerResolveCase(w http.ResponseWriter, r *http.Request) {
+	id, ok := caseID(w, r)
+	if !ok {
+		return
+	}
+	var req resolveRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	switch req.Resolution {
+	case ResolutionDismiss, ResolutionHide, ResolutionDelete:
+	default:
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "resolution must be dismiss, hide or delete")
+		return
+	}
+	if req.Reason != nil && utf8.RuneCountInString(*req.Reason) > 500 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "reason must be at most 500 characters")
+		return
+	}
+	userID, _ := api.CurrentUserID(r)
+	c, err := Resolve(id, userID, req.Resolution, req.Reason)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, c)
+}
+
+func caseID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid case id")
+	}
+	return id, ok
+}
+
+func respondError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTargetNotFound), errors.Is(err, ErrCaseNotFound):
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+	case errors.Is(err, ErrOwnContent):
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+	case errors.Is(err, ErrTooManyReports):
+		api.ResponseWithError(w, http.StatusTooManyRequests, api.CodeRateLimited, err.Error())
+	case errors.Is(err, ErrAlreadyReported), errors.Is(err, ErrCaseResolved),
+		errors.Is(err, ErrCaseClaimed), errors.Is(err, ErrCaseNotClaimedBy):
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+	default:
+		api.ResponseWithInternalError(w, err)
+	}
+}
@@ -0,0 +1,408 @@
+package moderation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/audit"
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+const (
+	TargetAxis    = "axis"
+	TargetComment = "comment"
+)
+
+var Reasons = []string{"spam", "stolen", "broken", "offensive"}
+
+const (
+	StatusOpen     = "open"
+	StatusClaimed  = "claimed"
+	StatusResolved = "resolved"
+)
+
+const (
+	ResolutionDismiss = "dismiss"
+	ResolutionHide    = "hide"
+	ResolutionDelete  = "delete"
+)
+
+var (
+	ErrTargetNotFound   = errors.New("reported content not found")
+	ErrOwnContent       = errors.New("cannot report your own content")
+	ErrAlreadyReported  = errors.New("already reported")
+	ErrTooManyReports   = errors.New("too many reports today")
+	ErrCaseNotFound     = errors.New("moderation case not found")
+	ErrCaseResolved     = errors.New("moderation case already resolved")
+	ErrCaseClaimed      = errors.New("moderation case claimed by another moderator")
+	ErrCaseNotClaimedBy = errors.New("moderation case is not claimed by you")
+)
+
+// Case collects every report against one piece of content until a
+// moderator resolves it. A new report after that opens a new case.
+type Case struct {
+	ID               uint64     `db:"id" json:"id"`
+	TargetType       string     `db:"target_type" json:"target_type"`
+	TargetID         uint64     `db:"target_id" json:"target_id"`
+	Status           string     `db:"status" json:"status"`
+	ReportCount      uint32     `db:"report_count" json:"report_count"`
+	AutoHidden       bool       `db:"auto_hidden" json:"auto_hidden"`
+	ClaimedBy        *uint64    `db:"claimed_by" json:"claimed_by"`
+	ClaimedByName    *string    `db:"claimed_by_name" json:"claimed_by_name"`
+	TimeClaimed      *time.Time `db:"time_claimed" json:"time_claimed"`
+	Resolution       *string    `db:"resolution" json:"resolution"`
+	ResolutionReason *string    `db:"resolution_reason" json:"resolution_reason"`
+	ResolvedBy       *uint64    `db:"resolved_by" json:"resolved_by"`
+	TimeResolved     *time.Time `db:"time_resolved" json:"time_resolved"`
+	TimeCreate       time.Time  `db:"time_create" json:"time_create"`
+	Reports          []Report   `db:"-" json:"reports,omitempty"`
+}
+
+type Report struct {
+	ID           uint64    `db:"id" json:"id"`
+	CaseID       uint64    `db:"case_id" json:"case_id"`
+	ReporterID   uint64    `db:"reporter_id" json:"reporter_id"`
+	ReporterName string    `db:"reporter_name" json:"reporter_name"`
+	Reason       string    `db:"reason" json:"reason"`
+	Details      *string   `db:"details" json:"details"`
+	TimeCreate   time.Time `db:"time_create" json:"time_create"`
+}
+
+type CaseFilter struct {
+	Status     string
+	TargetType string
+	Offset     int
+	Limit      int
+}
+
+const caseColumns = `c.id, c.target_type, c.target_id, c.status, c.report_count, c.auto_hidden,
+	c.claimed_by, u.username AS claimed_by_name, c.time_claimed, c.resolution, c.resolution_reason,
+	c.resolved_by, c.time_resolved, c.time_create`
+
+const caseFrom = ` FROM moderation_cases c LEFT JOIN users u ON u.id = c.claimed_by `
+
+func IsValidReason(reason string) bool {
+	for _, each := range Reasons {
+		if each == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// File records a report and opens a case for the target if none is open.
+// When enough different users reported the target it is hidden until a
+// moderator looks at it.
+func File(reporterID uint64, targetType string, targetID uint64, reason string, details *string) (*Report, error) {
+	t, err := loadTarget(targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if !t.visibleTo(reporterID) {
+		return nil, ErrTargetNotFound
+	}
+	if t.ownerID == reporterID {
+		return nil, ErrOwnContent
+	}
+	var today int
+	err = database.DB.Get(&today,
+		`SELECT COUNT(*) FROM reports WHERE reporter_id = ? AND time_create > DATE_SUB(NOW(), INTERVAL 1 DAY)`, reporterID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if today >= config.Config.Moderation.MaxReportsPerDay {
+		return nil, ErrTooManyReports
+	}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(
+		`INSERT INTO moderation_cases (target_type, target_id, open_target) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`,
+		targetType, targetID, openTarget(targetType, targetID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	caseID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	res, err = tx.Exec(
+		`INSERT IGNORE INTO reports (case_id, reporter_id, reason, details) VALUES (?, ?, ?, ?)`,
+		caseID, reporterID, reason, details,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrAlreadyReported
+	}
+	reportID, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE moderation_cases SET report_count = report_count + 1 WHERE id = ?`, caseID); err != nil {
+		return nil, err
+	}
+	var count uint32
+	if err := tx.Get(&count, `SELECT report_count FROM moderation_cases WHERE id = ?`, caseID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	threshold := config.Config.Moderation.AutoHideThreshold
+	if threshold > 0 && int(count) >= threshold {
+		if err := autoHide(uint64(caseID), t, count); err != nil {
+			logger.BAASError("Moderation Auto Hide Error :", err.Error())
+		}
+	}
+
+	var report Report
+	err = database.DB.Get(&report,
+		`SELECT r.id, r.case_id, r.reporter_id, u.username AS reporter_name, r.reason, r.details, r.time_create
+		FROM reports r JOIN users u ON u.id = r.reporter_id WHERE r.id = ?`, reportID,
+	)
+	return &report, err
+}
+
+// autoHide hides the target of a case once. The auto_hidden flag doubles as
+// the lock so concurrent reports do not hide or mail twice.
+func autoHide(caseID uint64, t *target, count uint32) error {
+	if t.hidden() {
+		return nil
+	}
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(
+		`UPDATE moderation_cases SET auto_hidden = TRUE WHERE id = ? AND auto_hidden = FALSE AND status <> ?`,
+		caseID, StatusResolved,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	reason := "reported by " + strconv.FormatUint(uint64(count), 10) + " users"
+	if err := t.apply(tx, ResolutionHide, 0, &reason); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	t.notifyAuthor(ResolutionHide, nil, true)
+	return nil
+}
+
+func ListCases(f CaseFilter) ([]Case, error) {
+	query := `SELECT ` + caseColumns + caseFrom + `WHERE 1 = 1`
+	var args []interface{}
+	if f.Status != "" {
+		query += ` AND c.status = ?`
+		args = append(args, f.Status)
+	} else {
+		query += ` AND c.status <> ?`
+		args = append(args, StatusResolved)
+	}
+	if f.TargetType != "" {
+		query += ` AND c.target_type = ?`
+		args = append(args, f.TargetType)
+	}
+	args = append(args, f.Limit, f.Offset)
+	cases := []Case{}
+	err := database.DB.Select(&cases, query+` ORDER BY c.report_count DESC, c.id LIMIT ? OFFSET ?`, args...)
+	return cases, err
+}
+
+func GetCase(id uint64) (*Case, error) {
+	var c Case
+	err := database.DB.Get(&c, `SELECT `+caseColumns+caseFrom+`WHERE c.id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Reports = []Report{}
+	err = database.DB.Select(&c.Reports,
+		`SELECT r.id, r.case_id, r.reporter_id, u.username AS reporter_name, r.reason, r.details, r.time_create
+		FROM reports r JOIN users u ON u.id = r.reporter_id WHERE r.case_id = ? ORDER BY r.id`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Claim assigns an open case to a moderator so two people do not work on
+// it at once. Claiming a case you already hold is a no-op.
+func Claim(caseID, moderatorID uint64) error {
+	res, err := database.DB.Exec(
+		`UPDATE moderation_cases SET status = ?, claimed_by = ?, time_claimed = NOW()
+		WHERE id = ? AND (status = ? OR (status = ? AND claimed_by = ?))`,
+		StatusClaimed, moderatorID, caseID, StatusOpen, StatusClaimed, moderatorID,
+	)
+	if err != nil {
+		return err
+	}
+	return explainCaseUpdate(res, caseID)
+}
+
+func Unclaim(caseID, moderatorID uint64) error {
+	res, err := database.DB.Exec(
+		`UPDATE moderation_cases SET status = ?, claimed_by = NULL, time_claimed = NULL
+		WHERE id = ? AND status = ? AND claimed_by = ?`,
+		StatusOpen, caseID, StatusClaimed, moderatorID,
+	)
+	if err != nil {
+		return err
+	}
+	err = explainCaseUpdate(res, caseID)
+	if errors.Is(err, ErrCaseNotFound) {
+		return err
+	}
+	if err != nil {
+		return ErrCaseNotClaimedBy
+	}
+	return nil
+}
+
+// Resolve closes a case. Hiding or deleting acts on the reported content and
+// mails its author; dismissing restores content that was hidden
+// automatically.
+func Resolve(caseID, moderatorID uint64, resolution string, reason *string) (*Case, error) {
+	c, err := GetCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status == StatusResolved {
+		return nil, ErrCaseResolved
+	}
+	if c.Status == StatusClaimed && (c.ClaimedBy == nil || *c.ClaimedBy != moderatorID) {
+		return nil, ErrCaseClaimed
+	}
+	t, err := loadTarget(c.TargetType, c.TargetID)
+	if err != nil && !errors.Is(err, ErrTargetNotFound) {
+		return nil, err
+	}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	// The case is closed first. The update locks it until the action is
+	// applied, so of two moderators resolving at once only one acts.
+	res, err := tx.Exec(
+		`UPDATE moderation_cases SET status = ?, open_target = NULL, resolution = ?, resolution_reason = ?,
+			resolved_by = ?, time_resolved = NOW()
+		WHERE id = ? AND (status = ? OR (status = ? AND claimed_by = ?))`,
+		StatusResolved, resolution, reason, moderatorID, caseID, StatusOpen, StatusClaimed, moderatorID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := explainCaseUpdate(res, caseID); err != nil {
+		return nil, err
+	}
+	// Content removed in the meantime leaves nothing to act on, the case is
+	// still closed with the moderator's decision.
+	if t != nil {
+		action := resolution
+		if resolution == ResolutionDismiss {
+			action = ""
+			if c.AutoHidden && t.hidden() {
+				action = axis.ModerationUnhide
+			}
+		}
+		if action == ResolutionHide && t.hidden() {
+			action = ""
+		}
+		if action != "" {
+			if err := t.apply(tx, action, moderatorID, reason); err != nil {
+				return nil, err
+			}
+		}
+	}
+	details, err := json.Marshal(map[string]interface{}{
+		"target_type":  c.TargetType,
+		"target_id":    c.TargetID,
+		"resolution":   resolution,
+		"report_count": c.ReportCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = audit.Record(tx, audit.Entry{
+		ActorID:    audit.Actor(moderatorID),
+		Action:     "case.resolve",
+		TargetType: "moderation_case",
+		TargetID:   caseID,
+		Reason:     reason,
+		Details:    types.NullJSONText{JSONText: details, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if t != nil && resolution != ResolutionDismiss {
+		t.notifyAuthor(resolution, reason, false)
+	}
+	return GetCase(caseID)
+}
+
+// PurgeUser drops the reports filed by a purged user. Cases they claimed or
+// resolved keep pointing at the anonymized account.
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(
+		`UPDATE moderation_cases c JOIN reports r ON r.case_id = c.id AND r.reporter_id = ?
+		SET c.report_count = c.report_count - 1`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM reports WHERE reporter_id = ?`, userID)
+	return err
+}
+
+func explainCaseUpdate(res sql.Result, caseID uint64) error {
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+	c, err := GetCase(caseID)
+	if err != nil {
+		return err
+	}
+	if c.Status == StatusResolved {
+		return ErrCaseResolved
+	}
+	return ErrCaseClaimed
+}
+
+func openTarget(targetType string, targetID uint64) string {
+	return targetType + ":" + strconv.FormatUint(targetID, 10)
+}
@@ -0,0 +1,22 @@
+package moderation
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.With(api.RequireUser, api.RequireScope(api.ScopeAxisWrite)).Post("/reports", handlerReport)
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireSession, rbac.RequirePermission(rbac.PermAxisModerate))
+		r.Get("/cases", handlerListCases)
+		r.Get("/cases/{id}", handlerGetCase)
+		r.Post("/cases/{id}/claim", handlerClaimCase)
+		r.Post("/cases/{id}/unclaim", handlerUnclaimCase)
+		r.Post("/cases/{id}/resolve", handlerResolveCase)
+	})
+	return r
+}
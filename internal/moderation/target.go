@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+)
+
+// target is the reported content, an axis or a comment on one.
+type target struct {
+	axis    *axis.Axis
+	comment *axis.Comment
+	ownerID uint64
+}
+
+func loadTarget(targetType string, targetID uint64) (*target, error) {
+	t := &target{}
+	var err error
+	switch targetType {
+	case TargetAxis:
+		t.axis, err = axis.Get(targetID)
+	case TargetComment:
+		t.comment, err = axis.GetCommentByID(targetID)
+		if err == nil {
+			t.axis, err = axis.Get(t.comment.AxisID)
+		}
+	default:
+		return nil, ErrTargetNotFound
+	}
+	if errors.Is(err, axis.ErrNotFound) || errors.Is(err, axis.ErrCommentNotFound) {
+		return nil, ErrTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.comment != nil {
+		if t.comment.Status == axis.CommentDeleted {
+			return nil, ErrTargetNotFound
+		}
+		t.ownerID = t.comment.AuthorID
+	} else {
+		t.ownerID = t.axis.OwnerID
+	}
+	return t, nil
+}
+
+// visibleTo reports whether a user can see the content, only that can be
+// reported.
+func (t *target) visibleTo(userID uint64) bool {
+	if (t.axis.Visibility == axis.VisibilityPrivate || t.axis.IsHidden) && t.axis.OwnerID != userID {
+		return false
+	}
+	if t.comment != nil && t.comment.Status == axis.CommentHidden && t.comment.AuthorID != userID {
+		return false
+	}
+	return true
+}
+
+func (t *target) hidden() bool {
+	if t.comment != nil {
+		return t.comment.Status == axis.CommentHidden
+	}
+	return t.axis.IsHidden
+}
+
+// apply performs a moderation action on the content within tx, actorID 0
+// being the system. Both paths write the audit log.
+func (t *target) apply(tx *sqlx.Tx, action string, actorID uint64, reason *string) error {
+	if t.comment == nil {
+		return axis.ModerateAxisTx(tx, t.axis, action, actorID, reason)
+	}
+	status := axis.CommentVisible
+	switch action {
+	case axis.ModerationHide:
+		status = axis.CommentHidden
+	case axis.ModerationDelete:
+		status = axis.CommentDeleted
+	}
+	return axis.SetCommentStatusTx(tx, t.comment, status, actorID, reason)
+}
+
+// notifyAuthor tells the author their content was hidden or deleted.
+func (t *target) notifyAuthor(resolution string, reason *string, automatic bool) {
+	axis.NotifyModeration(t.axis, t.comment, resolution, reason, automatic)
+}
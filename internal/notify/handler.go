@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+const replayPageSize = 200
+
+// seenWindow is how long the stream remembers the ids it sent. Concurrent
+// publishers commit out of id order, so an event may arrive after one with a
+// higher id, but only shortly after.
+const seenWindow = time.Minute
+
+// handlerList is the polling alternative to the stream.
+func handlerList(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	after, ok := parseEventID(w, r.URL.Query().Get("after"))
+	if !ok {
+		return
+	}
+	events, err := Since(userID, after, replayPageSize)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, events)
+}
+
+// handlerStream serves Server-Sent Events. A reconnecting EventSource sends
+// Last-Event-ID and first gets everything it missed.
+func handlerStream(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	after, ok := parseEventID(w, lastID)
+	if !ok {
+		return
+	}
+	// Subscribing before the replay means nothing published in between is
+	// lost; duplicates are skipped by the ids already sent, not by comparing
+	// with the last id, which would drop events arriving out of order.
+	s := subscribe(userID)
+	seen := map[uint64]time.Time{}
+	defer unsubscribe(userID, s)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if rc.Flush() != nil {
+		return
+	}
+
+	for {
+		events, err := Since(userID, after, replayPageSize)
+		if err != nil {
+			return
+		}
+		for _, e := range events {
+			if writeEvent(w, e) != nil {
+				return
+			}
+			seen[e.ID] = time.Now()
+			after = e.ID
+		}
+		if len(events) < replayPageSize {
+			break
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	interval := time.Duration(config.Config.Notify.HeartbeatSeconds) * time.Second
+	if interval <= 0 {
+		interval = 25 * time.Second
+	}
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case now := <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			for id, sent := range seen {
+				if now.Sub(sent) > seenWindow {
+					delete(seen, id)
+				}
+			}
+		case e, ok := <-s.events:
+			if !ok {
+				// Fell too far behind; the client reconnects and replays.
+				return
+			}
+			if _, dup := seen[e.ID]; dup {
+				continue
+			}
+			if writeEvent(w, e) != nil {
+				return
+			}
+			seen[e.ID] = time.Now()
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+func parseEventID(w http.ResponseWriter, v string) (uint64, bool) {
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid event id")
+		return 0, false
+	}
+	return id, true
+}
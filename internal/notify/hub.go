@@ -0,0 +1,81 @@
+package notify
+
+import "sync"
+
+// subscriberBuffer is how many events a stream may fall behind before it is
+// dropped. The client reconnects and catches up through Last-Event-ID.
+const subscriberBuffer = 64
+
+// Relay carries stored events to the processes holding the streams. The
+// default delivers inside this process. A deployment running several
+// instances sets a relay over its message bus with SetRelay and calls
+// Deliver on every instance for each event it receives.
+type Relay interface {
+	Publish(e Event) error
+}
+
+type localRelay struct{}
+
+func (localRelay) Publish(e Event) error {
+	Deliver(e)
+	return nil
+}
+
+var relay Relay = localRelay{}
+
+// SetRelay must be called before the server starts.
+func SetRelay(r Relay) {
+	relay = r
+}
+
+type subscriber struct {
+	events chan Event
+	closed bool
+}
+
+var hub = struct {
+	sync.Mutex
+	users map[uint64]map[*subscriber]struct{}
+}{users: map[uint64]map[*subscriber]struct{}{}}
+
+func subscribe(userID uint64) *subscriber {
+	s := &subscriber{events: make(chan Event, subscriberBuffer)}
+	hub.Lock()
+	defer hub.Unlock()
+	if hub.users[userID] == nil {
+		hub.users[userID] = map[*subscriber]struct{}{}
+	}
+	hub.users[userID][s] = struct{}{}
+	return s
+}
+
+func unsubscribe(userID uint64, s *subscriber) {
+	hub.Lock()
+	defer hub.Unlock()
+	remove(userID, s)
+}
+
+func remove(userID uint64, s *subscriber) {
+	delete(hub.users[userID], s)
+	if len(hub.users[userID]) == 0 {
+		delete(hub.users, userID)
+	}
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+// Deliver fans an event out to the open streams of its user in this
+// process.
+func Deliver(e Event) {
+	hub.Lock()
+	defer hub.Unlock()
+	for s := range hub.users[e.UserID] {
+		select {
+		case s.events <- e:
+		default:
+			remove(e.UserID, s)
+		}
+	}
+}
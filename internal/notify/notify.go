@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+const (
+	EventAxisRevision = "axis.revision"
+	EventAxisComment  = "axis.comment"
+	EventAxisMention  = "axis.mention"
+	EventMergeRequest = "merge_request"
+	EventClearReview  = "clear.review"
+	EventModeration   = "moderation"
+)
+
+// Event is stored before it is pushed, its id doubles as the SSE event id
+// clients send back in Last-Event-ID.
+type Event struct {
+	ID         uint64         `db:"id" json:"id"`
+	UserID     uint64         `db:"user_id" json:"-"`
+	Type       string         `db:"type" json:"type"`
+	Data       types.JSONText `db:"data" json:"data"`
+	TimeCreate time.Time      `db:"time_create" json:"time_create"`
+}
+
+// Publish sends an event to each user. Failures are only logged, a lost
+// notification must not fail the action that caused it.
+func Publish(eventType string, data interface{}, userIDs ...uint64) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.BAASError("Notify Error :", err.Error())
+		return
+	}
+	seen := map[uint64]bool{}
+	for _, userID := range userIDs {
+		if userID == 0 || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		e, err := store(userID, eventType, payload)
+		if err != nil {
+			logger.BAASError("Notify Error :", err.Error())
+			continue
+		}
+		if err := relay.Publish(*e); err != nil {
+			logger.BAASError("Notify Relay Error :", err.Error())
+		}
+	}
+}
+
+func store(userID uint64, eventType string, payload []byte) (*Event, error) {
+	res, err := database.DB.Exec(
+		`INSERT INTO notifications (user_id, type, data) VALUES (?, ?, ?)`, userID, eventType, string(payload),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &Event{ID: uint64(id), UserID: userID, Type: eventType, Data: payload, TimeCreate: time.Now()}, nil
+}
+
+// Since returns the events of a user after afterID, oldest first.
+func Since(userID, afterID uint64, limit int) ([]Event, error) {
+	events := []Event{}
+	err := database.DB.Select(&events,
+		`SELECT id, user_id, type, data, time_create FROM notifications WHERE user_id = ? AND id > ? ORDER BY id LIMIT ?`,
+		userID, afterID, limit,
+	)
+	return events, err
+}
+
+func prune() error {
+	_, err := database.DB.Exec(
+		`DELETE FROM notifications WHERE time_create < NOW() - INTERVAL ? DAY`, config.Config.Notify.RetentionDays,
+	)
+	return err
+}
+
+func StartPruneJob() {
+	if config.Config.Notify.RetentionDays <= 0 {
+		logger.BAASWarn("Notification prune job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := prune(); err != nil {
+				logger.BAASError("Notification Prune Error :", err.Error())
+			}
+		}
+	}()
+}
+
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(`DELETE FROM notifications WHERE user_id = ?`, userID)
+	return err
+}
@@ -0,0 +1,14 @@
+package notify
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(api.RequireUser, api.RequireScope(api.ScopeProfileRead))
+	r.Get("/", handlerList)
+	r.Get("/stream", handlerStream)
+	return r
+}
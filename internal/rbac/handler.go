@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func handlerListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := ListRoles()
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, roles)
+}
+
+func handlerGetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.URLParamID(r, "userID")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid user id")
+		return
+	}
+	roles, err := UserRoles(userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, roles)
+}
+
+func handlerAssignRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.URLParamID(r, "userID")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid user id")
+		return
+	}
+	operator, _ := api.CurrentUserID(r)
+	if err := AssignRole(userID, chi.URLParam(r, "role"), &operator); err != nil {
+		respondRoleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerRevokeRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.URLParamID(r, "userID")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid user id")
+		return
+	}
+	operator, _ := api.CurrentUserID(r)
+	role := chi.URLParam(r, "role")
+	if userID == operator && role == RoleAdmin {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "cannot revoke your own admin role")
+		return
+	}
+	if err := RevokeRole(userID, role); err != nil {
+		respondRoleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondRoleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrRoleNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	api.ResponseWithInternalError(w, err)
+}
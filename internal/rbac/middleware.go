@@ -0,0 +1,40 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := api.CurrentUserID(r)
+			if !ok {
+				api.ResponseWithError(w, http.StatusUnauthorized, api.CodeUnauthorized, "login required")
+				return
+			}
+			allowed, err := HasPermission(userID, permission)
+			if err != nil {
+				api.ResponseWithInternalError(w, err)
+				return
+			}
+			if !allowed {
+				api.ResponseWithError(w, http.StatusForbidden, api.CodeForbidden, "missing permission "+permission)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestHasPermission is HasPermission for the caller of r. Permissions
+// come with roles, and roles only act through signed in sessions: a
+// personal access token of a moderator acts as a plain user.
+func RequestHasPermission(r *http.Request, permission string) (bool, error) {
+	userID, ok := api.CurrentUserID(r)
+	if !ok || api.IsTokenRequest(r) {
+		return false, nil
+	}
+	return HasPermission(userID, permission)
+}
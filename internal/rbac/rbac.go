@@ -0,0 +1,126 @@
+package rbac
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+const (
+	RoleAuthor    = "author"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// DefaultRole is implicitly held by every active user.
+const DefaultRole = RoleAuthor
+
+const (
+	PermAxisCreate   = "axis.create"
+	PermAxisModerate = "axis.moderate"
+	PermUserManage   = "user.manage"
+	PermRoleManage   = "role.manage"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+type Role struct {
+	ID          uint32   `db:"id" json:"id"`
+	Name        string   `db:"name" json:"name"`
+	Description *string  `db:"description" json:"description"`
+	Permissions []string `db:"-" json:"permissions"`
+}
+
+type UserRole struct {
+	Role       string    `db:"role" json:"role"`
+	GrantedBy  *uint64   `db:"granted_by" json:"granted_by"`
+	TimeCreate time.Time `db:"time_create" json:"time_create"`
+}
+
+func HasPermission(userID uint64, permission string) (bool, error) {
+	var n int
+	err := database.DB.Get(&n,
+		`SELECT COUNT(*) FROM role_permissions rp
+		JOIN roles r ON r.id = rp.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE p.name = ? AND (r.name = ? OR r.id IN (SELECT role_id FROM user_roles WHERE user_id = ?))`,
+		permission, DefaultRole, userID,
+	)
+	return n > 0, err
+}
+
+func ListRoles() ([]Role, error) {
+	roles := []Role{}
+	if err := database.DB.Select(&roles, `SELECT id, name, description FROM roles ORDER BY id`); err != nil {
+		return nil, err
+	}
+	var grants []struct {
+		RoleID     uint32 `db:"role_id"`
+		Permission string `db:"name"`
+	}
+	err := database.DB.Select(&grants,
+		`SELECT rp.role_id, p.name FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id ORDER BY p.name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		roles[i].Permissions = []string{}
+		for _, g := range grants {
+			if g.RoleID == roles[i].ID {
+				roles[i].Permissions = append(roles[i].Permissions, g.Permission)
+			}
+		}
+	}
+	return roles, nil
+}
+
+func UserRoles(userID uint64) ([]UserRole, error) {
+	roles := []UserRole{}
+	err := database.DB.Select(&roles,
+		`SELECT r.name AS role, ur.granted_by, ur.time_create FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = ? ORDER BY r.id`, userID,
+	)
+	return roles, err
+}
+
+// AssignRole grants role to userID. grantedBy is nil for grants made from
+// config or the command line.
+func AssignRole(userID uint64, role string, grantedBy *uint64) error {
+	roleID, err := roleID(role)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(
+		`INSERT IGNORE INTO user_roles (user_id, role_id, granted_by) VALUES (?, ?, ?)`,
+		userID, roleID, grantedBy,
+	)
+	return err
+}
+
+func RevokeRole(userID uint64, role string) error {
+	roleID, err := roleID(role)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`DELETE FROM user_roles WHERE user_id = ? AND role_id = ?`, userID, roleID)
+	return err
+}
+
+// PurgeUser drops every role of a purged account.
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(`DELETE FROM user_roles WHERE user_id = ?`, userID)
+	return err
+}
+
+func roleID(name string) (uint32, error) {
+	var id uint32
+	err := database.DB.Get(&id, `SELECT id FROM roles WHERE name = ?`, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrRoleNotFound
+	}
+	return id, err
+}
@@ -0,0 +1,16 @@
+package rbac
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(api.RequireSession, RequirePermission(PermRoleManage))
+	r.Get("/", handlerListRoles)
+	r.Get("/users/{userID}", handlerGetUserRoles)
+	r.Put("/users/{userID}/{role}", handlerAssignRole)
+	r.Delete("/users/{userID}/{role}", handlerRevokeRole)
+	return r
+}
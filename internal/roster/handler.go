@@ -0,0 +1,134 @@
+package roster
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+const maxRosterSize = 1000
+
+type studentRequest struct {
+	StudentID     uint32 `json:"student_id"`
+	Star          int    `json:"star"`
+	Level         int    `json:"level"`
+	UELevel       int    `json:"ue_level"`
+	SkillEX       int    `json:"skill_ex"`
+	SkillBasic    int    `json:"skill_basic"`
+	SkillEnhanced int    `json:"skill_enhanced"`
+	SkillSub      int    `json:"skill_sub"`
+}
+
+func (req studentRequest) student() Student {
+	return Student{
+		StudentID:     req.StudentID,
+		Star:          req.Star,
+		Level:         req.Level,
+		UELevel:       req.UELevel,
+		SkillEX:       req.SkillEX,
+		SkillBasic:    req.SkillBasic,
+		SkillEnhanced: req.SkillEnhanced,
+		SkillSub:      req.SkillSub,
+	}
+}
+
+func handlerList(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	students, err := List(userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, students)
+}
+
+func handlerReplace(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req []studentRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if len(req) > maxRosterSize {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "too many students")
+		return
+	}
+	students := make([]Student, len(req))
+	seen := map[uint32]bool{}
+	for i, each := range req {
+		students[i] = each.student()
+		if msg := students[i].Validate(); msg != "" {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "["+strconv.Itoa(i)+"] "+msg)
+			return
+		}
+		if seen[each.StudentID] {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "["+strconv.Itoa(i)+"] duplicate student_id")
+			return
+		}
+		seen[each.StudentID] = true
+	}
+	if err := Replace(userID, students); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	handlerList(w, r)
+}
+
+func handlerPut(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	studentID, ok := parseStudentID(w, r)
+	if !ok {
+		return
+	}
+	var req studentRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	req.StudentID = studentID
+	s := req.student()
+	if msg := s.Validate(); msg != "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, msg)
+		return
+	}
+	if err := Upsert(userID, s); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	stored, err := Get(userID, studentID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, stored)
+}
+
+func handlerDelete(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	studentID, ok := parseStudentID(w, r)
+	if !ok {
+		return
+	}
+	err := Delete(userID, studentID)
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseStudentID(w http.ResponseWriter, r *http.Request) (uint32, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "studentID"), 10, 32)
+	if err != nil || id == 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid student id")
+		return 0, false
+	}
+	return uint32(id), true
+}
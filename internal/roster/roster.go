@@ -0,0 +1,145 @@
+package roster
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+var ErrNotFound = errors.New("student not in roster")
+
+type Student struct {
+	StudentID      uint32    `db:"student_id" json:"student_id"`
+	Star           int       `db:"star" json:"star"`
+	Level          int       `db:"level" json:"level"`
+	UELevel        int       `db:"ue_level" json:"ue_level"`
+	SkillEX        int       `db:"skill_ex" json:"skill_ex"`
+	SkillBasic     int       `db:"skill_basic" json:"skill_basic"`
+	SkillEnhanced  int       `db:"skill_enhanced" json:"skill_enhanced"`
+	SkillSub       int       `db:"skill_sub" json:"skill_sub"`
+	TimeLastUpdate time.Time `db:"time_last_update" json:"time_last_update"`
+}
+
+const studentColumns = `student_id, star, level, ue_level, skill_ex, skill_basic, skill_enhanced, skill_sub, time_last_update`
+
+// Validate returns a message describing the first out of range field.
+func (s *Student) Validate() string {
+	checks := []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"star", s.Star, 1, 5},
+		{"level", s.Level, 1, 90},
+		{"ue_level", s.UELevel, 0, 60},
+		{"skill_ex", s.SkillEX, 1, 5},
+		{"skill_basic", s.SkillBasic, 1, 10},
+		{"skill_enhanced", s.SkillEnhanced, 1, 10},
+		{"skill_sub", s.SkillSub, 1, 10},
+	}
+	if s.StudentID == 0 {
+		return "student_id is required"
+	}
+	for _, c := range checks {
+		if c.value < c.min || c.value > c.max {
+			return c.name + " out of range"
+		}
+	}
+	return ""
+}
+
+func List(userID uint64) ([]Student, error) {
+	students := []Student{}
+	err := database.DB.Select(&students,
+		`SELECT `+studentColumns+` FROM user_students WHERE user_id = ? ORDER BY student_id`, userID,
+	)
+	return students, err
+}
+
+// Map returns the roster keyed by student id.
+func Map(userID uint64) (map[uint32]Student, error) {
+	students, err := List(userID)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[uint32]Student, len(students))
+	for _, s := range students {
+		m[s.StudentID] = s
+	}
+	return m, nil
+}
+
+func Get(userID uint64, studentID uint32) (*Student, error) {
+	var s Student
+	err := database.DB.Get(&s,
+		`SELECT `+studentColumns+` FROM user_students WHERE user_id = ? AND student_id = ?`, userID, studentID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func Upsert(userID uint64, s Student) error {
+	return upsert(database.DB, userID, s)
+}
+
+// Replace swaps the whole roster, for clients syncing from the game.
+func Replace(userID uint64, students []Student) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM user_students WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, s := range students {
+		if err := upsert(tx, userID, s); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func Delete(userID uint64, studentID uint32) error {
+	res, err := database.DB.Exec(`DELETE FROM user_students WHERE user_id = ? AND student_id = ?`, userID, studentID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(`DELETE FROM user_students WHERE user_id = ?`, userID)
+	return err
+}
+
+func ExportUser(userID uint64) (interface{}, error) {
+	return List(userID)
+}
+
+func upsert(db sqlx.Execer, userID uint64, s Student) error {
+	_, err := db.Exec(
+		`INSERT INTO user_students (user_id, student_id, star, level, ue_level, skill_ex, skill_basic, skill_enhanced, skill_sub)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE star = VALUES(star), level = VALUES(level), ue_level = VALUES(ue_level),
+			skill_ex = VALUES(skill_ex), skill_basic = VALUES(skill_basic),
+			skill_enhanced = VALUES(skill_enhanced), skill_sub = VALUES(skill_sub)`,
+		userID, s.StudentID, s.Star, s.Level, s.UELevel, s.SkillEX, s.SkillBasic, s.SkillEnhanced, s.SkillSub,
+	)
+	return err
+}
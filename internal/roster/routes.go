@@ -0,0 +1,21 @@
+package roster
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(api.RequireUser)
+
+	r.With(api.RequireScope(api.ScopeProfileRead)).Get("/", handlerList)
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireScope(api.ScopeProfileWrite))
+		r.Put("/", handlerReplace)
+		r.Put("/{studentID}", handlerPut)
+		r.Delete("/{studentID}", handlerDelete)
+	})
+	return r
+}
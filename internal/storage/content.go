@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+)
+
+// PutContent stores r under its SHA-256 content key. Identical files share
+// one copy: when the key exists nothing is written. r is spooled to a
+// temporary file first, as the key is only known once it is fully read.
+func PutContent(ctx context.Context, s Storage, r io.Reader, contentType string) (*Object, error) {
+	tmp, err := os.CreateTemp("", "go_baas_upload_*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return nil, err
+	}
+	key := ContentKey(hex.EncodeToString(h.Sum(nil)))
+	if obj, err := s.Stat(ctx, key); err == nil {
+		return obj, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.Put(ctx, key, tmp, size, contentType); err != nil {
+		return nil, err
+	}
+	return s.Stat(ctx, key)
+}
+
+// PutContentBytes is PutContent for data already in memory, such as axis
+// revisions, and returns the key.
+func PutContentBytes(ctx context.Context, s Storage, data []byte, contentType string) (string, error) {
+	sum := sha256.Sum256(data)
+	key := ContentKey(hex.EncodeToString(sum[:]))
+	_, err := s.Stat(ctx, key)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	return key, s.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+// handlerDownload serves the signed links of the local backend. S3 links
+// point at the bucket and never reach it.
+func handlerDownload(w http.ResponseWriter, r *http.Request) {
+	local, ok := Store.(*Local)
+	key := chi.URLParam(r, "*")
+	q := r.URL.Query()
+	if !ok || !ValidKey(key) || !local.Verify(key, q.Get("expires"), q.Get("signature")) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, "link is invalid or expired")
+		return
+	}
+	body, obj, err := local.Get(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	defer body.Close()
+	expires, _ := strconv.ParseInt(q.Get("expires"), 10, 64)
+	maxAge := time.Until(time.Unix(expires, 0)) / time.Second
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(int64(maxAge), 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if rs, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", obj.TimeModify, rs)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
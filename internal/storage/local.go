@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+// Local keeps files below root/files and their content types below
+// root/meta. Writes go to root/tmp first and are renamed into place, so a
+// reader never sees a partial file.
+type Local struct {
+	root   string
+	secret []byte
+}
+
+type localMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+func NewLocal(root string, secret []byte) (*Local, error) {
+	for _, dir := range []string{"files", "meta", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &Local{root: root, secret: secret}, nil
+}
+
+func (l *Local) path(tree, key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.root, tree, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dst, err := l.path("files", key)
+	if err != nil {
+		return err
+	}
+	meta, _ := l.path("meta", key)
+	data, err := json.Marshal(localMeta{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+	if err := l.write(meta, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+	return l.write(dst, func(w io.Writer) error {
+		n, err := io.Copy(w, readerWithContext(ctx, r))
+		if err == nil && size >= 0 && n != size {
+			err = fmt.Errorf("wrote %d bytes, expected %d", n, size)
+		}
+		return err
+	})
+}
+
+func (l *Local) write(dst string, fill func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(l.root, "tmp"), "put_*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := fill(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	p, err := l.path("files", key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := l.stat(key, f.Stat)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, obj, nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (*Object, error) {
+	p, err := l.path("files", key)
+	if err != nil {
+		return nil, err
+	}
+	return l.stat(key, func() (fs.FileInfo, error) { return os.Stat(p) })
+}
+
+func (l *Local) stat(key string, info func() (fs.FileInfo, error)) (*Object, error) {
+	fi, err := info()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	obj := &Object{Key: key, Size: fi.Size(), ContentType: "application/octet-stream", TimeModify: fi.ModTime()}
+	meta, _ := l.path("meta", key)
+	if data, err := os.ReadFile(meta); err == nil {
+		var m localMeta
+		if json.Unmarshal(data, &m) == nil && m.ContentType != "" {
+			obj.ContentType = m.ContentType
+		}
+	}
+	return obj, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	for _, tree := range []string{"files", "meta"} {
+		p, err := l.path(tree, key)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	base := filepath.Join(l.root, "files")
+	start := base
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		start = filepath.Join(base, filepath.FromSlash(prefix[:i]))
+	}
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		obj, err := l.Stat(ctx, key)
+		if err != nil {
+			return err
+		}
+		return fn(*obj)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// SignedURL points at the files route of this server, see Routes.
+func (l *Local) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	exp := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	q := url.Values{"expires": {exp}, "signature": {l.sign(key, exp)}}
+	return config.Config.Server.PublicURL + "/api/v1/files/" + key + "?" + q.Encode(), nil
+}
+
+// Verify checks a link made by SignedURL.
+func (l *Local) Verify(key, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(l.sign(key, expires)))
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// readerWithContext stops a long copy once ctx is done.
+func readerWithContext(ctx context.Context, r io.Reader) io.Reader {
+	return readerFunc(func(p []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return r.Read(p)
+	})
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
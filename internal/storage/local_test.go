@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	l, err := NewLocal(t.TempDir(), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestLocalPutGetDelete(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	if err := l.Put(ctx, "avatars/1.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	rc, obj, err := l.Get(ctx, "avatars/1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "png" || obj.Size != 3 || obj.ContentType != "image/png" {
+		t.Fatalf("Get = %q, %+v", data, obj)
+	}
+	if err := l.Delete(ctx, "avatars/1.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Stat(ctx, "avatars/1.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after Delete = %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, "avatars/1.png"); err != nil {
+		t.Fatalf("Delete of a missing key = %v", err)
+	}
+}
+
+func TestLocalPutChecksSizeAndKey(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	if err := l.Put(ctx, "a/b", strings.NewReader("abc"), 4, ""); err == nil {
+		t.Fatal("Put accepted a short body")
+	}
+	if _, err := l.Stat(ctx, "a/b"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("a failed Put left a file behind : %v", err)
+	}
+	if err := l.Put(ctx, "../escape", strings.NewReader(""), 0, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Put ../escape = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+	for _, key := range []string{"sha256/ab/ab1", "sha256/ab/ab2", "sha256/cd/cd1", "avatars/1.png"} {
+		if err := l.Put(ctx, key, strings.NewReader(key), -1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var keys []string
+	err := l.List(ctx, "sha256/ab", func(obj Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sha256/ab/ab1", "sha256/ab/ab2"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List = %v, want %v", keys, want)
+	}
+	if err := l.List(ctx, "missing/", func(Object) error { return nil }); err != nil {
+		t.Fatalf("List of a missing prefix = %v", err)
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	config.Config = &config.GOBAASConfig{Server: config.ServerConfig{PublicURL: "https://baas.test"}}
+	l := newTestLocal(t)
+	link, err := l.SignedURL(context.Background(), "sha256/ab/ab1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.TrimPrefix(u.Path, "/api/v1/files/")
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	if !l.Verify(key, expires, signature) {
+		t.Fatal("Verify rejected a fresh link")
+	}
+	if l.Verify("sha256/ab/ab2", expires, signature) {
+		t.Error("Verify accepted a link for another key")
+	}
+	later := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	if l.Verify(key, later, signature) {
+		t.Error("Verify accepted an extended expiry")
+	}
+	flipped := "0"
+	if strings.HasSuffix(signature, "0") {
+		flipped = "1"
+	}
+	if l.Verify(key, expires, signature[:len(signature)-1]+flipped) {
+		t.Error("Verify accepted a changed signature")
+	}
+	past := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+	if l.Verify(key, past, l.sign(key, past)) {
+		t.Error("Verify accepted an expired link")
+	}
+	other := &Local{root: l.root, secret: []byte("other")}
+	if other.Verify(key, expires, signature) {
+		t.Error("Verify accepted a link signed with another secret")
+	}
+}
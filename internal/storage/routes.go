@@ -0,0 +1,11 @@
+package storage
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/*", handlerDownload)
+	return r
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+// fakeS3 is a path style bucket in memory. It checks the signature of every
+// request with the credentials of the client and pages listings by
+// pageSize keys.
+type fakeS3 struct {
+	t        *testing.T
+	signer   *S3
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	lists   int
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3) {
+	t.Helper()
+	f := &fakeS3{t: t, bucket: "axes", pageSize: 2, objects: map[string]fakeObject{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	s, err := NewS3(config.S3Config{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    f.bucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.signer = s
+	return f, s
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.checkSignature(r) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `<Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error>`)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if key == "" && r.Method == http.MethodGet {
+		f.list(w, r.URL.Query())
+		return
+	}
+	obj, exists := f.objects[key]
+	switch r.Method {
+	case http.MethodPut:
+		if r.ContentLength < 0 {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, q url.Values) {
+	f.lists++
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, q.Get("prefix")) && key > q.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var result struct {
+		XMLName  xml.Name `xml:"ListBucketResult"`
+		Contents []struct {
+			Key  string
+			Size int
+		}
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}
+	if len(keys) > f.pageSize {
+		keys, result.IsTruncated = keys[:f.pageSize], true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, struct {
+			Key  string
+			Size int
+		}{key, len(f.objects[key].data)})
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+// checkSignature signs the request again the way S3 does and compares.
+func (f *fakeS3) checkSignature(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	var signed []string
+	var signature string
+	for _, part := range strings.Split(strings.TrimPrefix(auth, s3Algorithm+" "), ", ") {
+		if v, ok := strings.CutPrefix(part, "SignedHeaders="); ok {
+			signed = strings.Split(v, ";")
+		}
+		if v, ok := strings.CutPrefix(part, "Signature="); ok {
+			signature = v
+		}
+	}
+	t, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || signature == "" {
+		return false
+	}
+	u := *r.URL
+	u.Host = r.Host
+	return f.signer.signature(t, r.Method, &u, r.Header, signed, r.Header.Get("X-Amz-Content-Sha256")) == signature
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	f, s := newFakeS3(t)
+	ctx := context.Background()
+	if err := s.Put(ctx, "sha256/ab/ab1", strings.NewReader("axis"), 4, "application/json"); err != nil {
+		t.Fatal(err)
+	}
+	// An unknown size is spooled first, S3 needs the length.
+	if err := s.Put(ctx, "avatars/1.png", strings.NewReader("png"), -1, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(f.objects["avatars/1.png"].data); got != "png" {
+		t.Fatalf("stored %q, want png", got)
+	}
+
+	rc, obj, err := s.Get(ctx, "sha256/ab/ab1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "axis" || obj.Size != 4 || obj.ContentType != "application/json" {
+		t.Fatalf("Get = %q, %+v", data, obj)
+	}
+	if obj, err := s.Stat(ctx, "avatars/1.png"); err != nil || obj.Size != 3 {
+		t.Fatalf("Stat = %+v, %v", obj, err)
+	}
+
+	if err := s.Delete(ctx, "sha256/ab/ab1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(ctx, "sha256/ab/ab1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Stat(ctx, "sha256/ab/ab1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after Delete = %v, want ErrNotFound", err)
+	}
+	if err := s.Put(ctx, "../escape", strings.NewReader(""), 0, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Put ../escape = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestS3ListPages(t *testing.T) {
+	f, s := newFakeS3(t)
+	ctx := context.Background()
+	want := []string{"sha256/ab/ab1", "sha256/ab/ab2", "sha256/cd/cd1", "sha256/cd/cd2", "sha256/ef/ef1"}
+	for _, key := range append([]string{"avatars/1.png"}, want...) {
+		if err := s.Put(ctx, key, strings.NewReader(key), int64(len(key)), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var keys []string
+	err := s.List(ctx, "sha256/", func(obj Object) error {
+		if obj.Size != int64(len(obj.Key)) {
+			t.Errorf("%s : size %d", obj.Key, obj.Size)
+		}
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("List = %v, want %v", keys, want)
+	}
+	if f.lists != 3 {
+		t.Errorf("List made %d requests, want 3 pages", f.lists)
+	}
+
+	stop := errors.New("stop")
+	err = s.List(ctx, "sha256/", func(Object) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Fatalf("List = %v, want the error of fn", err)
+	}
+}
+
+func TestS3RejectsBadCredentials(t *testing.T) {
+	f, _ := newFakeS3(t)
+	other, err := NewS3(config.S3Config{
+		Endpoint:  f.signer.cfg.Endpoint,
+		Region:    "us-east-1",
+		Bucket:    f.bucket,
+		AccessKey: "access",
+		SecretKey: "wrong",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = other.Put(context.Background(), "a/b", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("Put = %v, want SignatureDoesNotMatch", err)
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	_, s := newFakeS3(t)
+	link, err := s.SignedURL(context.Background(), "sha256/ab/ab1", 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if got := q.Get("X-Amz-Expires"); got != strconv.Itoa(int(s3MaxPresignTime.Seconds())) {
+		t.Errorf("X-Amz-Expires = %s, want the seven day limit", got)
+	}
+	if u.Path != "/axes/sha256/ab/ab1" || q.Get("X-Amz-Signature") == "" {
+		t.Errorf("SignedURL = %s", link)
+	}
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/global_info"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("file not found")
+	ErrInvalidKey = errors.New("invalid file key")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	TimeModify  time.Time `json:"time_modify"`
+}
+
+// Storage keeps files by key. Keys are slash separated paths of
+// ValidKey; readers and writers are streamed, never held in memory.
+type Storage interface {
+	// Put stores r under key, replacing an existing file. size may be -1
+	// when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns ErrNotFound for missing keys. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete does not fail for missing keys.
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every file under prefix in key order and stops at
+	// the first error fn returns.
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+	// SignedURL returns a link that downloads key without login until it
+	// expires.
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Store is the configured backend, set by Init.
+var Store Storage
+
+func Init() error {
+	logger.HighLight("Storage Init")
+	cfg := config.Config.Storage
+	logger.BAASInfo("Backend :", cfg.Backend)
+	switch cfg.Backend {
+	case BackendLocal:
+		root := cfg.LocalRoot
+		if root == "" {
+			root = global_info.GO_BAAS_STORAGE_DIR
+		}
+		secret := []byte(cfg.SigningSecret)
+		if len(secret) == 0 {
+			logger.BAASWarn("Storage signing_secret is empty, download links break on restart")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return errors.New("Storage Init Error : " + err.Error())
+			}
+		}
+		logger.BAASInfo("Root    :", root)
+		s, err := NewLocal(root, secret)
+		if err != nil {
+			return errors.New("Storage Init Error : " + err.Error())
+		}
+		Store = s
+	case BackendS3:
+		logger.BAASInfo("Bucket  :", cfg.S3.Bucket)
+		s, err := NewS3(cfg.S3)
+		if err != nil {
+			return errors.New("Storage Init Error : " + err.Error())
+		}
+		Store = s
+	default:
+		return errors.New("Storage Init Error : unknown backend " + cfg.Backend)
+	}
+	return nil
+}
+
+// URLExpiry is how long the download links handed to clients stay valid.
+func URLExpiry() time.Duration {
+	minutes := config.Config.Storage.URLExpiryMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ValidKey accepts relative slash separated paths of letters, digits and
+// "-_.", without empty, "." or ".." segments, so a key can be used as a file
+// path and an object name alike.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 512 {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, c := range segment {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ContentKey is the key of content addressed files, sharded by the first
+// bytes of the hash so no directory grows too large.
+func ContentKey(sha256Hex string) string {
+	return "sha256/" + sha256Hex[:2] + "/" + sha256Hex
+}
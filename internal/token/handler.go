@@ -0,0 +1,102 @@
+package token
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+type createRequest struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	ExpireDays int      `json:"expire_days"`
+}
+
+type createResponse struct {
+	*Token
+	Secret string `json:"token"`
+}
+
+func handlerListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	tokens, err := List(userID)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, tokens)
+}
+
+func handlerCreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req createRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "name must be 1-100 bytes")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "at least one scope is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !isKnownScope(scope) {
+			api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown scope "+scope)
+			return
+		}
+	}
+	if req.ExpireDays == 0 {
+		req.ExpireDays = config.Config.Token.DefaultExpireDays
+	}
+	if req.ExpireDays < 1 || req.ExpireDays > config.Config.Token.MaxExpireDays {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "expire_days out of range")
+		return
+	}
+
+	t, plain, err := Create(userID, req.Name, req.Scopes, req.ExpireDays, config.Config.Token.MaxTokensPerUser)
+	if errors.Is(err, ErrTooManyTokens) {
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	api.ResponseWithJson(w, http.StatusCreated, createResponse{Token: t, Secret: plain})
+}
+
+func handlerRevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid token id")
+		return
+	}
+	err := Revoke(userID, id)
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range api.KnownScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package token
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(api.RequireUser, api.RequireSession)
+	r.Get("/", handlerListTokens)
+	r.Post("/", handlerCreateToken)
+	r.Delete("/{id}", handlerRevokeToken)
+	return r
+}
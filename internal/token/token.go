@@ -0,0 +1,151 @@
+package token
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/user"
+)
+
+// Prefix makes leaked tokens easy to recognize by secret scanners.
+const Prefix = "baas_pat_"
+
+const displayPrefixLen = len(Prefix) + 4
+
+var (
+	ErrNotFound      = errors.New("token not found")
+	ErrInvalid       = errors.New("token invalid, expired or revoked")
+	ErrTooManyTokens = errors.New("too many active tokens")
+)
+
+type Token struct {
+	ID           uint64         `db:"id" json:"id"`
+	UserID       uint64         `db:"user_id" json:"-"`
+	Name         string         `db:"name" json:"name"`
+	TokenPrefix  string         `db:"token_prefix" json:"token_prefix"`
+	Scopes       types.JSONText `db:"scopes" json:"scopes"`
+	TimeCreate   time.Time      `db:"time_create" json:"time_create"`
+	TimeExpire   time.Time      `db:"time_expire" json:"time_expire"`
+	TimeLastUsed *time.Time     `db:"time_last_used" json:"time_last_used"`
+	LastUsedIP   *string        `db:"last_used_ip" json:"last_used_ip"`
+	TimeRevoked  *time.Time     `db:"time_revoked" json:"time_revoked"`
+}
+
+const tokenColumns = `id, user_id, name, token_prefix, scopes, time_create, time_expire, time_last_used, last_used_ip, time_revoked`
+
+func (t *Token) ScopeList() ([]string, error) {
+	var scopes []string
+	err := t.Scopes.Unmarshal(&scopes)
+	return scopes, err
+}
+
+// Create mints a new token and returns it together with its plaintext
+// secret, which is never stored and cannot be recovered afterwards.
+func Create(userID uint64, name string, scopes []string, expireDays int, maxActive int) (*Token, string, error) {
+	secret, err := user.NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := Prefix + secret
+	scopesJson, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var active int
+	err = database.DB.Get(&active,
+		`SELECT COUNT(*) FROM personal_access_tokens
+		WHERE user_id = ? AND time_revoked IS NULL AND time_expire > NOW()`, userID,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	if active >= maxActive {
+		return nil, "", ErrTooManyTokens
+	}
+
+	res, err := database.DB.Exec(
+		`INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, time_expire)
+		VALUES (?, ?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? DAY))`,
+		userID, name, plain[:displayPrefixLen], user.HashToken(plain), string(scopesJson), expireDays,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, "", err
+	}
+	var t Token
+	if err := database.DB.Get(&t, `SELECT `+tokenColumns+` FROM personal_access_tokens WHERE id = ?`, id); err != nil {
+		return nil, "", err
+	}
+	return &t, plain, nil
+}
+
+func List(userID uint64) ([]Token, error) {
+	tokens := []Token{}
+	err := database.DB.Select(&tokens,
+		`SELECT `+tokenColumns+` FROM personal_access_tokens WHERE user_id = ? ORDER BY id DESC`, userID,
+	)
+	return tokens, err
+}
+
+func Revoke(userID uint64, id uint64) error {
+	res, err := database.DB.Exec(
+		`UPDATE personal_access_tokens SET time_revoked = NOW() WHERE id = ? AND user_id = ? AND time_revoked IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Lookup resolves a plaintext token and records its use from ip.
+func Lookup(plain string, ip string) (*Token, error) {
+	var t Token
+	err := database.DB.Get(&t,
+		`SELECT `+tokenColumns+` FROM personal_access_tokens
+		WHERE token_hash = ? AND time_revoked IS NULL AND time_expire > NOW()`,
+		user.HashToken(plain),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Only write when the recorded use is stale, so a syncing client does not
+	// turn every read into an UPDATE.
+	_, err = database.DB.Exec(
+		`UPDATE personal_access_tokens SET time_last_used = NOW(), last_used_ip = ?
+		WHERE id = ? AND (time_last_used IS NULL OR last_used_ip <> ? OR time_last_used < DATE_SUB(NOW(), INTERVAL 1 MINUTE))`,
+		ip, t.ID, ip,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	_, err := tx.Exec(`DELETE FROM personal_access_tokens WHERE user_id = ?`, userID)
+	return err
+}
+
+func ExportUser(userID uint64) (interface{}, error) {
+	return List(userID)
+}
@@ -0,0 +1,40 @@
+package upload
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+// checkArchive looks at the central directory only. axisbundle.Read caps
+// the declared sizes per file and in total, and extracts no more than a
+// file declares, so an archive lying about them fails there instead.
+func checkArchive(r io.ReaderAt, size int64) error {
+	cfg := config.Config.Upload
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrArchiveRejected, err)
+	}
+	if len(zr.File) > cfg.MaxArchiveEntries {
+		return fmt.Errorf("%w : more than %d entries", ErrArchiveRejected, cfg.MaxArchiveEntries)
+	}
+	maxTotal := uint64(cfg.MaxArchiveUncompressedMB) << 20
+	var total uint64
+	for _, f := range zr.File {
+		if strings.HasSuffix(strings.ToLower(f.Name), ".zip") {
+			return fmt.Errorf("%w : nested archive %s", ErrArchiveRejected, f.Name)
+		}
+		total += f.UncompressedSize64
+		if total > maxTotal {
+			return fmt.Errorf("%w : more than %d MB uncompressed", ErrArchiveRejected, cfg.MaxArchiveUncompressedMB)
+		}
+		// Tiny entries compress well without being dangerous.
+		if f.UncompressedSize64 > 1<<20 && f.UncompressedSize64 > f.CompressedSize64*uint64(cfg.MaxArchiveRatio) {
+			return fmt.Errorf("%w : %s compresses more than %d:1", ErrArchiveRejected, f.Name, cfg.MaxArchiveRatio)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package upload
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/storage"
+)
+
+// handlerUploadScreenshot takes the raw image as the body. The returned url
+// can be used as the evidence_url of a clear.
+func handlerUploadScreenshot(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	u, ok := Receive(w, r, KindScreenshot, userID)
+	if !ok {
+		return
+	}
+	api.ResponseWithJson(w, http.StatusCreated, u)
+}
+
+// handlerGetUpload redirects to a signed link of the file. Private kinds
+// are only served to their owner.
+func handlerGetUpload(w http.ResponseWriter, r *http.Request) {
+	u, err := GetByPublicID(chi.URLParam(r, "id"))
+	if err == nil && !u.Public() {
+		// Private uploads look missing to everyone but their owner.
+		if userID, ok := api.CurrentUserID(r); !ok || userID != u.UserID {
+			err = ErrNotFound
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	link, err := storage.Store.SignedURL(r.Context(), u.StorageKey, storage.URLExpiry())
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	http.Redirect(w, r, link, http.StatusFound)
+}
+
+// Receive runs the request body through the pipeline and saves it, or
+// answers with the reason it was rejected.
+func Receive(w http.ResponseWriter, r *http.Request, kind string, userID uint64) (*Upload, bool) {
+	f, ok := ProcessRequest(w, r, kind)
+	if !ok {
+		return nil, false
+	}
+	defer f.Close()
+	u, err := Save(r.Context(), userID, f)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return nil, false
+	}
+	return u, true
+}
+
+// ProcessRequest is Process for the body of r, declared by Content-Type.
+// The caller closes the file.
+func ProcessRequest(w http.ResponseWriter, r *http.Request, kind string) (*File, bool) {
+	f, err := Process(r.Context(), kind, r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		RespondError(w, err)
+		return nil, false
+	}
+	return f, true
+}
+
+func RespondError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		api.ResponseWithError(w, http.StatusRequestEntityTooLarge, api.CodeFileTooLarge, err.Error())
+	case errors.Is(err, ErrUnsupportedType):
+		api.ResponseWithError(w, http.StatusUnsupportedMediaType, api.CodeUnsupportedMediaType, err.Error())
+	case errors.Is(err, ErrTypeMismatch):
+		api.ResponseWithError(w, http.StatusUnsupportedMediaType, api.CodeMediaTypeMismatch, err.Error())
+	case errors.Is(err, ErrArchiveRejected):
+		api.ResponseWithError(w, http.StatusUnprocessableEntity, api.CodeArchiveRejected, err.Error())
+	case errors.Is(err, ErrImageRejected):
+		api.ResponseWithError(w, http.StatusUnprocessableEntity, api.CodeImageRejected, err.Error())
+	case errors.Is(err, ErrRejected):
+		api.ResponseWithError(w, http.StatusUnprocessableEntity, api.CodeFileRejected, err.Error())
+	default:
+		api.ResponseWithInternalError(w, err)
+	}
+}
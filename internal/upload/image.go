@@ -0,0 +1,54 @@
+package upload
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+// reencode decodes the image and writes the pixels back, which leaves
+// EXIF, comments and anything appended to the file behind. GIFs become
+// still PNGs.
+func (f *File) reencode() error {
+	cfg, _, err := image.DecodeConfig(f.Reader())
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrImageRejected, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > config.Config.Upload.MaxImagePixels {
+		return fmt.Errorf("%w : %dx%d is too many pixels", ErrImageRejected, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(f.Reader())
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrImageRejected, err)
+	}
+
+	out, err := os.CreateTemp("", "go_baas_upload_*")
+	if err != nil {
+		return err
+	}
+	if f.ContentType == TypeJPEG {
+		err = jpeg.Encode(out, img, &jpeg.Options{Quality: 90})
+	} else {
+		f.ContentType = TypePNG
+		err = png.Encode(out, img)
+	}
+	var size int64
+	if err == nil {
+		size, err = out.Seek(0, io.SeekCurrent)
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return err
+	}
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+	f.tmp, f.Size = out, size
+	return nil
+}
@@ -0,0 +1,63 @@
+package upload
+
+import (
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+const (
+	KindAxis       = "axis"
+	KindBundle     = "bundle"
+	KindAvatar     = "avatar"
+	KindScreenshot = "screenshot"
+	KindVideo      = "video"
+)
+
+// publicKinds may be downloaded by anyone who has the link.
+var publicKinds = map[string]bool{KindAvatar: true, KindScreenshot: true}
+
+// kind is what the pipeline accepts for one kind of upload.
+type kind struct {
+	maxSize int64
+	types   []string
+	archive bool
+	image   bool
+}
+
+const (
+	TypeJSON = "application/json"
+	TypeZip  = "application/zip"
+	TypePNG  = "image/png"
+	TypeJPEG = "image/jpeg"
+	TypeGIF  = "image/gif"
+	TypeMP4  = "video/mp4"
+	TypeWebM = "video/webm"
+)
+
+// axisFileMaxSize bounds a single uploaded axis file.
+const axisFileMaxSize = 1 << 20
+
+func lookupKind(name string) (kind, bool) {
+	cfg := config.Config.Upload
+	switch name {
+	case KindAxis:
+		return kind{maxSize: axisFileMaxSize, types: []string{TypeJSON}}, true
+	case KindBundle:
+		return kind{maxSize: int64(config.Config.Axis.MaxBundleSizeMB) << 20, types: []string{TypeZip}, archive: true}, true
+	case KindAvatar:
+		return kind{maxSize: int64(cfg.MaxAvatarMB) << 20, types: []string{TypePNG, TypeJPEG, TypeGIF}, image: true}, true
+	case KindScreenshot:
+		return kind{maxSize: int64(cfg.MaxScreenshotMB) << 20, types: []string{TypePNG, TypeJPEG}, image: true}, true
+	case KindVideo:
+		return kind{maxSize: int64(cfg.MaxVideoMB) << 20, types: []string{TypeMP4, TypeWebM}}, true
+	}
+	return kind{}, false
+}
+
+func (k kind) allows(contentType string) bool {
+	for _, t := range k.types {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
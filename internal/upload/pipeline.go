@@ -0,0 +1,166 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/pur1fying/GO_BAAS/internal/storage"
+)
+
+var (
+	ErrUnknownKind     = errors.New("unknown upload kind")
+	ErrTooLarge        = errors.New("file too large")
+	ErrUnsupportedType = errors.New("file type not allowed")
+	ErrTypeMismatch    = errors.New("file content does not match its declared type")
+	ErrArchiveRejected = errors.New("archive rejected")
+	ErrImageRejected   = errors.New("image rejected")
+	// ErrRejected is what scanners wrap when they refuse a file.
+	ErrRejected = errors.New("file rejected")
+)
+
+// Rejected reports whether err is the pipeline refusing a file, as opposed
+// to failing to check it.
+func Rejected(err error) bool {
+	for _, target := range []error{ErrTooLarge, ErrUnsupportedType, ErrTypeMismatch, ErrArchiveRejected, ErrImageRejected, ErrRejected} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// File is an upload that passed every check, kept in a temporary file until
+// it is stored or closed.
+type File struct {
+	Kind        string
+	ContentType string
+	Size        int64
+	SHA256      string
+	tmp         *os.File
+}
+
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	return f.tmp.ReadAt(p, off)
+}
+
+// Reader reads the file from the start.
+func (f *File) Reader() io.Reader {
+	return io.NewSectionReader(f.tmp, 0, f.Size)
+}
+
+// Close removes the temporary file.
+func (f *File) Close() error {
+	f.tmp.Close()
+	return os.Remove(f.tmp.Name())
+}
+
+// Store writes the file to blob storage under its content key.
+func (f *File) Store(ctx context.Context) (*storage.Object, error) {
+	return storage.PutContent(ctx, storage.Store, f.Reader(), f.ContentType)
+}
+
+// Process runs r through the checks of kindName: the size is enforced while
+// streaming, the sniffed type must be allowed and match declaredType when
+// one is given, archives are checked for bombs, images are re-encoded to
+// drop metadata such as EXIF, and the registered scanners run last. Nothing
+// reaches storage on failure. The caller closes the returned file.
+func Process(ctx context.Context, kindName string, r io.Reader, declaredType string) (*File, error) {
+	k, ok := lookupKind(kindName)
+	if !ok {
+		return nil, ErrUnknownKind
+	}
+	tmp, err := os.CreateTemp("", "go_baas_upload_*")
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Kind: kindName, tmp: tmp}
+	if err := f.process(ctx, k, r, declaredType); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) process(ctx context.Context, k kind, r io.Reader, declaredType string) error {
+	n, err := io.Copy(f.tmp, io.LimitReader(r, k.maxSize+1))
+	var maxErr *http.MaxBytesError
+	if n > k.maxSize || errors.As(err, &maxErr) {
+		return fmt.Errorf("%w : at most %d bytes", ErrTooLarge, k.maxSize)
+	}
+	if err != nil {
+		return err
+	}
+	f.Size = n
+
+	head := make([]byte, 512)
+	m, err := f.tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	f.ContentType = sniff(head[:m])
+	if !k.allows(f.ContentType) {
+		return fmt.Errorf("%w : %s", ErrUnsupportedType, f.ContentType)
+	}
+	if declared := declaredMediaType(declaredType); declared != "" && declared != f.ContentType {
+		return fmt.Errorf("%w : declared %s, found %s", ErrTypeMismatch, declared, f.ContentType)
+	}
+
+	if k.archive {
+		if err := checkArchive(f.tmp, f.Size); err != nil {
+			return err
+		}
+	}
+	if k.image {
+		if err := f.reencode(); err != nil {
+			return err
+		}
+	}
+	for _, scan := range scanners {
+		if err := scan(ctx, f.Kind, f.ContentType, f.Reader()); err != nil {
+			return err
+		}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f.Reader()); err != nil {
+		return err
+	}
+	f.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// sniff is http.DetectContentType without parameters, recognizing JSON,
+// which it reports as plain text.
+func sniff(head []byte) string {
+	t, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if t == "text/plain" {
+		trimmed := bytes.TrimLeft(head, " \t\r\n")
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return TypeJSON
+		}
+	}
+	return t
+}
+
+// declaredMediaType ignores types that say nothing about the content.
+func declaredMediaType(declared string) string {
+	t, _, err := mime.ParseMediaType(declared)
+	if err != nil || t == "application/octet-stream" {
+		return ""
+	}
+	switch t {
+	case "image/jpg", "image/pjpeg":
+		return TypeJPEG
+	case "application/x-zip-compressed", "application/x-baasaxis":
+		return TypeZip
+	}
+	return t
+}
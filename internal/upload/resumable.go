@@ -0,0 +1,362 @@
+package upload
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/global_info"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+var (
+	ErrResumableNotFound = errors.New("resumable upload not found or expired")
+	ErrNotResumable      = errors.New("upload kind is not resumable")
+	ErrOffsetMismatch    = errors.New("upload offset does not match")
+	ErrResumableBusy     = errors.New("another request is writing to this upload")
+	ErrResumableDone     = errors.New("upload already complete")
+	ErrResumableQuota    = errors.New("too many unfinished uploads")
+)
+
+// resumableKinds are the kinds large enough to be worth resuming.
+var resumableKinds = map[string]bool{KindBundle: true, KindVideo: true}
+
+const expireBatchSize = 100
+
+// Resumable is an upload received in chunks. The data stays in the
+// resumable directory until the last chunk arrives, then it goes through the
+// pipeline and becomes an Upload.
+type Resumable struct {
+	ID          string    `db:"id" json:"id"`
+	UserID      uint64    `db:"user_id" json:"user_id"`
+	Kind        string    `db:"kind" json:"kind"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Length      int64     `db:"length" json:"length"`
+	Offset      int64     `db:"offset" json:"offset"`
+	UploadID    *uint64   `db:"upload_id" json:"-"`
+	TimeExpire  time.Time `db:"time_expire" json:"time_expire"`
+	TimeCreate  time.Time `db:"time_create" json:"time_create"`
+}
+
+const resumableColumns = "id, user_id, kind, content_type, length, `offset`, upload_id, time_expire, time_create"
+
+// writing holds the ids of uploads a request is appending to or completing,
+// a second request for the same upload is turned away instead of waiting.
+var writing sync.Map
+
+func (u *Resumable) lock() (func(), bool) {
+	if _, busy := writing.LoadOrStore(u.ID, struct{}{}); busy {
+		return nil, false
+	}
+	return func() { writing.Delete(u.ID) }, true
+}
+
+// reload refreshes the progress, another request may have moved it.
+func (u *Resumable) reload() error {
+	err := database.DB.Get(u, `SELECT `+resumableColumns+` FROM resumable_uploads WHERE id = ?`, u.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrResumableNotFound
+	}
+	return err
+}
+
+func resumableDir() string {
+	if dir := config.Config.Upload.ResumableDir; dir != "" {
+		return dir
+	}
+	return global_info.GO_BAAS_RESUMABLE_DIR
+}
+
+func resumableExpiry() time.Duration {
+	return time.Duration(config.Config.Upload.ResumableExpiryHours) * time.Hour
+}
+
+func (u *Resumable) path() string {
+	return filepath.Join(resumableDir(), u.ID)
+}
+
+// MaxResumableSize is the largest length a resumable upload may announce.
+func MaxResumableSize() int64 {
+	var size int64
+	for name := range resumableKinds {
+		if k, ok := lookupKind(name); ok && k.maxSize > size {
+			size = k.maxSize
+		}
+	}
+	return size
+}
+
+// CreateResumable starts an upload of length bytes. Oversized uploads are
+// refused here so the client does not send data that would be rejected, as
+// are uploads beyond the per user limits on unfinished ones.
+func CreateResumable(userID uint64, kindName string, contentType string, length int64) (*Resumable, error) {
+	if !resumableKinds[kindName] {
+		return nil, ErrNotResumable
+	}
+	k, _ := lookupKind(kindName)
+	if length > k.maxSize {
+		return nil, fmt.Errorf("%w : at most %d bytes", ErrTooLarge, k.maxSize)
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	u := &Resumable{ID: hex.EncodeToString(buf)}
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	// Locking the user row serializes the quota check per user.
+	var locked uint64
+	if err := tx.Get(&locked, `SELECT id FROM users WHERE id = ? FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+	if err := checkResumableQuota(tx, userID, length); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO resumable_uploads (id, user_id, kind, content_type, length, time_expire)
+		VALUES (?, ?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? HOUR))`,
+		u.ID, userID, kindName, contentType, length, config.Config.Upload.ResumableExpiryHours,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(resumableDir(), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(u.path(), nil, 0o600); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		os.Remove(u.path())
+		return nil, err
+	}
+	return GetResumable(u.ID, userID)
+}
+
+// checkResumableQuota counts the unexpired uploads of userID that are not
+// complete yet, along with the length they announced.
+func checkResumableQuota(tx *sqlx.Tx, userID uint64, length int64) error {
+	cfg := config.Config.Upload
+	var open struct {
+		Count  int   `db:"count"`
+		Length int64 `db:"length"`
+	}
+	err := tx.Get(&open,
+		`SELECT COUNT(*) AS count, COALESCE(SUM(length), 0) AS length FROM resumable_uploads
+		WHERE user_id = ? AND upload_id IS NULL AND time_expire > NOW()`, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if open.Count >= cfg.MaxResumablePerUser {
+		return fmt.Errorf("%w : at most %d at a time", ErrResumableQuota, cfg.MaxResumablePerUser)
+	}
+	if maxPending := int64(cfg.MaxResumablePendingMB) << 20; open.Length+length > maxPending {
+		return fmt.Errorf("%w : at most %d MB announced at a time", ErrResumableQuota, cfg.MaxResumablePendingMB)
+	}
+	return nil
+}
+
+// GetResumable returns an unexpired upload of userID.
+func GetResumable(id string, userID uint64) (*Resumable, error) {
+	var u Resumable
+	err := database.DB.Get(&u,
+		`SELECT `+resumableColumns+` FROM resumable_uploads WHERE id = ? AND user_id = ? AND time_expire > NOW()`,
+		id, userID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrResumableNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Append writes the chunk in r at offset, which must be where the upload
+// stands. Whatever arrived before r fails is kept, so the client can resume
+// from the offset it reads back. Every chunk pushes the expiry back.
+func (u *Resumable) Append(r io.Reader, offset int64) error {
+	unlock, ok := u.lock()
+	if !ok {
+		return ErrResumableBusy
+	}
+	defer unlock()
+	if err := u.reload(); err != nil {
+		return err
+	}
+	if u.Offset == u.Length {
+		return ErrResumableDone
+	}
+	if offset != u.Offset {
+		return ErrOffsetMismatch
+	}
+
+	file, err := os.OpenFile(u.path(), os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Drops bytes of an earlier chunk that were written but never counted.
+	if err := file.Truncate(u.Offset); err != nil {
+		return err
+	}
+	if _, err := file.Seek(u.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	n, copyErr := io.Copy(file, io.LimitReader(r, u.Length-u.Offset))
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	// The lock only covers this process, the offset check turns the chunk
+	// away when another request counted one meanwhile.
+	res, err := database.DB.Exec(
+		"UPDATE resumable_uploads SET `offset` = ?, time_expire = DATE_ADD(NOW(), INTERVAL ? HOUR) WHERE id = ? AND `offset` = ?",
+		u.Offset+n, config.Config.Upload.ResumableExpiryHours, u.ID, u.Offset,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrOffsetMismatch
+	}
+	u.Offset += n
+	u.TimeExpire = time.Now().Add(resumableExpiry())
+	return copyErr
+}
+
+// Complete runs the received data through the pipeline and saves it, or
+// returns the saved upload when that already happened. A rejected upload is
+// deleted, it cannot become acceptable by resuming.
+func (u *Resumable) Complete(ctx context.Context) (*Upload, error) {
+	unlock, ok := u.lock()
+	if !ok {
+		return nil, ErrResumableBusy
+	}
+	defer unlock()
+	if err := u.reload(); err != nil {
+		return nil, err
+	}
+	if u.UploadID != nil {
+		return Get(*u.UploadID)
+	}
+	if u.Offset != u.Length {
+		return nil, ErrOffsetMismatch
+	}
+	file, err := os.Open(u.path())
+	if err != nil {
+		return nil, err
+	}
+	f, err := Process(ctx, u.Kind, file, u.ContentType)
+	file.Close()
+	if err != nil {
+		if Rejected(err) {
+			u.Delete()
+		}
+		return nil, err
+	}
+	defer f.Close()
+	saved, err := Save(ctx, u.UserID, f)
+	if err != nil {
+		return nil, err
+	}
+	// The row stays until it expires so the client can still look up the
+	// result, the data is not needed anymore.
+	if _, err := database.DB.Exec(`UPDATE resumable_uploads SET upload_id = ? WHERE id = ?`, saved.ID, u.ID); err != nil {
+		return nil, err
+	}
+	u.UploadID = &saved.ID
+	os.Remove(u.path())
+	return saved, nil
+}
+
+// Delete terminates the upload and drops its data.
+func (u *Resumable) Delete() error {
+	if _, err := database.DB.Exec(`DELETE FROM resumable_uploads WHERE id = ?`, u.ID); err != nil {
+		return err
+	}
+	if err := os.Remove(u.path()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ExpireResumable deletes abandoned uploads and returns how many.
+func ExpireResumable() (int, error) {
+	deleted := 0
+	for {
+		expired := []Resumable{}
+		err := database.DB.Select(&expired,
+			`SELECT `+resumableColumns+` FROM resumable_uploads WHERE time_expire <= NOW() LIMIT ?`,
+			expireBatchSize,
+		)
+		if err != nil {
+			return deleted, err
+		}
+		for _, u := range expired {
+			if err := u.Delete(); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+		if len(expired) < expireBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+func StartExpiryJob() {
+	if resumableExpiry() <= 0 {
+		logger.BAASWarn("Resumable upload expiry job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := ExpireResumable()
+			if err != nil {
+				logger.BAASError("Resumable Upload Expiry Error :", err.Error())
+			}
+			if n > 0 {
+				logger.BAASInfo("Expired resumable uploads :", strconv.Itoa(n))
+			}
+		}
+	}()
+}
+
+// purgeResumable drops the unfinished uploads of a user along with their
+// data, the rows alone would go with the user anyway.
+func purgeResumable(tx *sqlx.Tx, userID uint64) error {
+	ids := []string{}
+	if err := tx.Select(&ids, `SELECT id FROM resumable_uploads WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM resumable_uploads WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		os.Remove(filepath.Join(resumableDir(), id))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package upload
+
+import (
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+)
+
+// The resumable endpoints speak the core, creation, expiration and
+// termination parts of the tus 1.0.0 protocol, so stock tus clients work.
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+	chunkType     = "application/offset+octet-stream"
+)
+
+// requireTusResumable answers requests of other protocol versions with 412.
+func requireTusResumable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if r.Header.Get("Tus-Resumable") != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			api.ResponseWithError(w, http.StatusPreconditionFailed, api.CodeInvalidRequest, "unsupported Tus-Resumable version")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func handlerResumableOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(MaxResumableSize(), 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerCreateResumable takes the total size as Upload-Length and the kind,
+// plus optionally the filetype, as Upload-Metadata.
+func handlerCreateResumable(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid Upload-Length")
+		return
+	}
+	meta, err := parseMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	contentType := ""
+	if t, _, err := mime.ParseMediaType(meta["filetype"]); err == nil {
+		contentType = t
+	}
+	u, err := CreateResumable(userID, meta["kind"], contentType, length)
+	if err != nil {
+		respondResumableError(w, err)
+		return
+	}
+	w.Header().Set("Location", resumableURL(u.ID))
+	setProgressHeaders(w, u)
+	api.ResponseWithJson(w, http.StatusCreated, u)
+}
+
+func handlerHeadResumable(w http.ResponseWriter, r *http.Request) {
+	u, ok := loadResumable(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Upload-Length", strconv.FormatInt(u.Length, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	setProgressHeaders(w, u)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handlerPatchResumable appends one chunk. The chunk completing the upload
+// also runs the pipeline, the saved upload is named in X-Upload-Id and
+// X-Upload-URL.
+func handlerPatchResumable(w http.ResponseWriter, r *http.Request) {
+	if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t != chunkType {
+		api.ResponseWithError(w, http.StatusUnsupportedMediaType, api.CodeUnsupportedMediaType, "chunks must be sent as "+chunkType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid Upload-Offset")
+		return
+	}
+	u, ok := loadResumable(w, r)
+	if !ok {
+		return
+	}
+	if err := u.Append(r.Body, offset); err != nil && !errors.Is(err, ErrResumableDone) {
+		respondResumableError(w, err)
+		return
+	}
+	if u.Offset == u.Length {
+		saved, err := u.Complete(r.Context())
+		if err != nil {
+			respondResumableError(w, err)
+			return
+		}
+		w.Header().Set("X-Upload-Id", saved.PublicID)
+		w.Header().Set("X-Upload-URL", saved.URL)
+	}
+	setProgressHeaders(w, u)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetResumable reports the progress as JSON, with the saved upload
+// once complete, for clients that missed the answer to their last chunk.
+func handlerGetResumable(w http.ResponseWriter, r *http.Request) {
+	u, ok := loadResumable(w, r)
+	if !ok {
+		return
+	}
+	resp := map[string]interface{}{"resumable": u}
+	if u.UploadID != nil {
+		saved, err := Get(*u.UploadID)
+		if err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+		resp["upload"] = saved
+	}
+	api.ResponseWithJson(w, http.StatusOK, resp)
+}
+
+func handlerDeleteResumable(w http.ResponseWriter, r *http.Request) {
+	u, ok := loadResumable(w, r)
+	if !ok {
+		return
+	}
+	if err := u.Delete(); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func loadResumable(w http.ResponseWriter, r *http.Request) (*Resumable, bool) {
+	userID, _ := api.CurrentUserID(r)
+	u, err := GetResumable(chi.URLParam(r, "id"), userID)
+	if err != nil {
+		respondResumableError(w, err)
+		return nil, false
+	}
+	return u, true
+}
+
+func setProgressHeaders(w http.ResponseWriter, u *Resumable) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	w.Header().Set("Upload-Expires", u.TimeExpire.UTC().Format(http.TimeFormat))
+}
+
+func resumableURL(id string) string {
+	return config.Config.Server.PublicURL + "/api/v1/uploads/resumable/" + id
+}
+
+// parseMetadata decodes Upload-Metadata, comma separated pairs of a key and
+// a base64 value, where the value may be left out.
+func parseMetadata(header string) (map[string]string, error) {
+	meta := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return meta, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("invalid Upload-Metadata")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errors.New("invalid Upload-Metadata value of " + key)
+		}
+		meta[key] = string(decoded)
+	}
+	return meta, nil
+}
+
+func respondResumableError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrResumableNotFound):
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+	case errors.Is(err, ErrNotResumable):
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+	case errors.Is(err, ErrOffsetMismatch):
+		api.ResponseWithError(w, http.StatusConflict, api.CodeConflict, err.Error())
+	case errors.Is(err, ErrResumableBusy):
+		api.ResponseWithError(w, http.StatusLocked, api.CodeConflict, err.Error())
+	case errors.Is(err, ErrResumableQuota):
+		api.ResponseWithError(w, http.StatusTooManyRequests, api.CodeRateLimited, err.Error())
+	default:
+		RespondError(w, err)
+	}
+}
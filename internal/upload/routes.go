@@ -0,0 +1,24 @@
+package upload
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/{id}", handlerGetUpload)
+	r.With(api.RequireUser, api.RequireScope(api.ScopeAxisWrite)).Post("/screenshots", handlerUploadScreenshot)
+	r.Route("/resumable", func(r chi.Router) {
+		r.Options("/", handlerResumableOptions)
+		r.Group(func(r chi.Router) {
+			r.Use(api.RequireUser, api.RequireScope(api.ScopeAxisWrite))
+			r.Get("/{id}", handlerGetResumable)
+			r.With(requireTusResumable).Post("/", handlerCreateResumable)
+			r.With(requireTusResumable).Head("/{id}", handlerHeadResumable)
+			r.With(requireTusResumable).Patch("/{id}", handlerPatchResumable)
+			r.With(requireTusResumable).Delete("/{id}", handlerDeleteResumable)
+		})
+	})
+	return r
+}
@@ -0,0 +1,19 @@
+package upload
+
+import (
+	"context"
+	"io"
+)
+
+// Scanner inspects an upload that passed the built in checks, for example
+// by handing it to an antivirus daemon. It returns an error wrapping
+// ErrRejected to refuse the file; any other error fails the upload as an
+// internal error.
+type Scanner func(ctx context.Context, kind, contentType string, r io.Reader) error
+
+var scanners []Scanner
+
+// RegisterScanner must be called before the server starts.
+func RegisterScanner(s Scanner) {
+	scanners = append(scanners, s)
+}
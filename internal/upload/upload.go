@@ -0,0 +1,121 @@
+package upload
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/storage"
+)
+
+var ErrNotFound = errors.New("upload not found")
+
+// Upload records a stored file and who uploaded it. Its URL is stable and
+// redirects to a short lived signed link. Outside the database it is known
+// by the random PublicID only, so uploads cannot be enumerated.
+type Upload struct {
+	ID          uint64    `db:"id" json:"-"`
+	PublicID    string    `db:"public_id" json:"id"`
+	UserID      uint64    `db:"user_id" json:"user_id"`
+	Kind        string    `db:"kind" json:"kind"`
+	StorageKey  string    `db:"storage_key" json:"-"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Size        int64     `db:"size" json:"size"`
+	SHA256      string    `db:"sha256" json:"sha256"`
+	URL         string    `db:"-" json:"url"`
+	TimeCreate  time.Time `db:"time_create" json:"time_create"`
+}
+
+const uploadColumns = `id, public_id, user_id, kind, storage_key, content_type, size, sha256, time_create`
+
+// Save stores a processed file and records it for userID.
+func Save(ctx context.Context, userID uint64, f *File) (*Upload, error) {
+	obj, err := f.Store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	publicID := hex.EncodeToString(buf)
+	_, err = database.DB.Exec(
+		`INSERT INTO uploads (public_id, user_id, kind, storage_key, content_type, size, sha256) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		publicID, userID, f.Kind, obj.Key, f.ContentType, f.Size, f.SHA256,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return GetByPublicID(publicID)
+}
+
+func Get(id uint64) (*Upload, error) {
+	return getOne(`SELECT `+uploadColumns+` FROM uploads WHERE id = ?`, id)
+}
+
+func GetByPublicID(publicID string) (*Upload, error) {
+	return getOne(`SELECT `+uploadColumns+` FROM uploads WHERE public_id = ?`, publicID)
+}
+
+func getOne(query string, args ...interface{}) (*Upload, error) {
+	var u Upload
+	err := database.DB.Get(&u, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.URL = URL(u.PublicID)
+	return &u, nil
+}
+
+// Public reports whether anyone may download the upload. Avatars and
+// clear screenshots are shown to everyone, bundles and videos only to their
+// owner.
+func (u *Upload) Public() bool {
+	return publicKinds[u.Kind]
+}
+
+// Open fetches the stored file of u back for reading, for uploads that are
+// used after they were saved, such as bundles sent in chunks. The caller
+// closes the file.
+func Open(ctx context.Context, u *Upload) (*File, error) {
+	body, _, err := storage.Store.Get(ctx, u.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	tmp, err := os.CreateTemp("", "go_baas_upload_*")
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Kind: u.Kind, ContentType: u.ContentType, Size: u.Size, SHA256: u.SHA256, tmp: tmp}
+	if _, err := io.Copy(tmp, body); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func URL(publicID string) string {
+	return config.Config.Server.PublicURL + "/api/v1/uploads/" + publicID
+}
+
+// PurgeUser forgets the uploads of a user. The stored files may be shared
+// with identical uploads of others and stay.
+func PurgeUser(tx *sqlx.Tx, userID uint64) error {
+	if err := purgeResumable(tx, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM uploads WHERE user_id = ?`, userID)
+	return err
+}
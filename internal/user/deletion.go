@@ -0,0 +1,168 @@
+package user
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/logger"
+)
+
+// PurgeHook removes data another package keeps about a user whose deletion
+// grace period has expired. It runs inside the purge transaction.
+type PurgeHook func(tx *sqlx.Tx, userID uint64) error
+
+var purgeHooks []PurgeHook
+
+func RegisterPurgeHook(hook PurgeHook) {
+	purgeHooks = append(purgeHooks, hook)
+}
+
+// AvailabilityHook records what another package derives from a user
+// becoming unavailable, by deletion, suspension or ban, or available again.
+// It runs inside the transaction changing the account.
+type AvailabilityHook func(tx *sqlx.Tx, userID uint64, available bool) error
+
+var availabilityHooks []AvailabilityHook
+
+func RegisterAvailabilityHook(hook AvailabilityHook) {
+	availabilityHooks = append(availabilityHooks, hook)
+}
+
+func runAvailabilityHooks(tx *sqlx.Tx, userID uint64, available bool) error {
+	for _, hook := range availabilityHooks {
+		if err := hook(tx, userID, available); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SoftDelete marks the account as deleted and signs it out everywhere. The
+// row stays restorable until the purge job anonymizes it.
+func SoftDelete(id uint64) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE users SET is_deleted = TRUE, time_deleted = NOW() WHERE id = ? AND is_deleted = FALSE`, id,
+	)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+	if err := runAvailabilityHooks(tx, id, false); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func Restore(id uint64) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE users SET is_deleted = FALSE, time_deleted = NULL WHERE id = ? AND is_deleted = TRUE AND time_purged IS NULL`, id,
+	)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+	if err := runAvailabilityHooks(tx, id, true); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeExpired anonymizes every account whose grace period has run out. The
+// users row is kept as a tombstone so content foreign keys stay valid.
+func PurgeExpired() (int, error) {
+	var ids []uint64
+	err := database.DB.Select(&ids,
+		`SELECT id FROM users
+		WHERE is_deleted = TRUE AND time_purged IS NULL AND time_deleted < DATE_SUB(NOW(), INTERVAL ? DAY)`,
+		config.Config.User.DeletionGraceDays,
+	)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := purge(id); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+func purge(id uint64) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE users SET
+			username = CONCAT('deleted_', id),
+			email = CONCAT('deleted_', id, '@deleted.invalid'),
+			password_hash = '',
+			phone = NULL,
+			social_links = NULL,
+			avatar = NULL,
+			status_reason = NULL,
+			time_last_login = NULL,
+			time_purged = NOW()
+		WHERE id = ?`, id,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM login_history WHERE user_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = ?`, id); err != nil {
+		return err
+	}
+	for _, hook := range purgeHooks {
+		if err := hook(tx, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func StartPurgeJob() {
+	interval := time.Duration(config.Config.User.PurgeIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		logger.BAASWarn("User purge job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := PurgeExpired()
+			if err != nil {
+				logger.BAASError("User Purge Error :", err.Error())
+			}
+			if n > 0 {
+				logger.BAASInfo("Purged deleted users :", strconv.Itoa(n))
+			}
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+// ExportSection collects one part of a user's personal data. The result is
+// written to "<name>.json" in the export archive.
+type ExportSection func(userID uint64) (interface{}, error)
+
+type exportEntry struct {
+	name    string
+	collect ExportSection
+}
+
+var exportSections = []exportEntry{
+	{name: "profile", collect: exportProfile},
+	{name: "login_history", collect: exportLoginHistory},
+}
+
+func RegisterExportSection(name string, collect ExportSection) {
+	exportSections = append(exportSections, exportEntry{name: name, collect: collect})
+}
+
+type LoginRecord struct {
+	LoginTime     time.Time `db:"login_time" json:"login_time"`
+	LoginIP       string    `db:"login_ip" json:"login_ip"`
+	UserAgent     *string   `db:"user_agent" json:"user_agent"`
+	LoginMethod   *string   `db:"login_method" json:"login_method"`
+	Success       bool      `db:"success" json:"success"`
+	FailureReason *string   `db:"failure_reason" json:"failure_reason"`
+	Country       *string   `db:"country" json:"country"`
+	Region        *string   `db:"region" json:"region"`
+	City          *string   `db:"city" json:"city"`
+	DeviceID      *string   `db:"device_id" json:"device_id"`
+}
+
+func exportProfile(userID uint64) (interface{}, error) {
+	return GetByID(userID)
+}
+
+func exportLoginHistory(userID uint64) (interface{}, error) {
+	records := []LoginRecord{}
+	err := database.DB.Select(&records,
+		`SELECT login_time, login_ip, user_agent, login_method, success, failure_reason, country, region, city, device_id
+		FROM login_history WHERE user_id = ? ORDER BY login_time`, userID,
+	)
+	return records, err
+}
+
+// WriteExport collects every section before writing anything, so a failing
+// section never leaves a half-written archive on the wire.
+func WriteExport(w io.Writer, userID uint64) error {
+	files := make([][]byte, len(exportSections))
+	for i, section := range exportSections {
+		payload, err := section.collect(userID)
+		if err != nil {
+			return err
+		}
+		files[i], err = json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	for i, section := range exportSections {
+		f, err := zw.Create(section.name + ".json")
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(files[i]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
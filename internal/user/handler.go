@@ -0,0 +1,229 @@
+package user
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/upload"
+)
+
+type deleteMeRequest struct {
+	ConfirmUsername string `json:"confirm_username"`
+}
+
+type loginRequest struct {
+	// Login is a username or an email.
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type setStatusRequest struct {
+	Status int8       `json:"status"`
+	Reason *string    `json:"reason"`
+	Until  *time.Time `json:"until"`
+}
+
+// handlerLogin checks the password and sets the session cookie. Sessions
+// are what account management and token creation require.
+func handlerLogin(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.Login == "" || req.Password == "" {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "login and password are required")
+		return
+	}
+	ip, userAgent := api.ClientIP(r), r.UserAgent()
+	u, err := CheckPassword(req.Login, req.Password, ip, userAgent)
+	if errors.Is(err, ErrInvalidCredentials) {
+		api.ResponseWithError(w, http.StatusUnauthorized, api.CodeUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	switch u.EffectiveStatus(time.Now()) {
+	case StatusSuspended:
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeAccountSuspended, "account suspended")
+		return
+	case StatusBanned:
+		api.ResponseWithError(w, http.StatusForbidden, api.CodeAccountBanned, "account banned")
+		return
+	}
+	token, err := CreateSession(u.ID, ip, userAgent)
+	if err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	http.SetCookie(w, SessionCookie(token, config.Config.User.SessionTTLHours*3600))
+	api.ResponseWithJson(w, http.StatusOK, u)
+}
+
+func handlerLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		if err := RevokeSession(cookie.Value); err != nil {
+			api.ResponseWithInternalError(w, err)
+			return
+		}
+	}
+	http.SetCookie(w, SessionCookie("", -1))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerGetMe(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	u, err := GetByID(userID)
+	if err != nil {
+		respondUserError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, u)
+}
+
+// handlerSetAvatar takes the raw image as the body.
+func handlerSetAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	up, ok := upload.Receive(w, r, upload.KindAvatar, userID)
+	if !ok {
+		return
+	}
+	if err := SetAvatar(userID, &up.URL); err != nil {
+		respondUserError(w, err)
+		return
+	}
+	handlerGetMe(w, r)
+}
+
+func handlerDeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	if err := SetAvatar(userID, nil); err != nil {
+		respondUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handlerDeleteMe(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	var req deleteMeRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	u, err := GetByID(userID)
+	if err != nil {
+		respondUserError(w, err)
+		return
+	}
+	if req.ConfirmUsername != u.Username {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "confirm_username does not match")
+		return
+	}
+	if err := SoftDelete(userID); err != nil {
+		respondUserError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, map[string]interface{}{
+		"status":      "deleted",
+		"purge_after": time.Now().AddDate(0, 0, config.Config.User.DeletionGraceDays),
+	})
+}
+
+func handlerExportMe(w http.ResponseWriter, r *http.Request) {
+	userID, _ := api.CurrentUserID(r)
+	u, err := GetByID(userID)
+	if err != nil {
+		respondUserError(w, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, userID); err != nil {
+		api.ResponseWithInternalError(w, err)
+		return
+	}
+	filename := fmt.Sprintf("go_baas_export_%d_%s.zip", u.ID, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func handlerGetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid user id")
+		return
+	}
+	u, err := GetByID(id)
+	if err != nil {
+		respondUserError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, u.Public())
+}
+
+func handlerSetStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid user id")
+		return
+	}
+	var req setStatusRequest
+	if err := api.DecodeJson(r, &req); err != nil {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	switch req.Status {
+	case StatusActive:
+		req.Reason, req.Until = nil, nil
+	case StatusSuspended:
+	case StatusBanned:
+		req.Until = nil
+	default:
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "unknown status")
+		return
+	}
+	if req.Reason != nil && len(*req.Reason) > 255 {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "reason too long")
+		return
+	}
+	if err := SetStatus(id, req.Status, req.Reason, req.Until); err != nil {
+		respondUserError(w, err)
+		return
+	}
+	u, err := GetByID(id)
+	if err != nil {
+		respondUserError(w, err)
+		return
+	}
+	api.ResponseWithJson(w, http.StatusOK, u)
+}
+
+func handlerRestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.URLParamID(r, "id")
+	if !ok {
+		api.ResponseWithError(w, http.StatusBadRequest, api.CodeInvalidRequest, "invalid user id")
+		return
+	}
+	if err := Restore(id); err != nil {
+		respondUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondUserError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		api.ResponseWithError(w, http.StatusNotFound, api.CodeNotFound, err.Error())
+		return
+	}
+	api.ResponseWithInternalError(w, err)
+}
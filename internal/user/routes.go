@@ -0,0 +1,35 @@
+package user
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+)
+
+func Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/{id}", handlerGetUser)
+	r.Post("/login", handlerLogin)
+	r.Post("/logout", handlerLogout)
+
+	r.With(api.RequireUser, api.RequireScope(api.ScopeProfileRead)).Get("/me", handlerGetMe)
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireUser, api.RequireScope(api.ScopeProfileWrite))
+		r.Put("/me/avatar", handlerSetAvatar)
+		r.Delete("/me/avatar", handlerDeleteAvatar)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireUser, api.RequireSession)
+		r.Delete("/me", handlerDeleteMe)
+		r.Get("/me/export", handlerExportMe)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(api.RequireSession, rbac.RequirePermission(rbac.PermUserManage))
+		r.Put("/{id}/status", handlerSetStatus)
+		r.Post("/{id}/restore", handlerRestoreUser)
+	})
+	return r
+}
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pur1fying/GO_BAAS/internal/config"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const SessionCookieName = "go_baas_session"
+
+var (
+	ErrSessionInvalid     = errors.New("session invalid or expired")
+	ErrInvalidCredentials = errors.New("wrong username, email or password")
+)
+
+// dummyHash is compared against when the user does not exist, so an unknown
+// name takes as long to reject as a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("go_baas"), bcrypt.DefaultCost)
+
+// CheckPassword finds the user by username or email and checks password
+// against its bcrypt hash. Failed attempts of existing users are recorded
+// in login_history.
+func CheckPassword(login string, password string, ip string, userAgent string) (*User, error) {
+	u, err := GetByUsername(login)
+	if errors.Is(err, ErrNotFound) {
+		u, err = GetByEmail(login)
+	}
+	if errors.Is(err, ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		reason := "wrong password"
+		if err := recordLogin(u.ID, ip, userAgent, &reason); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// recordLogin writes a login attempt, failureReason is nil on success.
+func recordLogin(userID uint64, ip string, userAgent string, failureReason *string) error {
+	_, err := database.DB.Exec(
+		`INSERT INTO login_history (user_id, login_ip, user_agent, login_method, success, failure_reason)
+		VALUES (?, ?, ?, 'password', ?, ?)`,
+		userID, ip, userAgent, failureReason == nil, failureReason,
+	)
+	return err
+}
+
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession logs userID in and returns the token for the session
+// cookie.
+func CreateSession(userID uint64, ip string, userAgent string) (string, error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = database.DB.Exec(
+		`INSERT INTO user_sessions (user_id, token_hash, ip, user_agent, time_expire)
+		VALUES (?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? HOUR))`,
+		userID, HashToken(token), ip, userAgent, config.Config.User.SessionTTLHours,
+	)
+	if err != nil {
+		return "", err
+	}
+	if _, err := database.DB.Exec(`UPDATE users SET time_last_login = NOW(), time_last_update = time_last_update WHERE id = ?`, userID); err != nil {
+		return "", err
+	}
+	if err := recordLogin(userID, ip, userAgent, nil); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// SessionCookie is sent Secure when the public address is https, a
+// negative maxAge removes it.
+func SessionCookie(token string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(config.Config.Server.PublicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// LookupSession resolves a session token to its (not soft-deleted) owner.
+func LookupSession(token string) (*User, error) {
+	var userID uint64
+	err := database.DB.Get(&userID,
+		`SELECT user_id FROM user_sessions WHERE token_hash = ? AND time_expire > NOW()`,
+		HashToken(token),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	u, err := GetByID(userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrSessionInvalid
+	}
+	return u, err
+}
+
+func RevokeSession(token string) error {
+	_, err := database.DB.Exec(`DELETE FROM user_sessions WHERE token_hash = ?`, HashToken(token))
+	return err
+}
+
+func RevokeUserSessions(userID uint64) error {
+	_, err := database.DB.Exec(`DELETE FROM user_sessions WHERE user_id = ?`, userID)
+	return err
+}
@@ -0,0 +1,137 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/pur1fying/GO_BAAS/internal/database"
+)
+
+// users.status
+const (
+	StatusActive    int8 = 1
+	StatusSuspended int8 = 2
+	StatusBanned    int8 = 3
+)
+
+var ErrNotFound = errors.New("user not found")
+
+type User struct {
+	ID              uint64             `db:"id" json:"id"`
+	Username        string             `db:"username" json:"username"`
+	Email           string             `db:"email" json:"email"`
+	PasswordHash    string             `db:"password_hash" json:"-"`
+	Phone           *string            `db:"phone" json:"phone"`
+	SocialLinks     types.NullJSONText `db:"social_links" json:"social_links"`
+	Status          int8               `db:"status" json:"status"`
+	StatusReason    *string            `db:"status_reason" json:"status_reason"`
+	TimeStatusUntil *time.Time         `db:"time_status_until" json:"time_status_until"`
+	IsVerified      bool               `db:"is_verified" json:"is_verified"`
+	IsDeleted       bool               `db:"is_deleted" json:"-"`
+	Avatar          *string            `db:"avatar" json:"avatar"`
+	TimeCreate      time.Time          `db:"time_create" json:"time_create"`
+	TimeLastUpdate  time.Time          `db:"time_last_update" json:"time_last_update"`
+	TimeLastLogin   *time.Time         `db:"time_last_login" json:"time_last_login"`
+	TimeDeleted     *time.Time         `db:"time_deleted" json:"-"`
+}
+
+type PublicProfile struct {
+	ID          uint64             `json:"id"`
+	Username    string             `json:"username"`
+	Avatar      *string            `json:"avatar"`
+	SocialLinks types.NullJSONText `json:"social_links"`
+	TimeCreate  time.Time          `json:"time_create"`
+}
+
+const userColumns = `id, username, email, password_hash, phone, social_links,
+	status, status_reason, time_status_until, is_verified, is_deleted, avatar,
+	time_create, time_last_update, time_last_login, time_deleted`
+
+func (u *User) Public() PublicProfile {
+	return PublicProfile{
+		ID:          u.ID,
+		Username:    u.Username,
+		Avatar:      u.Avatar,
+		SocialLinks: u.SocialLinks,
+		TimeCreate:  u.TimeCreate,
+	}
+}
+
+// EffectiveStatus folds an expired suspension back into StatusActive.
+func (u *User) EffectiveStatus(now time.Time) int8 {
+	if u.Status == StatusSuspended && u.TimeStatusUntil != nil && !now.Before(*u.TimeStatusUntil) {
+		return StatusActive
+	}
+	return u.Status
+}
+
+func GetByID(id uint64) (*User, error) {
+	return getOne(`SELECT `+userColumns+` FROM users WHERE id = ? AND is_deleted = FALSE`, id)
+}
+
+func GetByUsername(username string) (*User, error) {
+	return getOne(`SELECT `+userColumns+` FROM users WHERE username = ? AND is_deleted = FALSE`, username)
+}
+
+func GetByEmail(email string) (*User, error) {
+	return getOne(`SELECT `+userColumns+` FROM users WHERE email = ? AND is_deleted = FALSE`, email)
+}
+
+func getOne(query string, args ...interface{}) (*User, error) {
+	var u User
+	err := database.DB.Get(&u, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// SetStatus suspends, bans or reactivates a user. A suspension ending on its
+// own at time_status_until runs no availability hooks.
+func SetStatus(id uint64, status int8, reason *string, until *time.Time) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE users SET status = ?, status_reason = ?, time_status_until = ? WHERE id = ? AND is_deleted = FALSE`,
+		status, reason, until, id,
+	)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(res); err != nil {
+		return err
+	}
+	if err := runAvailabilityHooks(tx, id, status == StatusActive); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetAvatar sets the avatar link of a user, nil removes it.
+func SetAvatar(id uint64, avatar *string) error {
+	res, err := database.DB.Exec(`UPDATE users SET avatar = ? WHERE id = ? AND is_deleted = FALSE`, avatar, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -1,34 +1,47 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"embed"
+	"io/fs"
 	"net/http"
+	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/pur1fying/GO_BAAS/internal/api"
+	"github.com/pur1fying/GO_BAAS/internal/audit"
+	"github.com/pur1fying/GO_BAAS/internal/auth"
+	"github.com/pur1fying/GO_BAAS/internal/axis"
+	"github.com/pur1fying/GO_BAAS/internal/catalog"
+	"github.com/pur1fying/GO_BAAS/internal/collection"
 	"github.com/pur1fying/GO_BAAS/internal/config"
 	"github.com/pur1fying/GO_BAAS/internal/database"
+	"github.com/pur1fying/GO_BAAS/internal/follow"
 	"github.com/pur1fying/GO_BAAS/internal/mail"
+	"github.com/pur1fying/GO_BAAS/internal/moderation"
+	"github.com/pur1fying/GO_BAAS/internal/notify"
+	"github.com/pur1fying/GO_BAAS/internal/rbac"
+	"github.com/pur1fying/GO_BAAS/internal/roster"
+	"github.com/pur1fying/GO_BAAS/internal/storage"
+	"github.com/pur1fying/GO_BAAS/internal/token"
+	"github.com/pur1fying/GO_BAAS/internal/upload"
+	"github.com/pur1fying/GO_BAAS/internal/user"
 
 	"github.com/pur1fying/GO_BAAS/internal/global_info"
 	"github.com/pur1fying/GO_BAAS/internal/logger"
 )
 
-func ResponseWithJson(w http.ResponseWriter, statusCode int, payload interface{}) {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		logger.BAASError(fmt.Sprintf("JSON 序列化失败: %v", err))
-		w.WriteHeader(500)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	w.Write(data)
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// migrations are the scripts of sql/, built into the binary.
+func migrations() fs.FS {
+	sub, _ := fs.Sub(sqlFiles, "sql")
+	return sub
 }
 
 func handlerReadiness(w http.ResponseWriter, r *http.Request) {
-	ResponseWithJson(w, 200, map[string]string{"status": "ready"})
+	api.ResponseWithJson(w, 200, map[string]string{"status": "ready"})
 }
 
 func main() {
@@ -47,6 +60,40 @@ func main() {
 		logger.BAASCritical("Failed to init database:", err.Error())
 	}
 
+	err = storage.Init()
+	if err != nil {
+		logger.BAASCritical("Failed to init storage:", err.Error())
+	}
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			logger.BAASError(err.Error())
+			logger.Flush()
+			os.Exit(1)
+		}
+		logger.Flush()
+		return
+	}
+
+	if config.Config.Database.AutoMigrate {
+		err = database.Migrate(migrations())
+		if err != nil {
+			logger.BAASCritical("Failed to migrate database:", err.Error())
+		}
+	}
+
+	err = seedInitialAdmin()
+	if err != nil {
+		logger.BAASError("Failed to seed initial admin:", err.Error())
+	}
+
+	if dir := catalogDir(); config.Config.Catalog.ImportOnStartup && dirExists(dir) {
+		err = importCatalog(dir)
+		if err != nil {
+			logger.BAASError("Failed to import catalog:", err.Error())
+		}
+	}
+
 	// Mail Smtp Init
 	err = mail.InitMail()
 	if err != nil {
@@ -72,13 +119,54 @@ func main() {
 	router := chi.NewRouter()
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Location", "Upload-Offset", "Upload-Length", "Upload-Expires", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "X-Upload-Id", "X-Upload-URL"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 	router.HandleFunc("/readiness", handlerReadiness)
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Use(api.Localize, auth.Authenticate)
+		r.Mount("/users", user.Routes())
+		r.Mount("/roles", rbac.Routes())
+		r.Mount("/tokens", token.Routes())
+		r.Mount("/axes", axis.Routes())
+		r.Mount("/collections", collection.Routes())
+		r.Mount("/roster", roster.Routes())
+		r.Mount("/catalog", catalog.Routes())
+		r.Mount("/audit", audit.Routes())
+		r.Mount("/moderation", moderation.Routes())
+		r.Mount("/notifications", notify.Routes())
+		r.Mount("/follows", follow.Routes())
+		r.Mount("/files", storage.Routes())
+		r.Mount("/uploads", upload.Routes())
+	})
+
+	user.RegisterPurgeHook(rbac.PurgeUser)
+	user.RegisterPurgeHook(token.PurgeUser)
+	user.RegisterPurgeHook(roster.PurgeUser)
+	user.RegisterPurgeHook(axis.PurgeUser)
+	user.RegisterPurgeHook(moderation.PurgeUser)
+	user.RegisterPurgeHook(notify.PurgeUser)
+	user.RegisterPurgeHook(follow.PurgeUser)
+	user.RegisterPurgeHook(upload.PurgeUser)
+	user.RegisterAvailabilityHook(axis.RecordOwnerChange)
+	user.RegisterExportSection("access_tokens", token.ExportUser)
+	user.RegisterExportSection("axes", axis.ExportUser)
+	user.RegisterExportSection("roster", roster.ExportUser)
+	user.RegisterExportSection("axis_ratings", axis.ExportUserRatings)
+	user.RegisterExportSection("axis_favorites", axis.ExportUserFavorites)
+	user.RegisterExportSection("axis_comments", axis.ExportUserComments)
+	user.RegisterExportSection("axis_clears", axis.ExportUserClears)
+	user.RegisterExportSection("collections", collection.ExportUser)
+	user.RegisterExportSection("follows", follow.ExportUser)
+	user.StartPurgeJob()
+	axis.StartScoreJob()
+	notify.StartPruneJob()
+	follow.StartDigestJob()
+	upload.StartExpiryJob()
+	axis.StartRevisionStorageJob()
 
 	svr := &http.Server{
 		Handler: router,
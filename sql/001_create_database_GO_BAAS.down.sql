@@ -0,0 +1 @@
+-- 数据库本身不随迁移删除
@@ -1,2 +1,2 @@
-CREATE DATABASE IF NOT EXISTS `go_baas` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
-USE `go_baas`;
\ No newline at end of file
+-- 数据库由 database.Init 按配置中的 database.name 创建 (utf8mb4, utf8mb4_unicode_ci),
+-- 迁移在该数据库的连接上执行, 因此这里不再 CREATE DATABASE 或 USE
@@ -16,4 +16,4 @@ CREATE TABLE IF NOT EXISTS `users` (
     time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     time_last_login DATETIME NULL
 
-)
\ No newline at end of file
+);
@@ -0,0 +1,6 @@
+ALTER TABLE `users`
+    DROP INDEX idx_is_deleted,
+    DROP COLUMN status_reason,
+    DROP COLUMN time_status_until,
+    DROP COLUMN time_deleted,
+    DROP COLUMN time_purged;
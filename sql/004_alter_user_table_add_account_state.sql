@@ -0,0 +1,6 @@
+ALTER TABLE `users`
+    ADD COLUMN status_reason VARCHAR(255) NULL COMMENT '封禁/停用原因' AFTER status,
+    ADD COLUMN time_status_until DATETIME NULL COMMENT '停用截止时间, NULL表示永久' AFTER status_reason,
+    ADD COLUMN time_deleted DATETIME NULL COMMENT '申请注销时间' AFTER is_deleted,
+    ADD COLUMN time_purged DATETIME NULL COMMENT '个人数据清除时间' AFTER time_deleted,
+    ADD INDEX idx_is_deleted (is_deleted, time_deleted);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    token_hash CHAR(64) NOT NULL COMMENT 'SHA-256(token)',
+
+    ip VARCHAR(45) NULL COMMENT '支持IPv6',
+    user_agent TEXT NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_expire DATETIME NOT NULL,
+
+    UNIQUE INDEX idx_token_hash (token_hash),
+    INDEX idx_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS `roles` (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL,
+    description VARCHAR(255) NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `permissions` (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL COMMENT '例如 axis.moderate',
+    description VARCHAR(255) NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+    role_id INT UNSIGNED NOT NULL,
+    permission_id INT UNSIGNED NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_roles` (
+    user_id BIGINT UNSIGNED NOT NULL,
+    role_id INT UNSIGNED NOT NULL,
+    granted_by BIGINT UNSIGNED NULL COMMENT 'NULL表示由配置或命令行授予',
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id),
+    INDEX idx_role_id (role_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT IGNORE INTO `roles` (name, description) VALUES
+    ('author', '普通上传者, 所有用户默认拥有'),
+    ('moderator', '内容审核'),
+    ('admin', '管理员');
+
+INSERT IGNORE INTO `permissions` (name, description) VALUES
+    ('axis.create', '上传和编辑自己的战斗轴'),
+    ('axis.moderate', '隐藏或删除任意战斗轴'),
+    ('user.manage', '停用, 封禁和恢复用户'),
+    ('role.manage', '分配和撤销角色');
+
+INSERT IGNORE INTO `role_permissions` (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+WHERE (r.name = 'author' AND p.name IN ('axis.create'))
+   OR (r.name = 'moderator' AND p.name IN ('axis.create', 'axis.moderate'))
+   OR (r.name = 'admin');
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `personal_access_tokens` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL COMMENT '明文前缀, 仅用于展示',
+    token_hash CHAR(64) NOT NULL COMMENT 'SHA-256(token)',
+    scopes JSON NOT NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_expire DATETIME NOT NULL,
+    time_last_used DATETIME NULL,
+    last_used_ip VARCHAR(45) NULL COMMENT '支持IPv6',
+    time_revoked DATETIME NULL,
+
+    UNIQUE INDEX idx_token_hash (token_hash),
+    INDEX idx_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
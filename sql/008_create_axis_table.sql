@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `axes` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    owner_id BIGINT UNSIGNED NOT NULL,
+
+    title VARCHAR(100) NOT NULL,
+    stage_id VARCHAR(32) NOT NULL COMMENT '关卡/区域编号, 例如 H11-3',
+    difficulty VARCHAR(20) NOT NULL COMMENT 'normal, hard, veryhard, hardcore, extreme, insane, torment',
+    description TEXT NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public' COMMENT 'public, unlisted, private',
+
+    is_deleted BOOLEAN DEFAULT FALSE,
+    time_deleted DATETIME NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_owner_id (owner_id),
+    INDEX idx_stage (stage_id, difficulty),
+    INDEX idx_visibility (visibility, is_deleted),
+    FOREIGN KEY (owner_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE `axes`
+    DROP COLUMN content,
+    DROP COLUMN format_version;
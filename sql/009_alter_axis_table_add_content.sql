@@ -0,0 +1,3 @@
+ALTER TABLE `axes`
+    ADD COLUMN format_version SMALLINT UNSIGNED NULL COMMENT '轴文件格式版本' AFTER visibility,
+    ADD COLUMN content MEDIUMTEXT NULL COMMENT '校验并规范化后的轴文件JSON' AFTER format_version;
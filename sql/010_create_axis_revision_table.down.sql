@@ -0,0 +1,20 @@
+-- 将每个轴最新已发布revision的内容放回axes, 其余revision丢弃
+ALTER TABLE `axes`
+    ADD COLUMN format_version SMALLINT UNSIGNED NULL COMMENT '轴文件格式版本' AFTER visibility,
+    ADD COLUMN content MEDIUMTEXT NULL COMMENT '校验并规范化后的轴文件JSON' AFTER format_version;
+
+UPDATE `axes` a JOIN `axis_revisions` r ON r.id = a.head_revision_id
+SET a.format_version = r.format_version, a.content = r.content;
+
+-- 外键名由 MySQL 自动生成, 需按列查出后再删除
+SET @fk_head = (SELECT CONSTRAINT_NAME FROM information_schema.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'axes' AND COLUMN_NAME = 'head_revision_id' AND REFERENCED_TABLE_NAME IS NOT NULL);
+SET @drop_fk = CONCAT('ALTER TABLE `axes` DROP FOREIGN KEY `', @fk_head, '`');
+PREPARE drop_fk FROM @drop_fk;
+EXECUTE drop_fk;
+DEALLOCATE PREPARE drop_fk;
+
+ALTER TABLE `axes`
+    DROP COLUMN head_revision_id;
+
+DROP TABLE IF EXISTS `axis_revisions`;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS `axis_revisions` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    axis_id BIGINT UNSIGNED NOT NULL,
+    revision_no INT UNSIGNED NOT NULL COMMENT '轴内递增编号, 从1开始',
+    author_id BIGINT UNSIGNED NOT NULL,
+
+    format_version SMALLINT UNSIGNED NOT NULL,
+    content MEDIUMTEXT NOT NULL COMMENT '校验并规范化后的轴文件JSON, 写入后不可修改',
+    content_hash CHAR(64) NOT NULL COMMENT 'SHA-256(content)',
+
+    changelog VARCHAR(500) NOT NULL DEFAULT '',
+    game_version VARCHAR(32) NOT NULL DEFAULT '' COMMENT '适用的游戏版本',
+    rollback_of INT UNSIGNED NULL COMMENT '回滚时指向被恢复的revision_no',
+
+    is_published BOOLEAN DEFAULT TRUE,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_published DATETIME NULL,
+
+    UNIQUE INDEX idx_axis_revision (axis_id, revision_no),
+    INDEX idx_content_hash (content_hash),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `axes`
+    ADD COLUMN head_revision_id BIGINT UNSIGNED NULL COMMENT '最新已发布revision' AFTER visibility,
+    ADD FOREIGN KEY fk_head_revision (head_revision_id) REFERENCES axis_revisions(id);
+
+-- 将009中直接保存在axes上的轴文件迁移为第1个revision
+INSERT INTO `axis_revisions` (axis_id, revision_no, author_id, format_version, content, content_hash, changelog, time_create, time_published)
+SELECT id, 1, owner_id, format_version, content, SHA2(content, 256), '', time_last_update, time_last_update
+FROM `axes` WHERE content IS NOT NULL;
+
+UPDATE `axes` a JOIN `axis_revisions` r ON r.axis_id = a.id AND r.revision_no = 1
+SET a.head_revision_id = r.id;
+
+ALTER TABLE `axes`
+    DROP COLUMN content,
+    DROP COLUMN format_version;
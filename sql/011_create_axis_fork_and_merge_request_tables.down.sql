@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS `axis_merge_request_comments`;
+DROP TABLE IF EXISTS `axis_merge_requests`;
+
+ALTER TABLE `axes` DROP FOREIGN KEY fk_axes_merge_base_revision;
+ALTER TABLE `axes` DROP COLUMN merge_base_revision_id;
+
+-- 外键名由 MySQL 自动生成, 需按列查出后再删除
+SET @fk_axis = (SELECT CONSTRAINT_NAME FROM information_schema.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'axes' AND COLUMN_NAME = 'forked_from_axis_id' AND REFERENCED_TABLE_NAME IS NOT NULL);
+SET @fk_revision = (SELECT CONSTRAINT_NAME FROM information_schema.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'axes' AND COLUMN_NAME = 'forked_from_revision_id' AND REFERENCED_TABLE_NAME IS NOT NULL);
+SET @drop_fk = CONCAT('ALTER TABLE `axes` DROP FOREIGN KEY `', @fk_axis, '`, DROP FOREIGN KEY `', @fk_revision, '`');
+PREPARE drop_fk FROM @drop_fk;
+EXECUTE drop_fk;
+DEALLOCATE PREPARE drop_fk;
+
+ALTER TABLE `axes`
+    DROP COLUMN forked_from_revision_id,
+    DROP COLUMN forked_from_axis_id;
@@ -0,0 +1,49 @@
+ALTER TABLE `axes`
+    ADD COLUMN forked_from_axis_id BIGINT UNSIGNED NULL COMMENT '来源轴' AFTER head_revision_id,
+    ADD COLUMN forked_from_revision_id BIGINT UNSIGNED NULL COMMENT 'fork时或最近一次快进合并时来源轴的revision' AFTER forked_from_axis_id,
+    ADD COLUMN merge_base_revision_id BIGINT UNSIGNED NULL COMMENT '最近一次三方合并时被合并的revision, 为空时以forked_from_revision_id为合并基准' AFTER forked_from_revision_id,
+    ADD INDEX idx_forked_from (forked_from_axis_id),
+    ADD FOREIGN KEY fk_forked_from_axis (forked_from_axis_id) REFERENCES axes(id),
+    ADD FOREIGN KEY fk_forked_from_revision (forked_from_revision_id) REFERENCES axis_revisions(id),
+    ADD CONSTRAINT fk_axes_merge_base_revision FOREIGN KEY (merge_base_revision_id) REFERENCES axis_revisions(id);
+
+CREATE TABLE IF NOT EXISTS `axis_merge_requests` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    target_axis_id BIGINT UNSIGNED NOT NULL,
+    source_axis_id BIGINT UNSIGNED NOT NULL,
+    base_revision_id BIGINT UNSIGNED NOT NULL COMMENT '三方合并的共同祖先',
+    author_id BIGINT UNSIGNED NOT NULL,
+
+    title VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'open' COMMENT 'open, merged, rejected, closed',
+
+    resolved_by BIGINT UNSIGNED NULL,
+    resolve_reason VARCHAR(500) NULL,
+    merged_revision_id BIGINT UNSIGNED NULL COMMENT '合并后在目标轴上生成的revision',
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    time_resolved DATETIME NULL,
+
+    INDEX idx_target_status (target_axis_id, status),
+    INDEX idx_source (source_axis_id),
+    FOREIGN KEY (target_axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (base_revision_id) REFERENCES axis_revisions(id),
+    FOREIGN KEY (author_id) REFERENCES users(id),
+    FOREIGN KEY (resolved_by) REFERENCES users(id),
+    FOREIGN KEY (merged_revision_id) REFERENCES axis_revisions(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `axis_merge_request_comments` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    merge_request_id BIGINT UNSIGNED NOT NULL,
+    author_id BIGINT UNSIGNED NOT NULL,
+    body TEXT NOT NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_merge_request_id (merge_request_id),
+    FOREIGN KEY (merge_request_id) REFERENCES axis_merge_requests(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `axis_students`;
+
+ALTER TABLE `axes`
+    DROP INDEX ft_title_description,
+    DROP INDEX idx_time_last_update,
+    DROP COLUMN download_count,
+    DROP COLUMN rating_score,
+    DROP COLUMN server;
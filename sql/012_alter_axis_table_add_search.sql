@@ -0,0 +1,24 @@
+ALTER TABLE `axes`
+    ADD COLUMN server VARCHAR(8) NOT NULL DEFAULT 'JP' COMMENT 'JP, Global, CN' AFTER difficulty,
+    ADD COLUMN rating_score DOUBLE NOT NULL DEFAULT 0 COMMENT '用于排序的评分' AFTER forked_from_revision_id,
+    ADD COLUMN download_count INT UNSIGNED NOT NULL DEFAULT 0 AFTER rating_score,
+    ADD INDEX idx_server (server),
+    ADD INDEX idx_rating_score (rating_score),
+    ADD INDEX idx_download_count (download_count),
+    ADD INDEX idx_time_last_update (time_last_update),
+    ADD FULLTEXT INDEX ft_title_description (title, description) WITH PARSER ngram;
+
+-- 最新已发布revision中编队的学生, 随head_revision_id一起更新
+CREATE TABLE IF NOT EXISTS `axis_students` (
+    axis_id BIGINT UNSIGNED NOT NULL,
+    student_id INT UNSIGNED NOT NULL,
+    PRIMARY KEY (axis_id, student_id),
+    INDEX idx_student_id (student_id),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT IGNORE INTO `axis_students` (axis_id, student_id)
+SELECT a.id, jt.student_id
+FROM `axes` a
+JOIN `axis_revisions` r ON r.id = a.head_revision_id,
+JSON_TABLE(r.content, '$.team.*[*]' COLUMNS (student_id INT UNSIGNED PATH '$.student_id')) jt;
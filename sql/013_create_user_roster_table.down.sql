@@ -0,0 +1,10 @@
+ALTER TABLE `axis_students`
+    DROP COLUMN min_star,
+    DROP COLUMN min_level,
+    DROP COLUMN min_ue_level,
+    DROP COLUMN min_skill_ex,
+    DROP COLUMN min_skill_basic,
+    DROP COLUMN min_skill_enhanced,
+    DROP COLUMN min_skill_sub;
+
+DROP TABLE IF EXISTS `user_students`;
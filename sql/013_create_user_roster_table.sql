@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS `user_students` (
+    user_id BIGINT UNSIGNED NOT NULL,
+    student_id INT UNSIGNED NOT NULL,
+
+    star TINYINT UNSIGNED NOT NULL DEFAULT 1,
+    level TINYINT UNSIGNED NOT NULL DEFAULT 1,
+    ue_level TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '专武等级, 0表示未解锁',
+    skill_ex TINYINT UNSIGNED NOT NULL DEFAULT 1,
+    skill_basic TINYINT UNSIGNED NOT NULL DEFAULT 1,
+    skill_enhanced TINYINT UNSIGNED NOT NULL DEFAULT 1,
+    skill_sub TINYINT UNSIGNED NOT NULL DEFAULT 1,
+
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, student_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `axis_students`
+    ADD COLUMN min_star TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN min_level TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN min_ue_level TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN min_skill_ex TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN min_skill_basic TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN min_skill_enhanced TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN min_skill_sub TINYINT UNSIGNED NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `catalog_imports`;
+DROP TABLE IF EXISTS `catalog_stages`;
+DROP TABLE IF EXISTS `catalog_bosses`;
+DROP TABLE IF EXISTS `catalog_students`;
+DROP TABLE IF EXISTS `catalog_servers`;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS `catalog_servers` (
+    code VARCHAR(16) PRIMARY KEY COMMENT '服务器区域代码, 例如 JP',
+    name VARCHAR(64) NOT NULL,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `catalog_students` (
+    id INT UNSIGNED PRIMARY KEY COMMENT '游戏内学生编号',
+    name VARCHAR(64) NOT NULL,
+    school VARCHAR(32) NOT NULL DEFAULT '',
+    role VARCHAR(10) NOT NULL COMMENT 'striker, special',
+    rarity TINYINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '初始星级',
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_role (role)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `catalog_bosses` (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `catalog_stages` (
+    id VARCHAR(32) PRIMARY KEY COMMENT '与 axes.stage_id 对应, 例如 H11-3',
+    area VARCHAR(32) NOT NULL COMMENT '所属区域, 例如 11',
+    name VARCHAR(64) NOT NULL,
+    type VARCHAR(20) NOT NULL COMMENT 'mission, raid, event ...',
+    boss_id VARCHAR(32) NULL,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_area (area),
+    INDEX idx_boss_id (boss_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `catalog_imports` (
+    kind VARCHAR(16) PRIMARY KEY COMMENT 'servers, students, bosses, stages',
+    version INT UNSIGNED NOT NULL COMMENT '数据文件版本号, 只导入更高的版本',
+    file VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL COMMENT 'SHA-256',
+    item_count INT UNSIGNED NOT NULL,
+    time_import DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
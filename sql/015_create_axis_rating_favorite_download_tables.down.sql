@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `axis_downloads`;
+DROP TABLE IF EXISTS `axis_favorites`;
+DROP TABLE IF EXISTS `axis_ratings`;
+
+ALTER TABLE `axes`
+    DROP COLUMN favorite_count,
+    DROP COLUMN rating_sum,
+    DROP COLUMN rating_count;
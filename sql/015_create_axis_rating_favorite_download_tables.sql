@@ -0,0 +1,41 @@
+ALTER TABLE `axes`
+    ADD COLUMN rating_count INT UNSIGNED NOT NULL DEFAULT 0 AFTER rating_score,
+    ADD COLUMN rating_sum INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '评分总和, 与 rating_count 一起计算平均分' AFTER rating_count,
+    ADD COLUMN favorite_count INT UNSIGNED NOT NULL DEFAULT 0 AFTER download_count;
+
+CREATE TABLE IF NOT EXISTS `axis_ratings` (
+    axis_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    score TINYINT UNSIGNED NOT NULL COMMENT '1-5',
+    comment VARCHAR(1000) NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (axis_id, user_id),
+    INDEX idx_user_id (user_id),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `axis_favorites` (
+    user_id BIGINT UNSIGNED NOT NULL,
+    axis_id BIGINT UNSIGNED NOT NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, axis_id),
+    INDEX idx_axis_id (axis_id),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 下载去重, 同一用户或IP在去重窗口内多次下载只计一次
+CREATE TABLE IF NOT EXISTS `axis_downloads` (
+    axis_id BIGINT UNSIGNED NOT NULL,
+    viewer_key CHAR(64) NOT NULL COMMENT 'SHA-256("user:<id>") 或 SHA-256("ip:<ip>")',
+    time_last_count DATETIME NOT NULL COMMENT '最近一次计数的时间',
+
+    PRIMARY KEY (axis_id, viewer_key),
+    INDEX idx_time_last_count (time_last_count),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS `axis_comments` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    axis_id BIGINT UNSIGNED NOT NULL,
+    revision_id BIGINT UNSIGNED NULL COMMENT '针对某个revision的评论, NULL表示针对整个轴',
+    parent_id BIGINT UNSIGNED NULL COMMENT '回复的评论',
+    root_id BIGINT UNSIGNED NULL COMMENT '所在楼层的顶层评论, 顶层评论为NULL',
+    depth TINYINT UNSIGNED NOT NULL DEFAULT 0,
+    author_id BIGINT UNSIGNED NOT NULL,
+
+    body TEXT NOT NULL COMMENT 'Markdown 原文',
+    body_html TEXT NOT NULL COMMENT '渲染并过滤后的HTML',
+
+    status TINYINT NOT NULL DEFAULT 1 COMMENT '1: visible, 2: hidden by moderator, 3: deleted',
+    edit_count INT UNSIGNED NOT NULL DEFAULT 0,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_edited DATETIME NULL,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_axis_root (axis_id, root_id, id),
+    INDEX idx_root_id (root_id),
+    INDEX idx_author_id (author_id),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (revision_id) REFERENCES axis_revisions(id),
+    FOREIGN KEY (parent_id) REFERENCES axis_comments(id),
+    FOREIGN KEY (author_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 评论编辑历史, 每次编辑保存被替换掉的版本
+CREATE TABLE IF NOT EXISTS `axis_comment_edits` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    comment_id BIGINT UNSIGNED NOT NULL,
+    body TEXT NOT NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '被替换的时间',
+
+    INDEX idx_comment_id (comment_id),
+    FOREIGN KEY (comment_id) REFERENCES axis_comments(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `audit_log` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    actor_id BIGINT UNSIGNED NOT NULL,
+    action VARCHAR(50) NOT NULL COMMENT '例如 comment.hide',
+    target_type VARCHAR(30) NOT NULL,
+    target_id BIGINT UNSIGNED NOT NULL,
+    reason VARCHAR(500) NULL,
+    details JSON NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_target (target_type, target_id),
+    INDEX idx_actor_id (actor_id),
+    INDEX idx_action (action),
+    FOREIGN KEY (actor_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
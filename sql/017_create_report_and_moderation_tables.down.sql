@@ -0,0 +1,9 @@
+-- 系统自动操作的审计记录没有 actor_id, 需先处理这些记录, 否则此处失败且不做任何改动
+ALTER TABLE `audit_log`
+    MODIFY COLUMN actor_id BIGINT UNSIGNED NOT NULL;
+
+DROP TABLE IF EXISTS `reports`;
+DROP TABLE IF EXISTS `moderation_cases`;
+
+ALTER TABLE `axes`
+    DROP COLUMN is_hidden;
@@ -0,0 +1,49 @@
+ALTER TABLE `axes`
+    ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE COMMENT '被管理员或自动举报处理隐藏' AFTER visibility;
+
+-- 自动处理(例如举报达到阈值后自动隐藏)没有操作人
+ALTER TABLE `audit_log`
+    MODIFY COLUMN actor_id BIGINT UNSIGNED NULL COMMENT 'NULL表示系统自动操作';
+
+-- 同一内容的举报归入一个处理单, 处理完成前只有一个未结单
+CREATE TABLE IF NOT EXISTS `moderation_cases` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL COMMENT 'axis, comment',
+    target_id BIGINT UNSIGNED NOT NULL,
+    open_target VARCHAR(64) NULL COMMENT '未结单时为 <target_type>:<target_id>, 结单后为NULL, 用于保证唯一',
+
+    status VARCHAR(10) NOT NULL DEFAULT 'open' COMMENT 'open, claimed, resolved',
+    report_count INT UNSIGNED NOT NULL DEFAULT 0,
+    auto_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+
+    claimed_by BIGINT UNSIGNED NULL,
+    time_claimed DATETIME NULL,
+
+    resolution VARCHAR(10) NULL COMMENT 'dismiss, hide, delete',
+    resolution_reason VARCHAR(500) NULL,
+    resolved_by BIGINT UNSIGNED NULL,
+    time_resolved DATETIME NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_open_target (open_target),
+    INDEX idx_target (target_type, target_id),
+    INDEX idx_status (status, report_count),
+    FOREIGN KEY (claimed_by) REFERENCES users(id),
+    FOREIGN KEY (resolved_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `reports` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    case_id BIGINT UNSIGNED NOT NULL,
+    reporter_id BIGINT UNSIGNED NOT NULL,
+    reason VARCHAR(20) NOT NULL COMMENT 'spam, stolen, broken, offensive',
+    details VARCHAR(1000) NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_case_reporter (case_id, reporter_id),
+    INDEX idx_reporter_id (reporter_id),
+    FOREIGN KEY (case_id) REFERENCES moderation_cases(id) ON DELETE CASCADE,
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `axis_clears`;
+
+ALTER TABLE `axes`
+    DROP COLUMN verified_clear_count;
@@ -0,0 +1,32 @@
+ALTER TABLE `axes`
+    ADD COLUMN verified_clear_count INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '已被管理员确认的通关记录数' AFTER favorite_count;
+
+-- 用户提交的通关记录, 对应某个已发布的revision
+CREATE TABLE IF NOT EXISTS `axis_clears` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    axis_id BIGINT UNSIGNED NOT NULL,
+    revision_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+
+    evidence_url VARCHAR(500) NOT NULL COMMENT '截图或视频链接',
+    score BIGINT UNSIGNED NULL,
+    clear_time_ms INT UNSIGNED NULL COMMENT '通关用时, 毫秒',
+    server VARCHAR(8) NOT NULL COMMENT '账号所在服务器',
+    note VARCHAR(500) NULL,
+
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' COMMENT 'pending, verified, rejected',
+    reviewed_by BIGINT UNSIGNED NULL,
+    review_reason VARCHAR(500) NULL,
+    time_reviewed DATETIME NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_revision_user (revision_id, user_id),
+    INDEX idx_axis_status (axis_id, status),
+    INDEX idx_user_id (user_id),
+    INDEX idx_status (status),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (revision_id) REFERENCES axis_revisions(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (reviewed_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `collection_items`;
+DROP TABLE IF EXISTS `collections`;
@@ -0,0 +1,35 @@
+-- 合集: 按顺序排列的一组轴revision, 例如一整个活动或总力战轮换
+CREATE TABLE IF NOT EXISTS `collections` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    owner_id BIGINT UNSIGNED NOT NULL,
+
+    title VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public' COMMENT 'public, unlisted, private',
+    forked_from_collection_id BIGINT UNSIGNED NULL,
+
+    is_deleted BOOLEAN DEFAULT FALSE,
+    time_deleted DATETIME NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_owner_id (owner_id),
+    INDEX idx_visibility (visibility, is_deleted),
+    FOREIGN KEY (owner_id) REFERENCES users(id),
+    FOREIGN KEY (forked_from_collection_id) REFERENCES collections(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `collection_items` (
+    collection_id BIGINT UNSIGNED NOT NULL,
+    position SMALLINT UNSIGNED NOT NULL COMMENT '从1开始的顺序',
+    axis_id BIGINT UNSIGNED NOT NULL,
+    revision_id BIGINT UNSIGNED NOT NULL,
+    note VARCHAR(500) NULL,
+
+    PRIMARY KEY (collection_id, position),
+    INDEX idx_axis_id (axis_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (revision_id) REFERENCES axis_revisions(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
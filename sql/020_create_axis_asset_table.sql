@@ -0,0 +1,13 @@
+-- 轴附带的资源文件(截图, 示意图等), 随.baasaxis包导入导出
+CREATE TABLE IF NOT EXISTS `axis_assets` (
+    axis_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL COMMENT '文件名, 不含路径',
+    content LONGBLOB NOT NULL,
+    content_hash CHAR(64) NOT NULL COMMENT 'SHA-256(content)',
+    size INT UNSIGNED NOT NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (axis_id, name),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `axis_changes`;
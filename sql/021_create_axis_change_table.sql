@@ -0,0 +1,18 @@
+-- 轴的变更记录, 供BAAS客户端按游标增量同步
+CREATE TABLE IF NOT EXISTS `axis_changes` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY COMMENT '同步游标',
+    axis_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NULL COMMENT '只属于该用户的变更, 例如收藏和取消收藏',
+    kind VARCHAR(16) NOT NULL COMMENT 'created, updated, deleted, revision, subscribed, unsubscribed',
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_axis_id (axis_id),
+    INDEX idx_user_id (user_id),
+    INDEX idx_time_create (time_create),
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 已有的轴作为第一次同步的内容
+INSERT INTO `axis_changes` (axis_id, kind, time_create)
+SELECT id, IF(is_deleted, 'deleted', 'created'), time_create FROM `axes` ORDER BY id;
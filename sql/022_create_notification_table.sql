@@ -0,0 +1,12 @@
+-- 推送给用户的事件, id即SSE的事件id, 断线重连时按Last-Event-ID补发
+CREATE TABLE IF NOT EXISTS `notifications` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    type VARCHAR(32) NOT NULL COMMENT 'axis.revision, axis.comment, axis.mention, merge_request, moderation, ...',
+    data JSON NOT NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_user_id (user_id, id),
+    INDEX idx_time_create (time_create),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
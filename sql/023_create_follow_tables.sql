@@ -0,0 +1,25 @@
+-- 关注的作者, 关卡或学生, 用于邮件摘要
+CREATE TABLE IF NOT EXISTS `follows` (
+    user_id BIGINT UNSIGNED NOT NULL,
+    target_type VARCHAR(10) NOT NULL COMMENT 'author, stage, student',
+    target_id VARCHAR(32) NOT NULL COMMENT '作者的用户id, 关卡id或学生id',
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, target_type, target_id),
+    INDEX idx_target (target_type, target_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `digest_settings` (
+    user_id BIGINT UNSIGNED PRIMARY KEY,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'weekly' COMMENT 'off, daily, weekly',
+    unsubscribe_token VARCHAR(64) NOT NULL COMMENT '邮件中退订链接使用, 无需登录',
+    time_last_sent DATETIME NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_unsubscribe_token (unsubscribe_token),
+    INDEX idx_frequency (frequency, time_last_sent),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
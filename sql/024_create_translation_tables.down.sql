@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `catalog_names`;
+DROP TABLE IF EXISTS `axis_translations`;
+
+ALTER TABLE `axes`
+    DROP COLUMN language;
@@ -0,0 +1,27 @@
+-- 标题和简介的原始语言, 空字符串表示使用配置中的默认语言
+ALTER TABLE `axes`
+    ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT '' COMMENT 'en, ja, zh' AFTER description;
+
+CREATE TABLE IF NOT EXISTS `axis_translations` (
+    axis_id BIGINT UNSIGNED NOT NULL,
+    language VARCHAR(8) NOT NULL COMMENT 'en, ja, zh',
+    title VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+    time_last_update DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (axis_id, language),
+    FULLTEXT INDEX ft_title_description (title, description) WITH PARSER ngram,
+    FOREIGN KEY (axis_id) REFERENCES axes(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 目录数据文件中 names 字段的翻译, name 列保存数据文件的默认名称
+CREATE TABLE IF NOT EXISTS `catalog_names` (
+    kind VARCHAR(16) NOT NULL COMMENT 'servers, students, bosses, stages',
+    id VARCHAR(32) NOT NULL,
+    language VARCHAR(8) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+
+    PRIMARY KEY (kind, id, language)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1 @@
+ALTER TABLE `axis_revisions` DROP COLUMN storage_key;
@@ -0,0 +1,3 @@
+-- revision 写入时同时存入存储, 下载链接只读取这里记录的 key; 已有的 revision 由启动时的补写任务填充
+ALTER TABLE `axis_revisions`
+    ADD COLUMN storage_key VARCHAR(512) NULL COMMENT '内容在存储中的key, 按内容寻址' AFTER content_hash;
@@ -0,0 +1,16 @@
+-- 用户上传的文件, 内容保存在 blob 存储中, 相同内容共用一个 storage_key
+CREATE TABLE IF NOT EXISTS `uploads` (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    public_id CHAR(32) NOT NULL COMMENT '随机生成, 出现在上传地址中, 避免被逐个遍历',
+    user_id BIGINT UNSIGNED NOT NULL,
+    kind VARCHAR(16) NOT NULL COMMENT 'avatar, screenshot',
+    storage_key VARCHAR(512) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size BIGINT UNSIGNED NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_public_id (public_id),
+    INDEX idx_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,17 @@
+-- 断点续传中的上传, 已接收的数据保存在本机 resumable 目录, 完成后转为 uploads 记录
+CREATE TABLE IF NOT EXISTS `resumable_uploads` (
+    id CHAR(32) PRIMARY KEY COMMENT '随机生成, 出现在上传地址中',
+    user_id BIGINT UNSIGNED NOT NULL,
+    kind VARCHAR(16) NOT NULL COMMENT 'bundle, video',
+    content_type VARCHAR(64) NOT NULL DEFAULT '' COMMENT '客户端声明的类型',
+    length BIGINT UNSIGNED NOT NULL,
+    `offset` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    upload_id BIGINT UNSIGNED NULL COMMENT '完成后对应的 uploads.id',
+    time_expire DATETIME NOT NULL,
+    time_create DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_user_id (user_id),
+    INDEX idx_time_expire (time_expire),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (upload_id) REFERENCES uploads(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;